@@ -1,6 +1,7 @@
 package btc
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"math/big"
@@ -9,6 +10,7 @@ import (
 	"github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/btc"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/keystore"
 	"github.com/republicprotocol/renex-swapper-go/domains/order"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
 	"github.com/republicprotocol/renex-swapper-go/services/swap"
 )
 
@@ -26,6 +28,7 @@ type BitcoinData struct {
 	RedeemTxHash   [32]byte `json:"redeem_tx_hash"`
 	RedeemTx       []byte   `json:"redeem_tx"`
 	SecretHash     [32]byte `json:"secret_hash"`
+	Refunded       bool     `json:"refunded"`
 }
 
 // BitcoinAtom is a struct for Bitcoin Atom
@@ -100,7 +103,32 @@ func (atom *BitcoinAtom) Refund() error {
 	if err != nil {
 		return err
 	}
-	return bindings.Refund(atom.connection, string(from), atom.data.Contract, atom.data.ContractTx)
+	if err := bindings.Refund(atom.connection, string(from), atom.data.Contract, atom.data.ContractTx); err != nil {
+		return err
+	}
+	atom.data.Refunded = true
+	return nil
+}
+
+// Refundable checks on Bitcoin whether the Atom swap can be refunded
+func (atom *BitcoinAtom) Refundable() (bool, error) {
+	return bindings.Refundable(atom.connection, atom.data.Contract, atom.data.ContractTx)
+}
+
+// Spent checks on Bitcoin whether the Atom swap has been redeemed or refunded
+func (atom *BitcoinAtom) Spent() (bool, error) {
+	return bindings.Spent(atom.connection, atom.data.Contract, atom.data.ContractTx)
+}
+
+// RedeemedSecret returns the secret that the Atom swap was redeemed with on
+// Bitcoin, if it has been redeemed
+func (atom *BitcoinAtom) RedeemedSecret() ([32]byte, bool, error) {
+	return bindings.RedeemedSecret(atom.connection, atom.data.Contract, atom.data.ContractTx)
+}
+
+// Expiry returns the lock time of the Atom swap
+func (atom *BitcoinAtom) Expiry() (int64, error) {
+	return bindings.Expiry(atom.data.Contract)
 }
 
 // Audit an Atom swap by calling a function on Bitcoin
@@ -138,6 +166,23 @@ func (atom *BitcoinAtom) Deserialize(data []byte) error {
 	return json.Unmarshal(data, &atom.data)
 }
 
+// Transactions returns the hashes of the transactions submitted by the atom
+func (atom *BitcoinAtom) Transactions() swapDomain.Transactions {
+	txs := swapDomain.Transactions{
+		Contract: atom.data.ContractHash,
+	}
+	if len(atom.data.ContractTxHash) > 0 {
+		txs.Initiate = hex.EncodeToString(atom.data.ContractTxHash)
+	}
+	if atom.data.RedeemTxHash != [32]byte{} {
+		txs.Redeem = hex.EncodeToString(atom.data.RedeemTxHash[:])
+	}
+	if atom.data.Refunded {
+		txs.Refund = hex.EncodeToString(atom.data.RefundTxHash[:])
+	}
+	return txs
+}
+
 // PriorityCode returns the priority code of the currency.
 func (atom *BitcoinAtom) PriorityCode() uint32 {
 	return atom.key.PriorityCode()
@@ -3,6 +3,7 @@ package eth
 import (
 	"context"
 	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"math/big"
 	"time"
@@ -13,6 +14,7 @@ import (
 	ethclient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/eth"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/keystore"
 	"github.com/republicprotocol/renex-swapper-go/domains/order"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
 	"github.com/republicprotocol/renex-swapper-go/services/swap"
 )
 
@@ -22,8 +24,11 @@ type Adapter interface {
 
 // EthereumData
 type EthereumData struct {
-	SwapID   [32]byte `json:"swap_id"`
-	HashLock [32]byte `json:"hash_lock"`
+	SwapID         [32]byte `json:"swap_id"`
+	HashLock       [32]byte `json:"hash_lock"`
+	InitiateTxHash string   `json:"initiate_tx_hash,omitempty"`
+	RedeemTxHash   string   `json:"redeem_tx_hash,omitempty"`
+	RefundTxHash   string   `json:"refund_tx_hash,omitempty"`
 }
 
 type EthereumAtom struct {
@@ -74,6 +79,7 @@ func (atom *EthereumAtom) Initiate(to []byte, hash [32]byte, value *big.Int, exp
 	if err != nil {
 		return err
 	}
+	atom.data.InitiateTxHash = tx.Hash().Hex()
 	_, err = atom.client.PatchedWaitMined(atom.context, tx)
 	return err
 }
@@ -88,6 +94,7 @@ func (atom *EthereumAtom) Redeem(secret [32]byte) error {
 	auth.GasLimit = 3000000
 	tx, err := atom.binding.Redeem(auth, atom.data.SwapID, secret)
 	if err == nil {
+		atom.data.RedeemTxHash = tx.Hash().Hex()
 		_, err = atom.client.PatchedWaitMined(atom.context, tx)
 	}
 	return err
@@ -115,11 +122,60 @@ func (atom *EthereumAtom) Refund() error {
 	auth.GasLimit = 3000000
 	tx, err := atom.binding.Refund(auth, atom.data.SwapID)
 	if err == nil {
+		atom.data.RefundTxHash = tx.Hash().Hex()
 		_, err = atom.client.PatchedWaitMined(atom.context, tx)
 	}
 	return err
 }
 
+// Refundable checks on ethereum whether the Atom swap can be refunded
+func (atom *EthereumAtom) Refundable() (bool, error) {
+	return atom.binding.Refundable(&bind.CallOpts{}, atom.data.SwapID)
+}
+
+// Spent checks on ethereum whether the Atom swap has been redeemed or refunded
+func (atom *EthereumAtom) Spent() (bool, error) {
+	initiatable, err := atom.binding.Initiatable(&bind.CallOpts{}, atom.data.SwapID)
+	if err != nil || initiatable {
+		return false, err
+	}
+	redeemable, err := atom.binding.Redeemable(&bind.CallOpts{}, atom.data.SwapID)
+	if err != nil || redeemable {
+		return false, err
+	}
+	refundable, err := atom.Refundable()
+	if err != nil {
+		return false, err
+	}
+	return !refundable, nil
+}
+
+// RedeemedSecret returns the secret that the Atom swap was redeemed with on
+// ethereum, if it has been redeemed
+func (atom *EthereumAtom) RedeemedSecret() ([32]byte, bool, error) {
+	redeemedAt, err := atom.binding.RedeemedAt(&bind.CallOpts{}, atom.data.SwapID)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+	if redeemedAt.Sign() == 0 {
+		return [32]byte{}, false, nil
+	}
+	secret, err := atom.binding.AuditSecret(&bind.CallOpts{}, atom.data.SwapID)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+	return secret, true, nil
+}
+
+// Expiry returns the time lock of the Atom swap on ethereum
+func (atom *EthereumAtom) Expiry() (int64, error) {
+	auditReport, err := atom.binding.Audit(&bind.CallOpts{}, atom.data.SwapID)
+	if err != nil {
+		return 0, err
+	}
+	return auditReport.Timelock.Int64(), nil
+}
+
 // Audit an Atom swap by calling a function on ethereum
 func (atom *EthereumAtom) Audit() ([32]byte, []byte, *big.Int, int64, error) {
 	details, err := atom.adapter.ReceiveSwapDetails(atom.orderID, time.Now().Add(15*time.Minute).Unix())
@@ -179,6 +235,16 @@ func (atom *EthereumAtom) Deserialize(data []byte) error {
 	return json.Unmarshal(data, &atom.data)
 }
 
+// Transactions returns the hashes of the transactions submitted by the atom
+func (atom *EthereumAtom) Transactions() swapDomain.Transactions {
+	return swapDomain.Transactions{
+		Contract: "0x" + hex.EncodeToString(atom.data.SwapID[:]),
+		Initiate: atom.data.InitiateTxHash,
+		Redeem:   atom.data.RedeemTxHash,
+		Refund:   atom.data.RefundTxHash,
+	}
+}
+
 // GetFromAddress returns the address of the sender
 func (atom *EthereumAtom) GetFromAddress() ([]byte, error) {
 	return atom.key.GetAddress()
@@ -19,6 +19,7 @@ import (
 	"github.com/republicprotocol/renex-swapper-go/domains/match"
 	"github.com/republicprotocol/renex-swapper-go/domains/order"
 	"github.com/republicprotocol/renex-swapper-go/domains/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/errors"
 )
 
 // Binder implements all methods that will communicate with the smart contracts
@@ -129,7 +130,8 @@ func (binder *Binder) slashBond(guiltyOrderID order.ID) error {
 
 // CheckForMatch checks if a match is found and returns the match object. If
 // a match is not found and the 'wait' flag is set to true, it loops until a
-// match is found.
+// match is found. It returns ErrOrderCancelled or ErrOrderExpired if the order
+// can no longer be matched.
 func (binder *Binder) CheckForMatch(orderID order.ID, wait bool) (match.Match, error) {
 	for {
 		status, err := binder.OrderStatus(binder.callOpts, orderID)
@@ -144,22 +146,24 @@ func (binder *Binder) CheckForMatch(orderID order.ID, wait bool) (match.Match, e
 			return match.NewMatch(PersonalOrder, ForeignOrder, SendValue, ReceiveValue, SendCurrency, ReceiveCurrency), nil
 		}
 
-		if !wait {
-			return nil, fmt.Errorf("Match does not exist")
+		cancelled, err := binder.cancelled(orderID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the order state: %v", err)
+		}
+		if cancelled {
+			return nil, errors.ErrOrderCancelled
 		}
 
 		expired, err := binder.expired(orderID)
-
 		if err != nil {
 			return nil, fmt.Errorf("Failed to get match details")
 		}
-
-		// if cancelled := binder.cancelled(orderID); cancelled {
-		// 	return nil, fmt.Errorf("Order cancelled")
-		// }
-
 		if expired {
-			return nil, fmt.Errorf("Order expired")
+			return nil, errors.ErrOrderExpired
+		}
+
+		if !wait {
+			return nil, errors.ErrMatchNotFound
 		}
 
 		time.Sleep(15 * time.Second)
@@ -177,15 +181,12 @@ func (binder *Binder) expired(orderID order.ID) (bool, error) {
 	return false, nil
 }
 
-func (binder *Binder) cancelled(orderID order.ID) bool {
+func (binder *Binder) cancelled(orderID order.ID) (bool, error) {
 	state, err := binder.Orderbook.OrderState(binder.callOpts, orderID)
 	if err != nil {
-		return false
-	}
-	if state == 3 {
-		return true
+		return false, err
 	}
-	return false
+	return state == 3, nil
 }
 
 // SendSwapDetails stores the swap details on the ethereum blockchain
@@ -383,6 +383,128 @@ func Refund(connection btc.Conn, myAddress string, contract, contractTxBytes []b
 	return nil
 }
 
+// Refundable returns true if the contract has not been redeemed or refunded,
+// and its lock time is before the median time of the latest blocks, so that
+// a refund would be accepted into the next block.
+func Refundable(connection btc.Conn, contract, contractTxBytes []byte) (bool, error) {
+	var contractTx wire.MsgTx
+	err := contractTx.Deserialize(bytes.NewReader(contractTxBytes))
+	if err != nil {
+		return false, fmt.Errorf("failed to decode contract transaction: %v", err)
+	}
+
+	pushes, err := txscript.ExtractAtomicSwapDataPushes(0, contract)
+	if err != nil {
+		return false, err
+	}
+	if pushes == nil {
+		return false, errors.New("contract is not an atomic swap script recognized by this tool")
+	}
+
+	contractOut, err := contractOutput(connection, contract, &contractTx)
+	if err != nil {
+		return false, err
+	}
+
+	contractTxHash := contractTx.TxHash()
+	unspent, err := connection.Unspent(&contractTxHash, contractOut)
+	if err != nil || !unspent {
+		return false, err
+	}
+
+	info, err := connection.ChainInfo()
+	if err != nil {
+		return false, err
+	}
+	return info.MedianTime > pushes.LockTime, nil
+}
+
+// Spent returns true if the contract has been redeemed or refunded, including
+// by a transaction in the mempool.
+func Spent(connection btc.Conn, contract, contractTxBytes []byte) (bool, error) {
+	var contractTx wire.MsgTx
+	err := contractTx.Deserialize(bytes.NewReader(contractTxBytes))
+	if err != nil {
+		return false, fmt.Errorf("failed to decode contract transaction: %v", err)
+	}
+
+	contractOut, err := contractOutput(connection, contract, &contractTx)
+	if err != nil {
+		return false, err
+	}
+
+	contractTxHash := contractTx.TxHash()
+	unspent, err := connection.Unspent(&contractTxHash, contractOut)
+	if err != nil {
+		return false, err
+	}
+	return !unspent, nil
+}
+
+// RedeemedSecret returns the secret that the contract was redeemed with, and
+// false if it has not been redeemed. A contract that has been refunded has
+// not been redeemed.
+func RedeemedSecret(connection btc.Conn, contract, contractTxBytes []byte) ([32]byte, bool, error) {
+	var contractTx wire.MsgTx
+	err := contractTx.Deserialize(bytes.NewReader(contractTxBytes))
+	if err != nil {
+		return [32]byte{}, false, fmt.Errorf("failed to decode contract transaction: %v", err)
+	}
+
+	pushes, err := txscript.ExtractAtomicSwapDataPushes(0, contract)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+	if pushes == nil {
+		return [32]byte{}, false, errors.New("contract is not an atomic swap script recognized by this tool")
+	}
+
+	contractOut, err := contractOutput(connection, contract, &contractTx)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+
+	contractTxHash := contractTx.TxHash()
+	spender, err := connection.Spender(&contractTxHash, contractOut)
+	if err != nil || spender == nil {
+		return [32]byte{}, false, err
+	}
+
+	secret, err := extractSecret(spender, pushes.SecretHash[:])
+	if err != nil {
+		return [32]byte{}, false, nil
+	}
+	return secret, true, nil
+}
+
+// contractOutput returns the index of the contract's output in the contract
+// transaction.
+func contractOutput(connection btc.Conn, contract []byte, contractTx *wire.MsgTx) (uint32, error) {
+	contractHash160 := btcutil.Hash160(contract)
+	for i, out := range contractTx.TxOut {
+		sc, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript, connection.ChainParams)
+		if err != nil || sc != txscript.ScriptHashTy {
+			continue
+		}
+		if bytes.Equal(addrs[0].(*btcutil.AddressScriptHash).Hash160()[:], contractHash160) {
+			return uint32(i), nil
+		}
+	}
+	return 0, errors.New("transaction does not contain the contract output")
+}
+
+// Expiry returns the lock time of the contract.
+func Expiry(contract []byte) (int64, error) {
+	pushes, err := txscript.ExtractAtomicSwapDataPushes(0, contract)
+	if err != nil {
+		return 0, err
+	}
+	if pushes == nil {
+		return 0, errors.New("contract is not an atomic swap script recognized by this tool")
+	}
+	return pushes.LockTime, nil
+}
+
 func Audit(connection btc.Conn, contract, contractTxBytes []byte) (readResult, error) {
 
 	var contractTx wire.MsgTx
@@ -447,7 +569,12 @@ func AuditSecret(connection btc.Conn, redemptionTxBytes, secretHash []byte) ([32
 	if err != nil {
 		return [32]byte{}, fmt.Errorf("failed to decode redemption transaction: %v", err)
 	}
+	return extractSecret(&redemptionTx, secretHash)
+}
 
+// extractSecret returns the secret that the redemption transaction pushed,
+// which hashes to the secret hash.
+func extractSecret(redemptionTx *wire.MsgTx, secretHash []byte) ([32]byte, error) {
 	if len(secretHash) != sha256.Size {
 		return [32]byte{}, errors.New("secret hash has wrong size")
 	}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/txscript"
@@ -18,12 +19,36 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/network"
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
 )
 
+// reorgDepth is how many of the blocks that were scanned for the spender of
+// an output are scanned again, in case they were replaced by a reorg.
+const reorgDepth = 6
+
 type Conn struct {
 	Client      *rpc.Client
 	ChainParams *chaincfg.Params
 	Network     string
+
+	spenders *spenderCache
+}
+
+// spenderCache remembers the spenders that were found in blocks, and the
+// last block that was scanned for the spender of each output, so that the
+// blocks are not scanned again each time a spender is looked for.
+type spenderCache struct {
+	mu       *sync.Mutex
+	spenders map[wire.OutPoint]*wire.MsgTx
+	scanned  map[wire.OutPoint]int64
+}
+
+func newSpenderCache() *spenderCache {
+	return &spenderCache{
+		mu:       new(sync.Mutex),
+		spenders: map[wire.OutPoint]*wire.MsgTx{},
+		scanned:  map[wire.OutPoint]int64{},
+	}
 }
 
 func Connect(networkConfig network.Config) (Conn, error) {
@@ -79,6 +104,7 @@ func ConnectWithParams(chain, url, user, password string) (Conn, error) {
 		Client:      rpcClient,
 		ChainParams: chainParams,
 		Network:     chain,
+		spenders:    newSpenderCache(),
 	}, nil
 }
 
@@ -91,7 +117,9 @@ func (conn *Conn) FundRawTransaction(tx *wire.MsgTx) (fundedTx *wire.MsgTx, err
 		return nil, err
 	}
 	params := []json.RawMessage{param0}
+	start := time.Now()
 	rawResp, err := conn.Client.RawRequest("fundrawtransaction", params)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "fundrawtransaction", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -116,9 +144,52 @@ func (conn *Conn) FundRawTransaction(tx *wire.MsgTx) (fundedTx *wire.MsgTx, err
 	return fundedTx, nil
 }
 
+// Transfer sends value satoshis from an address in the node's wallet to
+// another address, returning the change to the sender, and returns the hash
+// of the transaction.
+func (conn *Conn) Transfer(from, to string, value int64) (string, error) {
+	fromAddr, err := btcutil.DecodeAddress(from, conn.ChainParams)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the sender's address: %v", err)
+	}
+	toAddr, err := btcutil.DecodeAddress(to, conn.ChainParams)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the destination address: %v", err)
+	}
+	if !toAddr.IsForNet(conn.ChainParams) {
+		return "", fmt.Errorf("destination address is not intended for use on %v", conn.ChainParams.Name)
+	}
+	script, err := txscript.PayToAddrScript(toAddr)
+	if err != nil {
+		return "", err
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(value, script))
+	tx, _, err = conn.FundTransaction(tx, []btcutil.Address{fromAddr})
+	if err != nil {
+		return "", err
+	}
+	signedTx, complete, err := conn.SignTransaction(tx)
+	if err != nil {
+		return "", fmt.Errorf("signrawtransaction: %v", err)
+	}
+	if !complete {
+		return "", fmt.Errorf("signrawtransaction: failed to completely sign the transaction")
+	}
+
+	txHash, err := conn.PromptPublishTx(signedTx, "transfer")
+	if err != nil {
+		return "", err
+	}
+	return txHash.String(), nil
+}
+
 func (conn *Conn) PromptPublishTx(tx *wire.MsgTx, name string) (*chainhash.Hash, error) {
 	// FIXME: Transaction fees are set to high, change it before deploying to mainnet. By changing the booleon to false.
+	start := time.Now()
 	txHash, err := conn.Client.SendRawTransaction(tx, true)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "sendrawtransaction", start, err)
 	if err != nil {
 		return nil, fmt.Errorf("sendrawtransaction: %v", err)
 	}
@@ -128,7 +199,9 @@ func (conn *Conn) PromptPublishTx(tx *wire.MsgTx, name string) (*chainhash.Hash,
 func (conn *Conn) WaitForConfirmations(txHash *chainhash.Hash, requiredConfirmations int64) error {
 	confirmations := int64(0)
 	for confirmations < requiredConfirmations {
+		start := time.Now()
 		txDetails, err := conn.Client.GetTransaction(txHash)
+		metrics.ObserveRPC(metrics.ChainBitcoin, "gettransaction", start, err)
 		if err != nil {
 			return err
 		}
@@ -140,6 +213,182 @@ func (conn *Conn) WaitForConfirmations(txHash *chainhash.Hash, requiredConfirmat
 	return nil
 }
 
+// ChainInfo is the state of the node's copy of the blockchain. MedianTime is
+// the median time of the last 11 blocks, which lock times are checked
+// against.
+type ChainInfo struct {
+	Blocks               int64   `json:"blocks"`
+	Headers              int64   `json:"headers"`
+	MedianTime           int64   `json:"mediantime"`
+	InitialBlockDownload bool    `json:"initialblockdownload"`
+	VerificationProgress float64 `json:"verificationprogress"`
+}
+
+// ChainInfo returns the height of the node and whether it is still in its
+// initial block download.
+func (conn *Conn) ChainInfo() (ChainInfo, error) {
+	start := time.Now()
+	rawResp, err := conn.Client.RawRequest("getblockchaininfo", nil)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "getblockchaininfo", start, err)
+	if err != nil {
+		return ChainInfo{}, err
+	}
+	info := ChainInfo{}
+	if err := json.Unmarshal(rawResp, &info); err != nil {
+		return ChainInfo{}, err
+	}
+	return info, nil
+}
+
+// Unspent returns true if the output of the transaction has not been spent,
+// including by a transaction in the mempool.
+func (conn *Conn) Unspent(txHash *chainhash.Hash, index uint32) (bool, error) {
+	start := time.Now()
+	out, err := conn.Client.GetTxOut(txHash, index, true)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "gettxout", start, err)
+	if err != nil {
+		return false, err
+	}
+	return out != nil, nil
+}
+
+// Spender returns the transaction that spends the output of the transaction,
+// or nil if the output has not been spent. An output that is only spent in
+// the mempool is looked for in the mempool, and otherwise the blocks since
+// the transaction was mined are scanned, skipping the blocks that were
+// scanned by earlier calls. The transaction must be in the node's wallet.
+func (conn *Conn) Spender(txHash *chainhash.Hash, index uint32) (*wire.MsgTx, error) {
+	if conn.spenders == nil {
+		conn.spenders = newSpenderCache()
+	}
+	outPoint := wire.OutPoint{Hash: *txHash, Index: index}
+	conn.spenders.mu.Lock()
+	spender, ok := conn.spenders.spenders[outPoint]
+	conn.spenders.mu.Unlock()
+	if ok {
+		return spender, nil
+	}
+
+	unspent, err := conn.Unspent(txHash, index)
+	if err != nil || unspent {
+		return nil, err
+	}
+
+	start := time.Now()
+	out, err := conn.Client.GetTxOut(txHash, index, false)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "gettxout", start, err)
+	if err != nil {
+		return nil, err
+	}
+	if out != nil {
+		return conn.mempoolSpender(txHash, index)
+	}
+	return conn.blockSpender(outPoint)
+}
+
+// mempoolSpender returns the transaction in the mempool that spends the
+// output of the transaction, or nil if there is none.
+func (conn *Conn) mempoolSpender(txHash *chainhash.Hash, index uint32) (*wire.MsgTx, error) {
+	start := time.Now()
+	mempool, err := conn.Client.GetRawMempool()
+	metrics.ObserveRPC(metrics.ChainBitcoin, "getrawmempool", start, err)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range mempool {
+		start := time.Now()
+		tx, err := conn.Client.GetRawTransaction(hash)
+		metrics.ObserveRPC(metrics.ChainBitcoin, "getrawtransaction", start, err)
+		if err != nil {
+			// The transaction may have been mined or evicted since the
+			// mempool was read.
+			continue
+		}
+		if spends(tx.MsgTx(), txHash, index) {
+			return tx.MsgTx(), nil
+		}
+	}
+	return nil, nil
+}
+
+// blockSpender scans the blocks since the transaction was mined for the
+// transaction that spends the output, starting a few blocks before the last
+// block that was scanned for it.
+func (conn *Conn) blockSpender(outPoint wire.OutPoint) (*wire.MsgTx, error) {
+	start := time.Now()
+	txDetails, err := conn.Client.GetTransaction(&outPoint.Hash)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "gettransaction", start, err)
+	if err != nil {
+		return nil, err
+	}
+	if txDetails.BlockHash == "" {
+		return nil, nil
+	}
+	blockHash, err := chainhash.NewHashFromStr(txDetails.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	header, err := conn.Client.GetBlockHeaderVerbose(blockHash)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "getblockheader", start, err)
+	if err != nil {
+		return nil, err
+	}
+	start = time.Now()
+	height, err := conn.Client.GetBlockCount()
+	metrics.ObserveRPC(metrics.ChainBitcoin, "getblockcount", start, err)
+	if err != nil {
+		return nil, err
+	}
+
+	from := int64(header.Height)
+	conn.spenders.mu.Lock()
+	if scanned, ok := conn.spenders.scanned[outPoint]; ok && scanned-reorgDepth > from {
+		from = scanned - reorgDepth
+	}
+	conn.spenders.mu.Unlock()
+
+	for h := from; h <= height; h++ {
+		start := time.Now()
+		hash, err := conn.Client.GetBlockHash(h)
+		metrics.ObserveRPC(metrics.ChainBitcoin, "getblockhash", start, err)
+		if err != nil {
+			return nil, err
+		}
+		start = time.Now()
+		block, err := conn.Client.GetBlock(hash)
+		metrics.ObserveRPC(metrics.ChainBitcoin, "getblock", start, err)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range block.Transactions {
+			if spends(tx, &outPoint.Hash, outPoint.Index) {
+				conn.spenders.mu.Lock()
+				conn.spenders.spenders[outPoint] = tx
+				delete(conn.spenders.scanned, outPoint)
+				conn.spenders.mu.Unlock()
+				return tx, nil
+			}
+		}
+		conn.spenders.mu.Lock()
+		conn.spenders.scanned[outPoint] = h
+		conn.spenders.mu.Unlock()
+	}
+	return nil, nil
+}
+
+// spends returns true if the transaction spends the output of the other
+// transaction.
+func spends(tx *wire.MsgTx, txHash *chainhash.Hash, index uint32) bool {
+	for _, in := range tx.TxIn {
+		if in.PreviousOutPoint.Hash.IsEqual(txHash) && in.PreviousOutPoint.Index == index {
+			return true
+		}
+	}
+	return false
+}
+
 func (conn *Conn) Shutdown() {
 	conn.Client.Shutdown()
 	conn.Client.WaitForShutdown()
@@ -176,7 +425,9 @@ func (conn *Conn) FundTransaction(tx *wire.MsgTx, addresses []btcutil.Address) (
 	for _, j := range tx.TxOut {
 		value = value + float64(j.Value)
 	}
+	start := time.Now()
 	utxos, err := conn.Client.ListUnspentMinMaxAddresses(0, 99999, addresses)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "listunspent", start, err)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -245,7 +496,9 @@ func (conn *Conn) SignTransaction(tx *wire.MsgTx) (*wire.MsgTx, bool, error) {
 	// hash2 := sha256.Sum256(hash1[:])
 	// fmt.Println(hash2)
 
+	start := time.Now()
 	stx, complete, err := conn.Client.SignRawTransaction(tx)
+	metrics.ObserveRPC(metrics.ChainBitcoin, "signrawtransaction", start, err)
 	if err != nil {
 		return nil, false, err
 	}
@@ -25,15 +25,16 @@ type Conn struct {
 	orderbook          common.Address
 }
 
-// Connect to an ethereum network.
+// Connect to an ethereum network. Calls made over HTTP are recorded in the
+// RPC metrics.
 func Connect(config network.Config) (Conn, error) {
-	ethclient, err := ethclient.Dial(config.Ethereum.URL)
+	rpcClient, err := dial(config.Ethereum.URL)
 	if err != nil {
 		return Conn{}, err
 	}
 
 	return Conn{
-		client:             ethclient,
+		client:             ethclient.NewClient(rpcClient),
 		network:            config.Ethereum.Network,
 		renExAtomicSwapper: common.HexToAddress(config.Ethereum.RenExAtomicSwapper),
 		renExAtomicInfo:    common.HexToAddress(config.Ethereum.RenExAtomicInfo),
@@ -77,6 +78,30 @@ func (b *Conn) Transfer(to common.Address, from *bind.TransactOpts, value int64)
 	return err
 }
 
+// SendEther sends ETH to an address and returns the hash of the transaction
+// once it has been mined.
+func (b *Conn) SendEther(to common.Address, from *bind.TransactOpts, value *big.Int) (string, error) {
+	transactor := &bind.TransactOpts{
+		From:     from.From,
+		Nonce:    from.Nonce,
+		Signer:   from.Signer,
+		Value:    value,
+		GasPrice: from.GasPrice,
+		GasLimit: 21000,
+		Context:  from.Context,
+	}
+
+	bound := bind.NewBoundContract(to, abi.ABI{}, nil, b.client, nil)
+	tx, err := bound.Transfer(transactor)
+	if err != nil {
+		return "", err
+	}
+	if _, err := b.PatchedWaitMined(context.Background(), tx); err != nil {
+		return tx.Hash().Hex(), err
+	}
+	return tx.Hash().Hex(), nil
+}
+
 // PatchedWaitMined waits for tx to be mined on the blockchain.
 // It stops waiting when the context is canceled.
 //
@@ -114,6 +139,55 @@ func (b *Conn) PatchedWaitDeployed(ctx context.Context, tx *types.Transaction) (
 	}
 }
 
+// PendingNonces returns the number of transactions sent from the address that
+// have not been mined yet.
+func (b *Conn) PendingNonces(address common.Address) (uint64, error) {
+	pending, err := b.client.PendingNonceAt(context.Background(), address)
+	if err != nil {
+		return 0, err
+	}
+	mined, err := b.client.NonceAt(context.Background(), address, nil)
+	if err != nil {
+		return 0, err
+	}
+	if pending < mined {
+		return 0, nil
+	}
+	return pending - mined, nil
+}
+
+// SyncStatus is the state of the node's copy of the blockchain. Current and
+// highest blocks are only set while the node is syncing.
+type SyncStatus struct {
+	Syncing         bool
+	CurrentBlock    uint64
+	HighestBlock    uint64
+	LatestBlock     uint64
+	LatestBlockTime int64
+}
+
+// SyncStatus returns whether the node is syncing, and the number and time of
+// the latest block that it has.
+func (b *Conn) SyncStatus() (SyncStatus, error) {
+	status := SyncStatus{}
+	progress, err := b.client.SyncProgress(context.Background())
+	if err != nil {
+		return status, err
+	}
+	if progress != nil {
+		status.Syncing = true
+		status.CurrentBlock = progress.CurrentBlock
+		status.HighestBlock = progress.HighestBlock
+	}
+	header, err := b.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return status, err
+	}
+	status.LatestBlock = header.Number.Uint64()
+	status.LatestBlockTime = header.Time.Int64()
+	return status, nil
+}
+
 func (conn *Conn) RenExAtomicSwapperAddress() common.Address {
 	return conn.renExAtomicSwapper
 }
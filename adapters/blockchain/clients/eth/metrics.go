@@ -0,0 +1,66 @@
+package ethclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
+)
+
+// dial connects to the node, timing the calls made over HTTP. Other
+// transports are not timed.
+func dial(url string) (*rpc.Client, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return rpc.Dial(url)
+	}
+	return rpc.DialHTTPWithClient(url, &http.Client{
+		Transport: &metricsTransport{http.DefaultTransport},
+	})
+}
+
+// metricsTransport records the latency and errors of JSON-RPC calls by
+// method.
+type metricsTransport struct {
+	http.RoundTripper
+}
+
+func (transport *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := "unknown"
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		method = rpcMethod(body)
+	}
+
+	start := time.Now()
+	resp, err := transport.RoundTripper.RoundTrip(req)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		metrics.ObserveRPC(metrics.ChainEthereum, method, start, fmt.Errorf("%s", resp.Status))
+		return resp, err
+	}
+	metrics.ObserveRPC(metrics.ChainEthereum, method, start, err)
+	return resp, err
+}
+
+// rpcMethod returns the method of a JSON-RPC request, or "batch" for a batch
+// of requests.
+func rpcMethod(body []byte) string {
+	msg := struct {
+		Method string `json:"method"`
+	}{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return "batch"
+	}
+	return msg.Method
+}
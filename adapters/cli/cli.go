@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	config "github.com/republicprotocol/renex-swapper-go/adapters/configs/general"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/bolt"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/leveldb"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/sqlite"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// BuildStore opens the swap store of the type and at the location given in
+// the config.
+func BuildStore(conf config.Config) (store.Store, error) {
+	loc, err := conf.StoreLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	switch conf.StoreType() {
+	case "leveldb":
+		return leveldb.NewLDBStore(loc)
+	case "bolt":
+		return bolt.NewBoltStore(loc)
+	case "sqlite":
+		return sqlite.NewSQLiteStore(loc)
+	case "memory":
+		log.Println("Using an in-memory store, swaps will be lost when the swapper stops")
+		return memory.NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("unsupported store type: %s", conf.StoreType())
+}
+
+// ReadPassphrase returns the passphrase in the environment variable, or
+// prompts for it without echoing it if the variable is not set. A passphrase
+// piped to the standard input is read as a line. It returns
+// store.ErrEmptyPassphrase if no passphrase is given.
+func ReadPassphrase(env, prompt string) (string, error) {
+	if passphrase := os.Getenv(env); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fmt.Print(prompt)
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		passphrase, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		return nonEmpty(strings.Trim(passphrase, "\r\n"))
+	}
+
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return nonEmpty(string(passphrase))
+}
+
+func nonEmpty(passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", store.ErrEmptyPassphrase
+	}
+	return passphrase, nil
+}
@@ -5,22 +5,139 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
 type Config struct {
-	Version             string   `json:"version"`
-	SupportedCurrencies []string `json:"supportedCurrencies"`
-	AuthorizedAddresses []string `json:"authorizedAddresses"`
-	Watchdog            string   `json:"watchdogURL"`
+	Version             string    `json:"version"`
+	SupportedCurrencies []string  `json:"supportedCurrencies"`
+	AuthorizedAddresses []string  `json:"authorizedAddresses"`
+	Watchdog            string    `json:"watchdogURL"`
+	WatchdogOptions     Watchdog  `json:"watchdog"`
+	Store               Store     `json:"store"`
+	Auth                Auth      `json:"auth"`
+	HTTP                HTTP      `json:"http"`
+	Funding             Funding   `json:"funding"`
+	Metrics             Metrics   `json:"metrics"`
+	Health              Health    `json:"health"`
+	Scheduler           Scheduler `json:"scheduler"`
 
 	mu   *sync.RWMutex
 	path string
 }
 
+// Watchdog configures the requests made to the watchdog at watchdogURL,
+// which is a base URL such as https://watchdog.example.com, or a host that is
+// reached over HTTPS. Complaints time out after TimeoutSeconds, 30 by
+// default, and pings after PingTimeoutSeconds, 5 by default.
+type Watchdog struct {
+	TimeoutSeconds     int `json:"timeoutSeconds"`
+	PingTimeoutSeconds int `json:"pingTimeoutSeconds"`
+}
+
+// Store selects the backend that is used to persist swaps, and where it is
+// kept. Supported types are "leveldb" (the default), "bolt", "sqlite" and
+// "memory". The details of finished swaps are pruned after
+// ArchiveRetentionDays, or never if it is zero.
+type Store struct {
+	Type                 string `json:"type"`
+	Location             string `json:"location"`
+	ArchiveRetentionDays int    `json:"archiveRetentionDays"`
+}
+
+// Auth configures access to the HTTP API. Only AllowedOrigins can make
+// requests from a browser. Sessions expire after TokenTTLMinutes. Permissions
+// maps authorized addresses to the permissions that their sessions are given,
+// addresses that are not listed are given the read and trade permissions.
+type Auth struct {
+	AllowedOrigins  []string            `json:"allowedOrigins"`
+	TokenTTLMinutes int                 `json:"tokenTTLMinutes"`
+	Permissions     map[string][]string `json:"permissions"`
+}
+
+// HTTP configures where the HTTP API listens. Address is the host that it is
+// bound to, 127.0.0.1 by default. When TLS is enabled the API is served over
+// HTTPS using CertFile and KeyFile, or using a self-signed certificate that is
+// generated in ~/.swapper/tls if they are not set. UnixSocket is the path of a
+// socket that local tools can use to reach the API without TLS.
+type HTTP struct {
+	Address    string `json:"address"`
+	UnixSocket string `json:"unixSocket"`
+	TLS        TLS    `json:"tls"`
+}
+
+type TLS struct {
+	Enabled  bool   `json:"enabled"`
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+// Funding configures the check that the swapper can fund an order when it is
+// posted. Check is "reject" (the default) to refuse orders that cannot be
+// funded, "warn" to accept them with a warning, or "off".
+type Funding struct {
+	Check string `json:"check"`
+}
+
+// Metrics configures where the Prometheus metrics are served. They are served
+// on 127.0.0.1:18517 unless an address is set, or they are disabled.
+type Metrics struct {
+	Disabled bool   `json:"disabled"`
+	Address  string `json:"address"`
+}
+
+// Health configures the health checks. The Ethereum node is considered to be
+// behind when its latest block is older than MaxBlockAgeSeconds, 300 by
+// default.
+type Health struct {
+	MaxBlockAgeSeconds int `json:"maxBlockAgeSeconds"`
+}
+
+// Scheduler configures how many swaps the watcher, and refunds the guardian,
+// run at the same time. Swaps beyond the limit are queued, the ones closest
+// to expiring first. When the swapper shuts down it waits up to
+// ShutdownTimeoutSeconds, 30 by default, for the swaps that are running to
+// stop at a checkpoint.
+type Scheduler struct {
+	Concurrency            int `json:"concurrency"`
+	ShutdownTimeoutSeconds int `json:"shutdownTimeoutSeconds"`
+}
+
+// DefaultShutdownTimeout is how long the swapper waits for the swaps that are
+// running to stop when it shuts down, when no timeout is configured.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Default watchdog timeouts, used when none are configured.
+const (
+	DefaultWatchdogTimeout     = 30 * time.Second
+	DefaultWatchdogPingTimeout = 5 * time.Second
+)
+
+// DefaultMetricsAddress is the address that the metrics are served on when
+// none is configured.
+const DefaultMetricsAddress = "127.0.0.1:18517"
+
+// Funding checks
+const (
+	FundingReject = "reject"
+	FundingWarn   = "warn"
+	FundingOff    = "off"
+)
+
+// DefaultAllowedOrigins are the origins allowed to use the HTTP API when none
+// are configured.
+var DefaultAllowedOrigins = []string{"https://ren.exchange", "https://testnet.ren.exchange"}
+
+// DefaultPermissions are given to authorized addresses that are not listed
+// in the permissions.
+var DefaultPermissions = []string{"read", "trade"}
+
 var ErrUnSupportedPriorityCode = errors.New("Unsupported Priority Code")
 
 func LoadConfig(path string) (Config, error) {
@@ -59,21 +176,175 @@ func (config *Config) GetAuthorizedAddresses() []common.Address {
 	return addrs
 }
 
+func (config *Config) StoreType() string {
+	if config.Store.Type == "" {
+		return "leveldb"
+	}
+	return config.Store.Type
+}
+
 func (config *Config) StoreLocation() (string, error) {
+	if config.Store.Location != "" {
+		return config.Store.Location, nil
+	}
+
+	var name string
+	switch config.StoreType() {
+	case "leveldb":
+		name = "db"
+	case "bolt":
+		name = "swapper.bolt"
+	case "sqlite":
+		name = "swapper.sqlite"
+	case "memory":
+		return "", nil
+	default:
+		return "", fmt.Errorf("Unsupported store type: %s", config.StoreType())
+	}
+
+	return swapperPath(name)
+}
+
+// ListenAddress returns the address that the HTTP API listens on.
+func (config *Config) ListenAddress(port string) string {
+	if config.HTTP.Address == "" {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+	return net.JoinHostPort(config.HTTP.Address, port)
+}
+
+// MetricsAddress returns the address that the metrics are served on, or an
+// empty string if they are disabled.
+func (config *Config) MetricsAddress() string {
+	if config.Metrics.Disabled {
+		return ""
+	}
+	if config.Metrics.Address == "" {
+		return DefaultMetricsAddress
+	}
+	return config.Metrics.Address
+}
+
+// MaxBlockAge returns how old the latest Ethereum block can be before the
+// node is considered to be behind, or zero to use the default.
+func (config *Config) MaxBlockAge() time.Duration {
+	return time.Duration(config.Health.MaxBlockAgeSeconds) * time.Second
+}
+
+// Concurrency returns the number of swaps that are run at the same time, or
+// zero to use the default.
+func (config *Config) Concurrency() int {
+	return config.Scheduler.Concurrency
+}
+
+// ShutdownTimeout returns how long the swapper waits for the swaps that are
+// running to stop when it shuts down.
+func (config *Config) ShutdownTimeout() time.Duration {
+	if config.Scheduler.ShutdownTimeoutSeconds <= 0 {
+		return DefaultShutdownTimeout
+	}
+	return time.Duration(config.Scheduler.ShutdownTimeoutSeconds) * time.Second
+}
+
+// FundingCheck returns what is done when a posted order cannot be funded.
+func (config *Config) FundingCheck() string {
+	switch config.Funding.Check {
+	case FundingWarn, FundingOff:
+		return config.Funding.Check
+	}
+	return FundingReject
+}
+
+// SelfSigned returns true if the HTTP API is served with a self-signed
+// certificate because no certificate is configured.
+func (config *Config) SelfSigned() bool {
+	return config.HTTP.TLS.CertFile == "" && config.HTTP.TLS.KeyFile == ""
+}
+
+// TLSFiles returns the certificate and key files that the HTTP API is served
+// with.
+func (config *Config) TLSFiles() (string, string, error) {
+	if !config.SelfSigned() {
+		return config.HTTP.TLS.CertFile, config.HTTP.TLS.KeyFile, nil
+	}
+	certFile, err := swapperPath("tls/cert.pem")
+	if err != nil {
+		return "", "", err
+	}
+	keyFile, err := swapperPath("tls/key.pem")
+	if err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+func swapperPath(name string) (string, error) {
 	winHome := os.Getenv("userprofile")
 	unixHome := os.Getenv("HOME")
 
 	if unixHome != "" {
-		return unixHome + "/.swapper/db", nil
+		return unixHome + "/.swapper/" + name, nil
 	}
 
 	if winHome != "" {
-		return winHome + "/.swapper/db", nil
+		return winHome + "/.swapper/" + name, nil
 	}
 
 	return "", fmt.Errorf("Unsupported Operating System")
 }
 
+// ArchiveRetention returns how long the details of finished swaps are kept
+// before they are pruned. A retention of zero keeps them forever.
+func (config *Config) ArchiveRetention() time.Duration {
+	return time.Duration(config.Store.ArchiveRetentionDays) * 24 * time.Hour
+}
+
+func (config *Config) AllowedOrigins() []string {
+	if len(config.Auth.AllowedOrigins) == 0 {
+		return DefaultAllowedOrigins
+	}
+	return config.Auth.AllowedOrigins
+}
+
+// TokenTTL returns how long sessions last, fifteen minutes by default.
+func (config *Config) TokenTTL() time.Duration {
+	if config.Auth.TokenTTLMinutes <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(config.Auth.TokenTTLMinutes) * time.Minute
+}
+
+// Permissions returns the permissions of an authorized address.
+func (config *Config) Permissions(address common.Address) []string {
+	for addr, permissions := range config.Auth.Permissions {
+		if common.HexToAddress(addr) == address {
+			return permissions
+		}
+	}
+	return DefaultPermissions
+}
+
+// WatchdogURL returns the base URL of the watchdog, without a trailing slash.
 func (config *Config) WatchdogURL() string {
-	return config.Watchdog
+	url := strings.TrimRight(config.Watchdog, "/")
+	if !strings.Contains(url, "://") {
+		url = "https://" + url
+	}
+	return url
+}
+
+// WatchdogTimeout returns how long a complaint to the watchdog can take.
+func (config *Config) WatchdogTimeout() time.Duration {
+	if config.WatchdogOptions.TimeoutSeconds <= 0 {
+		return DefaultWatchdogTimeout
+	}
+	return time.Duration(config.WatchdogOptions.TimeoutSeconds) * time.Second
+}
+
+// WatchdogPingTimeout returns how long a ping to the watchdog can take.
+func (config *Config) WatchdogPingTimeout() time.Duration {
+	if config.WatchdogOptions.PingTimeoutSeconds <= 0 {
+		return DefaultWatchdogPingTimeout
+	}
+	return time.Duration(config.WatchdogOptions.PingTimeoutSeconds) * time.Second
 }
@@ -1,10 +1,18 @@
 package http
 
+import (
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/health"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
 type BoxInfo struct {
-	Challenge           string   `json:"challenge"`
-	Version             string   `json:"version"`
-	AuthorizedAddresses []string `json:"authorizedAddresses"`
-	SupportedCurrencies []string `json:"supportedCurrencies"`
+	Challenge              string   `json:"challenge"`
+	Version                string   `json:"version"`
+	AuthorizedAddresses    []string `json:"authorizedAddresses"`
+	SupportedCurrencies    []string `json:"supportedCurrencies"`
+	CertificateFingerprint string   `json:"certificateFingerprint,omitempty"`
 }
 
 type WhoAmI struct {
@@ -17,22 +25,141 @@ type Status struct {
 	Status  string `json:"status"`
 }
 
+// PostOrder is a signed order ID. SendCurrency and MaxSendAmount are
+// optional, and are used to check that the order can be funded if its
+// details are not available from RenEx yet. Warning is set in the response if
+// the order was accepted without being funded.
 type PostOrder struct {
-	OrderID   string `json:"orderID"`
-	Signature string `json:"signature"`
+	OrderID       string `json:"orderID"`
+	Signature     string `json:"signature"`
+	SendCurrency  string `json:"sendCurrency,omitempty"`
+	MaxSendAmount string `json:"maxSendAmount,omitempty"`
+	Warning       string `json:"warning,omitempty"`
 }
 
+// Balance is the balance of one of the swapper's addresses. Amounts are
+// decimal strings in the base unit of the currency, satoshis or wei.
 type Balance struct {
+	Currency     string `json:"currency"`
 	Address      string `json:"address"`
-	Amount       uint64 `json:"amount"`
 	PriorityCode uint32 `json:"priorityCode"`
+	Amount       string `json:"amount"`
+	Confirmed    string `json:"confirmed"`
+	Unconfirmed  string `json:"unconfirmed"`
+	Locked       string `json:"locked"`
+	Available    string `json:"available"`
+}
+
+// FeeBalance is the ether that an authorized trader has in RenEx to pay fees.
+type FeeBalance struct {
+	Trader string `json:"trader"`
+	Amount string `json:"amount"`
+}
+
+type Balances struct {
+	Balances  []Balance    `json:"balances"`
+	RenExFees []FeeBalance `json:"renExFees"`
+}
+
+type Swap struct {
+	OrderID         string                  `json:"orderID"`
+	ForeignOrderID  string                  `json:"foreignOrderID"`
+	Status          string                  `json:"status"`
+	Archived        bool                    `json:"archived"`
+	Role            string                  `json:"role"`
+	SendCurrency    string                  `json:"sendCurrency"`
+	ReceiveCurrency string                  `json:"receiveCurrency"`
+	SendValue       string                  `json:"sendValue"`
+	ReceiveValue    string                  `json:"receiveValue"`
+	Transactions    swapDomain.Transactions `json:"transactions"`
+	StartedAt       int64                   `json:"startedAt"`
+	FinishedAt      int64                   `json:"finishedAt,omitempty"`
+}
+
+type SwapDetails struct {
+	Swap
+	HashLock     string                  `json:"hashLock,omitempty"`
+	Expiry       int64                   `json:"expiry,omitempty"`
+	Counterparty swapDomain.Transactions `json:"counterpartyTransactions"`
+	NextAction   *NextAction             `json:"nextAction,omitempty"`
+	Complaint    *store.Complaint        `json:"complaint,omitempty"`
+	Error        *store.SwapError        `json:"error,omitempty"`
+	History      []store.StatusChange    `json:"history"`
+	Pruned       bool                    `json:"pruned"`
+}
+
+type NextAction struct {
+	Description string `json:"description"`
+	Deadline    int64  `json:"deadline,omitempty"`
+}
+
+type Event struct {
+	events.Event
+	OrderID string `json:"orderID"`
+}
+
+type Challenge struct {
+	Challenge string `json:"challenge"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type Login struct {
+	Challenge string `json:"challenge"`
+	Signature string `json:"signature"`
+}
+
+type Session struct {
+	Token       string   `json:"token"`
+	Address     string   `json:"address"`
+	Permissions []string `json:"permissions"`
+	ExpiresAt   int64    `json:"expiresAt"`
+}
+
+type Swaps struct {
+	Swaps      []Swap `json:"swaps"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
-type Balances []Balance
+// PostWithdrawal requests that an amount of a currency, in its base unit, is
+// sent to an address. The signature is of the WithdrawalMessage, by an
+// authorized address. The nonce can be anything, but a signed withdrawal can
+// only be made once.
+type PostWithdrawal struct {
+	Currency  string `json:"currency"`
+	To        string `json:"to"`
+	Amount    string `json:"amount"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+type Withdrawal struct {
+	ID           string `json:"id"`
+	Currency     string `json:"currency"`
+	To           string `json:"to"`
+	Amount       string `json:"amount"`
+	AuthorizedBy string `json:"authorizedBy"`
+	Status       string `json:"status"`
+	TxHash       string `json:"txHash,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Time         int64  `json:"time"`
+}
 
 type BoxHTTPAdapter interface {
 	WhoAmI(challenge string) (WhoAmI, error)
 	PostOrder(order PostOrder) (PostOrder, error)
+	CancelOrder(orderID, signature string) error
 	GetStatus(orderID string) (Status, error)
 	GetBalances() (Balances, error)
+	GetSwaps(query store.SwapQuery) (Swaps, error)
+	GetSwap(orderID string) (SwapDetails, error)
+	Subscribe(orderID string, after uint64) (*events.Subscription, error)
+	NewChallenge() (Challenge, error)
+	Login(login Login) (Session, error)
+	Logout(token string)
+	Authorize(token, permission string) (Session, error)
+	SwapAction(orderID, action, actor string) error
+	Withdraw(withdrawal PostWithdrawal) (Withdrawal, error)
+	GetWithdrawals() ([]Withdrawal, error)
+	Health() health.Report
+	AllowedOrigins() []string
 }
@@ -0,0 +1,271 @@
+package http_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/adapters/http"
+	"github.com/republicprotocol/renex-swapper-go/services/auth"
+	"github.com/republicprotocol/renex-swapper-go/services/health"
+	"github.com/republicprotocol/renex-swapper-go/services/watch"
+)
+
+// mockAdapter only implements what is needed to route and authorize
+// requests.
+type mockAdapter struct {
+	BoxHTTPAdapter
+}
+
+func (adapter mockAdapter) AllowedOrigins() []string {
+	return []string{"https://ren.exchange"}
+}
+
+func (adapter mockAdapter) Authorize(token, permission string) (Session, error) {
+	if token == "" {
+		return Session{}, auth.ErrUnauthorized
+	}
+	return Session{}, auth.ErrForbidden
+}
+
+// Health reports that the swapper is live but cannot reach the watchdog.
+func (adapter mockAdapter) Health() health.Report {
+	return health.Report{
+		Live:  true,
+		Ready: false,
+		Checks: []health.Check{
+			{Name: health.CheckBitcoin, Healthy: true},
+			{Name: health.CheckWatchdog, Error: "connection refused"},
+		},
+	}
+}
+
+// failingAdapter authorizes every request and fails every call with err.
+type failingAdapter struct {
+	mockAdapter
+	err error
+}
+
+func (adapter failingAdapter) Authorize(token, permission string) (Session, error) {
+	return Session{}, nil
+}
+
+func (adapter failingAdapter) PostOrder(order PostOrder) (PostOrder, error) {
+	return PostOrder{}, adapter.err
+}
+
+func (adapter failingAdapter) CancelOrder(orderID, signature string) error {
+	return adapter.err
+}
+
+func (adapter failingAdapter) SwapAction(orderID, action, actor string) error {
+	return adapter.err
+}
+
+func (adapter failingAdapter) Withdraw(withdrawal PostWithdrawal) (Withdrawal, error) {
+	return Withdrawal{}, adapter.err
+}
+
+func (adapter failingAdapter) GetStatus(orderID string) (Status, error) {
+	return Status{}, adapter.err
+}
+
+func (adapter failingAdapter) NewChallenge() (Challenge, error) {
+	return Challenge{}, adapter.err
+}
+
+func (adapter failingAdapter) Login(login Login) (Session, error) {
+	return Session{}, adapter.err
+}
+
+// cancellingAdapter records the order that is cancelled, and fails with err.
+type cancellingAdapter struct {
+	failingAdapter
+	orderID, signature string
+}
+
+func (adapter *cancellingAdapter) CancelOrder(orderID, signature string) error {
+	adapter.orderID, adapter.signature = orderID, signature
+	return adapter.err
+}
+
+var _ = Describe("API", func() {
+	var server http.Handler
+
+	BeforeEach(func() {
+		server = NewServer(mockAdapter{})
+	})
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	expectError := func(w *httptest.ResponseRecorder, status int, code string) {
+		Expect(w.Code).Should(Equal(status))
+		Expect(w.Header().Get("Content-Type")).Should(Equal("application/json"))
+		resp := ErrorResponse{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &resp)).Should(Succeed())
+		Expect(resp.Error.Code).Should(Equal(code))
+		Expect(resp.Error.Message).ShouldNot(BeEmpty())
+	}
+
+	It("should serve a valid OpenAPI document under /v1", func() {
+		w := get("/v1/openapi.json", "")
+		Expect(w.Code).Should(Equal(http.StatusOK))
+
+		doc := struct {
+			Paths map[string]interface{} `json:"paths"`
+		}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &doc)).Should(Succeed())
+		Expect(doc.Paths).Should(HaveKey("/orders"))
+		Expect(doc.Paths).Should(HaveKey("/swaps/{orderId}"))
+		Expect(doc.Paths).Should(HaveKey("/health"))
+	})
+
+	It("should return structured errors with codes", func() {
+		expectError(get("/v1/balances", ""), http.StatusUnauthorized, CodeUnauthorized)
+		expectError(get("/v1/balances", "token"), http.StatusForbidden, CodeForbidden)
+		expectError(get("/v1/unknown", ""), http.StatusNotFound, CodeNotFound)
+	})
+
+	It("should still serve the API without the version prefix", func() {
+		expectError(get("/balances", ""), http.StatusUnauthorized, CodeUnauthorized)
+	})
+
+	It("should report the health of the swapper without a session", func() {
+		w := get("/v1/health", "")
+		Expect(w.Code).Should(Equal(http.StatusServiceUnavailable))
+		report := health.Report{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &report)).Should(Succeed())
+		Expect(report.Ready).Should(BeFalse())
+		Expect(report.Checks).Should(HaveLen(2))
+
+		Expect(get("/v1/health/live", "").Code).Should(Equal(http.StatusOK))
+	})
+
+	It("should document every route in the OpenAPI document", func() {
+		doc := struct {
+			Paths map[string]map[string]interface{} `json:"paths"`
+		}{}
+		Expect(json.Unmarshal([]byte(OpenAPI), &doc)).Should(Succeed())
+
+		routes := map[string]bool{}
+		err := NewRouter(mockAdapter{}).Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			methods, err := route.GetMethods()
+			if err != nil {
+				// The /v1 prefix has no methods of its own
+				return nil
+			}
+			path = strings.TrimPrefix(path, "/v1")
+			for _, method := range methods {
+				method = strings.ToLower(method)
+				Expect(doc.Paths).Should(HaveKey(path))
+				Expect(doc.Paths[path]).Should(HaveKey(method), "%s %s is not documented", method, path)
+				routes[method+" "+path] = true
+			}
+			return nil
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		for path, methods := range doc.Paths {
+			for method := range methods {
+				Expect(routes).Should(HaveKey(method+" "+path), "%s %s is documented but not served", method, path)
+			}
+		}
+	})
+
+	Context("when the swapper fails to handle a request", func() {
+		call := func(err error, method, path, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			NewServer(failingAdapter{err: err}).ServeHTTP(w, req)
+			return w
+		}
+
+		requests := [][]string{
+			{"POST", "/v1/orders", "{}"},
+			{"DELETE", "/v1/orders/00", ""},
+			{"POST", "/v1/swaps/00/actions/retry", ""},
+			{"POST", "/v1/withdrawals", "{}"},
+			{"GET", "/v1/status/00", ""},
+		}
+
+		It("should report unknown errors as internal errors", func() {
+			for _, req := range requests {
+				expectError(call(errors.New("connection refused"), req[0], req[1], req[2]), http.StatusInternalServerError, CodeInternal)
+			}
+		})
+
+		It("should report failed logins as unauthorized", func() {
+			expectError(call(errors.New("login failed"), "POST", "/v1/login", "{}"), http.StatusUnauthorized, CodeUnauthorizedAddress)
+			expectError(call(ErrUnauthorizedAddress, "POST", "/v1/login", "{}"), http.StatusUnauthorized, CodeUnauthorizedAddress)
+			expectError(call(auth.ErrUnknownChallenge, "POST", "/v1/login", "{}"), http.StatusUnauthorized, CodeUnknownChallenge)
+			expectError(call(ErrInvalidSignature, "POST", "/v1/login", "{}"), http.StatusBadRequest, CodeInvalidSignature)
+		})
+
+		It("should limit the challenges that are waiting to be signed", func() {
+			expectError(call(auth.ErrTooManyChallenges, "GET", "/v1/login", ""), http.StatusTooManyRequests, CodeTooManyChallenges)
+		})
+
+		It("should report invalid requests with their own codes", func() {
+			for _, req := range requests {
+				expectError(call(ErrInvalidSignature, req[0], req[1], req[2]), http.StatusBadRequest, CodeInvalidSignature)
+				expectError(call(ErrInvalidOrderID, req[0], req[1], req[2]), http.StatusBadRequest, CodeInvalidOrderID)
+			}
+			expectError(call(ErrUnknownCurrency, "POST", "/v1/orders", "{}"), http.StatusBadRequest, CodeUnknownCurrency)
+			expectError(call(ErrUnknownCurrency, "POST", "/v1/withdrawals", "{}"), http.StatusBadRequest, CodeUnknownCurrency)
+		})
+	})
+
+	Context("when an order is cancelled", func() {
+		cancel := func(adapter *cancellingAdapter, path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("DELETE", path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			NewServer(adapter).ServeHTTP(w, req)
+			return w
+		}
+
+		It("should cancel the order with the signature", func() {
+			adapter := &cancellingAdapter{}
+			w := cancel(adapter, "/v1/orders/0102?signature=abcd")
+			Expect(w.Code).Should(Equal(http.StatusNoContent))
+			Expect(adapter.orderID).Should(Equal("0102"))
+			Expect(adapter.signature).Should(Equal("abcd"))
+		})
+
+		It("should not cancel an order that it is not watching", func() {
+			adapter := &cancellingAdapter{failingAdapter: failingAdapter{err: ErrSwapNotFound}}
+			expectError(cancel(adapter, "/v1/orders/0102?signature=abcd"), http.StatusNotFound, CodeSwapNotFound)
+		})
+
+		It("should not cancel an order that has been matched", func() {
+			adapter := &cancellingAdapter{failingAdapter: failingAdapter{err: watch.ErrOrderMatched}}
+			expectError(cancel(adapter, "/v1/orders/0102?signature=abcd"), http.StatusConflict, CodeOrderMatched)
+		})
+
+		It("should not cancel an order without an authorized signature", func() {
+			adapter := &cancellingAdapter{failingAdapter: failingAdapter{err: ErrUnauthorizedAddress}}
+			expectError(cancel(adapter, "/v1/orders/0102"), http.StatusUnauthorized, CodeUnauthorizedAddress)
+			Expect(adapter.signature).Should(BeEmpty())
+		})
+	})
+})
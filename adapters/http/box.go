@@ -1,41 +1,82 @@
 package http
 
 import (
-	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"strings"
 
-	"github.com/btcsuite/btcutil"
 	"github.com/ethereum/go-ethereum/common"
 	ethCrypto "github.com/ethereum/go-ethereum/crypto"
 
-	btcClient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/btc"
-	ethClient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/eth"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/general"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/keystore"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/network"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/services/admin"
+	"github.com/republicprotocol/renex-swapper-go/services/auth"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/health"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/wallet"
 	"github.com/republicprotocol/renex-swapper-go/services/watch"
 	"github.com/republicprotocol/renex-swapper-go/utils"
 )
 
+var ErrInvalidSignature = errors.New("invalid signature")
 var ErrInvalidSignatureLength = errors.New("invalid signature length")
+var ErrInvalidOrderID = errors.New("invalid order id")
 var ErrInvalidOrderIDLength = errors.New("invalid order id length")
+var ErrSwapNotFound = errors.New("swap not found")
+var ErrInvalidChallenge = errors.New("invalid challenge")
+var ErrInvalidChallengeLength = errors.New("invalid challenge length")
+var ErrUnauthorizedAddress = errors.New("address is not authorized")
+var ErrInvalidAmount = errors.New("invalid amount")
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// NotReadyError is returned when an order is posted while some of the
+// dependencies of the swapper are unhealthy.
+type NotReadyError struct {
+	Unhealthy []string
+}
+
+func (err NotReadyError) Error() string {
+	return fmt.Sprintf("swapper is not ready, unhealthy: %s", strings.Join(err.Unhealthy, ", "))
+}
 
 type boxHttpAdapter struct {
 	config  config.Config
 	network network.Config
 	keystr  keystore.Keystore
 	watch   watch.Watch
+	state   store.State
+	events  events.Broker
+	auth    auth.Authenticator
+	admin   admin.Admin
+	wallet  wallet.Wallet
+	health  health.Checker
+
+	// fingerprint is the fingerprint of the TLS certificate that the API is
+	// served with, if it is served over TLS.
+	fingerprint string
 }
 
-func NewBoxHttpAdapter(config config.Config, network network.Config, keystr keystore.Keystore, watcher watch.Watch) BoxHTTPAdapter {
+func NewBoxHttpAdapter(config config.Config, network network.Config, keystr keystore.Keystore, watcher watch.Watch, state store.State, broker events.Broker, admin admin.Admin, wallet wallet.Wallet, checker health.Checker, fingerprint string) BoxHTTPAdapter {
 	return &boxHttpAdapter{
-		config:  config,
-		network: network,
-		keystr:  keystr,
-		watch:   watcher,
+		config:      config,
+		network:     network,
+		keystr:      keystr,
+		watch:       watcher,
+		state:       state,
+		events:      broker,
+		auth:        auth.NewAuthenticator(config.TokenTTL()),
+		admin:       admin,
+		wallet:      wallet,
+		health:      checker,
+		fingerprint: fingerprint,
 	}
 }
 
@@ -45,10 +86,11 @@ func (adapter *boxHttpAdapter) WhoAmI(challenge string) (WhoAmI, error) {
 	authorizedAddresses := adapter.config.AuthorizedAddresses
 
 	boxInfo := BoxInfo{
-		Challenge:           challenge,
-		Version:             version,
-		SupportedCurrencies: suppCurrencies,
-		AuthorizedAddresses: authorizedAddresses,
+		Challenge:              challenge,
+		Version:                version,
+		SupportedCurrencies:    suppCurrencies,
+		AuthorizedAddresses:    authorizedAddresses,
+		CertificateFingerprint: adapter.fingerprint,
 	}
 
 	boxBytes, err := MarshalBoxInfo(boxInfo)
@@ -84,6 +126,10 @@ func (adapter *boxHttpAdapter) WhoAmI(challenge string) (WhoAmI, error) {
 }
 
 func (adapter *boxHttpAdapter) PostOrder(order PostOrder) (PostOrder, error) {
+	if report := adapter.health.Check(); !report.Ready {
+		return PostOrder{}, notReady(report)
+	}
+
 	orderID, err := UnmarshalOrderID(order.OrderID)
 	if err != nil {
 		return PostOrder{}, err
@@ -95,17 +141,20 @@ func (adapter *boxHttpAdapter) PostOrder(order PostOrder) (PostOrder, error) {
 
 	addrs := adapter.config.GetAuthorizedAddresses()
 
-	err = validate(orderID, sigIn, addrs)
+	err = validate("Republic Protocol: open: ", orderID, sigIn, addrs)
 	if err != nil {
 		return PostOrder{}, err
 	}
 
-	go func() {
-		if err := adapter.watch.Add(orderID); err != nil {
-			return
-		}
-		adapter.watch.Notify()
-	}()
+	warning, err := adapter.fund(orderID, order)
+	if err != nil {
+		return PostOrder{}, err
+	}
+
+	if err := adapter.watch.Add(orderID); err != nil {
+		return PostOrder{}, err
+	}
+	go adapter.watch.Notify()
 
 	ethKey, err := adapter.keystr.GetKey(1, 0)
 	if err != nil {
@@ -129,11 +178,88 @@ func (adapter *boxHttpAdapter) PostOrder(order PostOrder) (PostOrder, error) {
 	}
 
 	return PostOrder{
-		order.OrderID,
-		MarshalSignature(sig65),
+		OrderID:   order.OrderID,
+		Signature: MarshalSignature(sig65),
+		Warning:   warning,
 	}, nil
 }
 
+func notReady(report health.Report) error {
+	err := NotReadyError{}
+	for _, check := range report.Checks {
+		if !check.Healthy {
+			err.Unhealthy = append(err.Unhealthy, check.Name)
+		}
+	}
+	return err
+}
+
+// fund checks that the order can be funded and reserves the funds for it,
+// returning a warning if the order should be accepted anyway.
+func (adapter *boxHttpAdapter) fund(orderID [32]byte, order PostOrder) (string, error) {
+	check := adapter.config.FundingCheck()
+	if check == config.FundingOff {
+		return "", nil
+	}
+
+	var fallback *wallet.Funding
+	if order.SendCurrency != "" || order.MaxSendAmount != "" {
+		currency, err := cc.Code(order.SendCurrency)
+		if err != nil {
+			return "", ErrUnknownCurrency
+		}
+		amount, ok := new(big.Int).SetString(order.MaxSendAmount, 10)
+		if !ok || amount.Sign() <= 0 {
+			return "", ErrInvalidAmount
+		}
+		fallback = &wallet.Funding{
+			Currency: currency,
+			Amount:   amount,
+		}
+	}
+
+	_, err := adapter.wallet.Fund(orderID, fallback, check == config.FundingWarn)
+	switch err.(type) {
+	case nil:
+		return "", nil
+	case wallet.UnfundedError:
+		if check == config.FundingWarn {
+			return err.Error(), nil
+		}
+		return "", err
+	}
+	if err == wallet.ErrOrderNotFound {
+		return "cannot check that the order can be funded until it is opened on RenEx", nil
+	}
+	return "", err
+}
+
+// CancelOrder stops the swapper from watching an order that has not been
+// matched. The order ID must be signed by an authorized address, prefixed
+// with "Republic Protocol: cancel: ".
+func (adapter *boxHttpAdapter) CancelOrder(orderID, signature string) error {
+	id, err := UnmarshalOrderID(orderID)
+	if err != nil {
+		return err
+	}
+	sigIn, err := UnmarshalSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	if err := validate("Republic Protocol: cancel: ", id, sigIn, adapter.config.GetAuthorizedAddresses()); err != nil {
+		return err
+	}
+
+	if err := adapter.watch.Cancel(id); err != nil {
+		if err == store.ErrKeyNotFound {
+			return ErrSwapNotFound
+		}
+		return err
+	}
+	return nil
+}
+
 func (adapter *boxHttpAdapter) GetStatus(orderID string) (Status, error) {
 	id, err := UnmarshalOrderID(orderID)
 	if err != nil {
@@ -149,80 +275,283 @@ func (adapter *boxHttpAdapter) GetStatus(orderID string) (Status, error) {
 }
 
 func (adapter *boxHttpAdapter) GetBalances() (Balances, error) {
-	balances := Balances{}
-	ethKey, err := adapter.keystr.GetKey(1, 0)
+	balances := Balances{
+		Balances:  []Balance{},
+		RenExFees: []FeeBalance{},
+	}
+	for _, currency := range []uint32{cc.ETHEREUMCC, cc.BITCOINCC} {
+		balance, err := adapter.wallet.Balance(currency)
+		if err != nil {
+			return balances, err
+		}
+		balances.Balances = append(balances.Balances, MarshalBalance(balance))
+	}
+
+	for _, trader := range adapter.config.GetAuthorizedAddresses() {
+		fees, err := adapter.wallet.FeeBalance(trader.String())
+		if err != nil {
+			return balances, err
+		}
+		balances.RenExFees = append(balances.RenExFees, FeeBalance{
+			Trader: trader.String(),
+			Amount: fees.String(),
+		})
+	}
+	return balances, nil
+}
+
+func MarshalBalance(balance wallet.Balance) Balance {
+	return Balance{
+		Currency:     cc.Name(balance.Currency),
+		Address:      balance.Address,
+		PriorityCode: balance.Currency,
+		Amount:       new(big.Int).Add(balance.Confirmed, balance.Unconfirmed).String(),
+		Confirmed:    balance.Confirmed.String(),
+		Unconfirmed:  balance.Unconfirmed.String(),
+		Locked:       balance.Locked.String(),
+		Available:    balance.Available.String(),
+	}
+}
+
+func (adapter *boxHttpAdapter) GetSwaps(query store.SwapQuery) (Swaps, error) {
+	page, err := adapter.state.ListSwaps(query)
+	if err != nil {
+		return Swaps{}, err
+	}
+	swaps := Swaps{
+		Swaps:      []Swap{},
+		NextCursor: page.NextCursor,
+	}
+	for _, summary := range page.Swaps {
+		swaps.Swaps = append(swaps.Swaps, MarshalSwap(summary))
+	}
+	return swaps, nil
+}
+
+func (adapter *boxHttpAdapter) GetSwap(orderID string) (SwapDetails, error) {
+	id, err := UnmarshalOrderID(orderID)
 	if err != nil {
-		return balances, err
+		return SwapDetails{}, err
+	}
+
+	summary, err := adapter.state.PendingSwap(id)
+	if err == store.ErrKeyNotFound {
+		summary, err = adapter.state.ArchivedSwap(id)
+	}
+	if err == store.ErrKeyNotFound {
+		return SwapDetails{}, ErrSwapNotFound
 	}
-	ethBal, err := ethereumBalance(adapter.network, ethKey)
 	if err != nil {
-		return balances, err
+		return SwapDetails{}, err
+	}
+
+	details := SwapDetails{
+		Swap:    MarshalSwap(summary),
+		Pruned:  summary.Pruned,
+		History: []store.StatusChange{},
+	}
+	if summary.Pruned {
+		return details, nil
+	}
+
+	expiry, hashLock, err := adapter.state.InitiateDetails(id)
+	if err == nil {
+		details.Expiry = expiry
+		details.HashLock = hex.EncodeToString(hashLock[:])
 	}
-	balances = append(balances, ethBal)
+	if details.Counterparty, err = adapter.state.CounterpartyTransactions(id); err != nil {
+		return SwapDetails{}, err
+	}
+	if details.History, err = adapter.state.History(id); err != nil {
+		return SwapDetails{}, err
+	}
+	if complaint, err := adapter.state.Complaint(id); err == nil {
+		details.Complaint = &complaint
+	}
+	if swapErr, err := adapter.state.Error(id); err == nil {
+		details.Error = &swapErr
+	}
+	if !summary.Archived {
+		action := swap.NextAction(summary.Status, summary.Role == store.RoleRequestor, expiry)
+		if action.Description != "" {
+			details.NextAction = &NextAction{
+				Description: action.Description,
+				Deadline:    action.Deadline,
+			}
+		}
+	}
+	return details, nil
+}
 
-	btcKey, err := adapter.keystr.GetKey(0, 0)
+// Subscribe subscribes to the events of the order, or of all orders if the
+// order ID is empty, replaying the events after the sequence number.
+func (adapter *boxHttpAdapter) Subscribe(orderID string, after uint64) (*events.Subscription, error) {
+	if orderID == "" {
+		return adapter.events.Subscribe(nil, after), nil
+	}
+	id, err := UnmarshalOrderID(orderID)
 	if err != nil {
-		return balances, err
+		return nil, err
 	}
-	btcBal, err := bitcoinBalance(adapter.network, btcKey)
+	return adapter.events.Subscribe(&id, after), nil
+}
+
+func MarshalEvent(event events.Event) Event {
+	return Event{
+		Event:   event,
+		OrderID: MarshalOrderID(event.OrderID),
+	}
+}
+
+func (adapter *boxHttpAdapter) NewChallenge() (Challenge, error) {
+	challenge, err := adapter.auth.NewChallenge()
 	if err != nil {
-		return balances, err
+		return Challenge{}, err
 	}
-	balances = append(balances, btcBal)
-	return balances, nil
+	return Challenge{
+		Challenge: hex.EncodeToString(challenge.Challenge[:]),
+		ExpiresAt: challenge.ExpiresAt,
+	}, nil
 }
 
-func bitcoinBalance(conf network.Config, key keystore.Key) (Balance, error) {
-	conn, err := btcClient.Connect(conf)
+// Login verifies that the challenge was signed by an authorized address and
+// starts a session with the permissions of that address.
+func (adapter *boxHttpAdapter) Login(login Login) (Session, error) {
+	challengeBytes, err := hex.DecodeString(login.Challenge)
 	if err != nil {
-		return Balance{}, err
+		return Session{}, ErrInvalidChallenge
+	}
+	if len(challengeBytes) != 32 {
+		return Session{}, ErrInvalidChallengeLength
 	}
+	challenge := [32]byte{}
+	copy(challenge[:], challengeBytes)
 
-	addr, err := key.GetAddress()
+	sigIn, err := UnmarshalSignature(login.Signature)
 	if err != nil {
-		return Balance{}, err
+		return Session{}, err
 	}
 
-	btcAddr, err := btcutil.DecodeAddress(string(addr), conn.ChainParams)
+	addr, err := recoverAddress(append([]byte("Republic Protocol: login: "), challenge[:]...), sigIn)
 	if err != nil {
-		return Balance{}, err
+		return Session{}, err
+	}
+	if !isAuthorized(addr, adapter.config.GetAuthorizedAddresses()) {
+		return Session{}, ErrUnauthorizedAddress
 	}
 
-	utxos, err := conn.Client.ListUnspentMinMaxAddresses(1, 999999, []btcutil.Address{btcAddr})
-	balance := float64(0)
+	session, err := adapter.auth.Login(challenge, addr.String(), adapter.config.Permissions(addr))
+	if err != nil {
+		return Session{}, err
+	}
+	return MarshalSession(session), nil
+}
 
-	for _, utxo := range utxos {
-		balance += utxo.Amount
+func (adapter *boxHttpAdapter) Withdraw(postWithdrawal PostWithdrawal) (Withdrawal, error) {
+	currency, err := cc.Code(postWithdrawal.Currency)
+	if err != nil {
+		return Withdrawal{}, ErrUnknownCurrency
+	}
+	amount, ok := new(big.Int).SetString(postWithdrawal.Amount, 10)
+	if !ok {
+		return Withdrawal{}, ErrInvalidAmount
+	}
+	sigIn, err := UnmarshalSignature(postWithdrawal.Signature)
+	if err != nil {
+		return Withdrawal{}, err
 	}
 
-	return Balance{
-		PriorityCode: key.PriorityCode(),
-		Address:      string(addr),
-		Amount:       uint64(balance * 100000000),
-	}, nil
+	message := WithdrawalMessage(postWithdrawal)
+	addr, err := recoverAddress(message, sigIn)
+	if err != nil {
+		return Withdrawal{}, err
+	}
+	if !isAuthorized(addr, adapter.config.GetAuthorizedAddresses()) {
+		return Withdrawal{}, ErrUnauthorizedAddress
+	}
+
+	id := [32]byte{}
+	copy(id[:], ethCrypto.Keccak256(message))
+	withdrawal, err := adapter.wallet.Withdraw(id, currency, postWithdrawal.To, amount, addr.String())
+	if err != nil {
+		return Withdrawal{}, err
+	}
+	return MarshalWithdrawal(withdrawal), nil
 }
 
-func ethereumBalance(conf network.Config, key keystore.Key) (Balance, error) {
-	conn, err := ethClient.Connect(conf)
+func (adapter *boxHttpAdapter) GetWithdrawals() ([]Withdrawal, error) {
+	withdrawals, err := adapter.state.Withdrawals()
 	if err != nil {
-		return Balance{}, err
+		return nil, err
 	}
-	addr, err := key.GetAddress()
+	marshalled := []Withdrawal{}
+	for _, withdrawal := range withdrawals {
+		marshalled = append(marshalled, MarshalWithdrawal(withdrawal))
+	}
+	return marshalled, nil
+}
+
+// WithdrawalMessage returns the message that is signed to request a
+// withdrawal.
+func WithdrawalMessage(withdrawal PostWithdrawal) []byte {
+	return []byte(fmt.Sprintf("Republic Protocol: withdraw: %s %s to %s (nonce %s)", withdrawal.Amount, withdrawal.Currency, withdrawal.To, withdrawal.Nonce))
+}
+
+func MarshalWithdrawal(withdrawal store.Withdrawal) Withdrawal {
+	return Withdrawal{
+		ID:           hex.EncodeToString(withdrawal.ID[:]),
+		Currency:     cc.Name(withdrawal.Currency),
+		To:           withdrawal.To,
+		Amount:       withdrawal.Amount.String(),
+		AuthorizedBy: withdrawal.AuthorizedBy,
+		Status:       withdrawal.Status,
+		TxHash:       withdrawal.TxHash,
+		Error:        withdrawal.Error,
+		Time:         withdrawal.Time,
+	}
+}
+
+func MarshalSession(session auth.Session) Session {
+	return Session{
+		Token:       session.Token,
+		Address:     session.Address,
+		Permissions: session.Permissions,
+		ExpiresAt:   session.ExpiresAt,
+	}
+}
+
+func (adapter *boxHttpAdapter) Logout(token string) {
+	adapter.auth.Logout(token)
+}
+
+func (adapter *boxHttpAdapter) Authorize(token, permission string) (Session, error) {
+	session, err := adapter.auth.Authorize(token, permission)
 	if err != nil {
-		return Balance{}, err
+		return Session{}, err
 	}
+	return MarshalSession(session), nil
+}
 
-	address := common.BytesToAddress(addr)
-	bal, err := conn.Client().PendingBalanceAt(context.Background(), address)
+func (adapter *boxHttpAdapter) SwapAction(orderID, action, actor string) error {
+	id, err := UnmarshalOrderID(orderID)
 	if err != nil {
-		return Balance{}, err
+		return err
 	}
+	if err := adapter.admin.Do(id, action, actor); err != nil {
+		if err == store.ErrKeyNotFound {
+			return ErrSwapNotFound
+		}
+		return err
+	}
+	return nil
+}
 
-	return Balance{
-		PriorityCode: key.PriorityCode(),
-		Address:      address.String(),
-		Amount:       bal.Uint64(),
-	}, nil
+func (adapter *boxHttpAdapter) Health() health.Report {
+	return adapter.health.Check()
+}
+
+func (adapter *boxHttpAdapter) AllowedOrigins() []string {
+	return adapter.config.AllowedOrigins()
 }
 
 func MarshalSignature(signatureIn [65]byte) string {
@@ -233,7 +562,7 @@ func UnmarshalSignature(signatureIn string) ([65]byte, error) {
 	signature := [65]byte{}
 	signatureBytes, err := hex.DecodeString(signatureIn)
 	if err != nil {
-		return signature, fmt.Errorf("cannot decode signature %v: %v", signatureIn, err)
+		return signature, ErrInvalidSignature
 	}
 	if len(signatureBytes) != 65 {
 		return signature, ErrInvalidSignatureLength
@@ -250,7 +579,7 @@ func UnmarshalOrderID(orderIDIn string) ([32]byte, error) {
 	orderID := [32]byte{}
 	orderIDBytes, err := hex.DecodeString(orderIDIn)
 	if err != nil {
-		return orderID, fmt.Errorf("cannot decode order id %v: %v", orderIDIn, err)
+		return orderID, ErrInvalidOrderID
 	}
 	if len(orderIDBytes) != 32 {
 		return orderID, ErrInvalidOrderIDLength
@@ -259,6 +588,30 @@ func UnmarshalOrderID(orderIDIn string) ([32]byte, error) {
 	return orderID, nil
 }
 
+func MarshalSwap(summary store.SwapSummary) Swap {
+	swap := Swap{
+		OrderID:      MarshalOrderID(summary.OrderID),
+		Status:       summary.Status,
+		Archived:     summary.Archived,
+		Role:         summary.Role,
+		Transactions: summary.Transactions,
+		StartedAt:    summary.StartedAt,
+		FinishedAt:   summary.FinishedAt,
+	}
+	if summary.ForeignOrderID != [32]byte{} {
+		swap.ForeignOrderID = MarshalOrderID(summary.ForeignOrderID)
+		swap.SendCurrency = cc.Name(summary.SendCurrency)
+		swap.ReceiveCurrency = cc.Name(summary.ReceiveCurrency)
+	}
+	if summary.SendValue != nil {
+		swap.SendValue = summary.SendValue.String()
+	}
+	if summary.ReceiveValue != nil {
+		swap.ReceiveValue = summary.ReceiveValue.String()
+	}
+	return swap
+}
+
 func MarshalBoxInfo(boxInfo BoxInfo) ([]byte, error) {
 	return json.Marshal(boxInfo)
 }
@@ -275,25 +628,42 @@ func UnmarshalBoxInfo(boxInfo []byte) (BoxInfo, error) {
 	return box, nil
 }
 
-func validate(id [32]byte, signature [65]byte, addresses []common.Address) error {
-	message := append([]byte("Republic Protocol: open: "), id[:]...)
+// validate checks that the order ID, prefixed with the message, was signed by
+// one of the addresses.
+func validate(message string, id [32]byte, signature [65]byte, addresses []common.Address) error {
+	addr, err := recoverAddress(append([]byte(message), id[:]...), signature)
+	if err != nil {
+		return err
+	}
+
+	if isAuthorized(addr, addresses) {
+		return nil
+	}
+	return ErrUnauthorizedAddress
+}
+
+// recoverAddress returns the address that signed the message as an Ethereum
+// signed message.
+func recoverAddress(message []byte, signature [65]byte) (common.Address, error) {
 	signatureData := ethCrypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), message)
 
 	marshalledPubKey, err := ethCrypto.Ecrecover(signatureData, signature[:])
 	if err != nil {
-		return err
+		return common.Address{}, ErrInvalidSignature
 	}
 
 	ecdsaPubKey, err := ethCrypto.UnmarshalPubkey(marshalledPubKey)
 	if err != nil {
-		return err
+		return common.Address{}, ErrInvalidSignature
 	}
-	addr := ethCrypto.PubkeyToAddress(*ecdsaPubKey)
+	return ethCrypto.PubkeyToAddress(*ecdsaPubKey), nil
+}
 
+func isAuthorized(addr common.Address, addresses []common.Address) bool {
 	for _, j := range addresses {
 		if j.String() == addr.String() {
-			return nil
+			return true
 		}
 	}
-	return errors.New("Unauthorized Public Key")
+	return false
 }
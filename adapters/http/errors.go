@@ -0,0 +1,132 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/republicprotocol/renex-swapper-go/services/admin"
+	"github.com/republicprotocol/renex-swapper-go/services/auth"
+	"github.com/republicprotocol/renex-swapper-go/services/scheduler"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/wallet"
+	"github.com/republicprotocol/renex-swapper-go/services/watch"
+)
+
+// Error codes identify the errors returned by the API. Unlike the messages,
+// they do not change between versions of the swapper.
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeInvalidOrderID      = "invalid_order_id"
+	CodeInvalidSignature    = "invalid_signature"
+	CodeInvalidChallenge    = "invalid_challenge"
+	CodeInvalidAmount       = "invalid_amount"
+	CodeInvalidCursor       = "invalid_cursor"
+	CodeUnknownCurrency     = "unknown_currency"
+	CodeUnknownAction       = "unknown_action"
+	CodeUnauthorized        = "unauthorized"
+	CodeUnauthorizedAddress = "unauthorized_address"
+	CodeUnknownChallenge    = "unknown_challenge"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeSwapNotFound        = "swap_not_found"
+	CodeOrderMatched        = "order_matched"
+	CodeSwapFinished        = "swap_finished"
+	CodeSwapNotFailed       = "swap_not_failed"
+	CodeSwapNotInitiated    = "swap_not_initiated"
+	CodeSwapNotExpired      = "swap_not_expired"
+	CodeSwapNotComplained   = "swap_not_complained"
+	CodeSwapFunded          = "swap_funded"
+	CodeSwapInFlight        = "swap_in_flight"
+	CodeInsufficientFunds   = "insufficient_funds"
+	CodeDuplicateWithdrawal = "duplicate_withdrawal"
+	CodeUnfunded            = "unfunded"
+	CodeTooManyChallenges   = "too_many_challenges"
+	CodeNotReady            = "not_ready"
+	CodeInternal            = "internal"
+)
+
+// APIError is an error returned by the API. Status is the HTTP status of the
+// response, which is not part of the body.
+type APIError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err APIError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Code, err.Message)
+}
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+type errorCode struct {
+	status int
+	code   string
+}
+
+// errorCodes are the status and code of the errors that the swapper knows
+// about.
+var errorCodes = map[error]errorCode{
+	ErrInvalidOrderID:             {http.StatusBadRequest, CodeInvalidOrderID},
+	ErrInvalidOrderIDLength:       {http.StatusBadRequest, CodeInvalidOrderID},
+	ErrInvalidSignature:           {http.StatusBadRequest, CodeInvalidSignature},
+	ErrInvalidSignatureLength:     {http.StatusBadRequest, CodeInvalidSignature},
+	ErrInvalidChallenge:           {http.StatusBadRequest, CodeInvalidChallenge},
+	ErrInvalidChallengeLength:     {http.StatusBadRequest, CodeInvalidChallenge},
+	ErrInvalidAmount:              {http.StatusBadRequest, CodeInvalidAmount},
+	wallet.ErrInvalidAmount:       {http.StatusBadRequest, CodeInvalidAmount},
+	ErrUnknownCurrency:            {http.StatusBadRequest, CodeUnknownCurrency},
+	store.ErrInvalidCursor:        {http.StatusBadRequest, CodeInvalidCursor},
+	admin.ErrUnknownAction:        {http.StatusBadRequest, CodeUnknownAction},
+	auth.ErrUnauthorized:          {http.StatusUnauthorized, CodeUnauthorized},
+	auth.ErrUnknownChallenge:      {http.StatusUnauthorized, CodeUnknownChallenge},
+	ErrUnauthorizedAddress:        {http.StatusUnauthorized, CodeUnauthorizedAddress},
+	auth.ErrForbidden:             {http.StatusForbidden, CodeForbidden},
+	auth.ErrTooManyChallenges:     {http.StatusTooManyRequests, CodeTooManyChallenges},
+	ErrSwapNotFound:               {http.StatusNotFound, CodeSwapNotFound},
+	watch.ErrOrderMatched:         {http.StatusConflict, CodeOrderMatched},
+	admin.ErrSwapFinished:         {http.StatusConflict, CodeSwapFinished},
+	admin.ErrNotFailed:            {http.StatusConflict, CodeSwapNotFailed},
+	admin.ErrNotInitiated:         {http.StatusConflict, CodeSwapNotInitiated},
+	admin.ErrNotExpired:           {http.StatusConflict, CodeSwapNotExpired},
+	admin.ErrNotComplained:        {http.StatusConflict, CodeSwapNotComplained},
+	admin.ErrFunded:               {http.StatusConflict, CodeSwapFunded},
+	scheduler.ErrInFlight:         {http.StatusConflict, CodeSwapInFlight},
+	wallet.ErrInsufficientFunds:   {http.StatusConflict, CodeInsufficientFunds},
+	wallet.ErrDuplicateWithdrawal: {http.StatusConflict, CodeDuplicateWithdrawal},
+}
+
+// writeError writes the error in an ErrorResponse. Errors that the swapper
+// knows about are written with their own status and code, and other errors
+// with the status and code given.
+func writeError(w http.ResponseWriter, statusCode int, code string, message string, err error) {
+	// Errors are compared rather than looked up, since not every error can
+	// be used as a map key
+	for known, errCode := range errorCodes {
+		if err == known {
+			statusCode, code = errCode.status, errCode.code
+		}
+	}
+	if _, ok := err.(wallet.UnfundedError); ok {
+		statusCode, code = http.StatusUnprocessableEntity, CodeUnfunded
+	}
+	if _, ok := err.(NotReadyError); ok {
+		statusCode, code = http.StatusServiceUnavailable, CodeNotReady
+	}
+	if err != nil {
+		message = fmt.Sprintf("%s: %v", message, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: APIError{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
@@ -1,30 +1,151 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/services/auth"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
 	"github.com/rs/cors"
 )
 
-// NewServer creates a new http handler
+// NewServer creates a new http handler. The API is served under /v1, and
+// also without the prefix for clients that have not moved to it yet.
 func NewServer(adapter BoxHTTPAdapter) http.Handler {
+	handler := cors.New(cors.Options{
+		AllowedOrigins: adapter.AllowedOrigins(),
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "Last-Event-ID"},
+	}).Handler(NewRouter(adapter))
+	return handler
+}
 
+// NewRouter returns the routes of the API, without the CORS handler.
+func NewRouter(adapter BoxHTTPAdapter) *mux.Router {
 	r := mux.NewRouter()
-	r.HandleFunc("/orders", PostOrdersHandler(adapter)).Methods("POST")
-	r.HandleFunc("/status/{orderId}", GetStatusHandler(adapter)).Methods("GET")
-	r.HandleFunc("/whoami/{challenge}", WhoAmIHandler(adapter)).Methods("GET")
-	r.HandleFunc("/balances", GetBalancesHandler(adapter)).Methods("GET")
+	r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	addRoutes(r.PathPrefix("/v1").Subrouter(), adapter)
+	addRoutes(r, adapter)
 	r.Use(RecoveryHandler)
+	r.Use(JSONHandler)
+	return r
+}
 
-	handler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "POST"},
-	}).Handler(r)
-	return handler
+func addRoutes(r *mux.Router, adapter BoxHTTPAdapter) {
+	r.HandleFunc("/openapi.json", GetOpenAPIHandler()).Methods("GET")
+	r.HandleFunc("/health", GetHealthHandler(adapter, false)).Methods("GET")
+	r.HandleFunc("/health/live", GetHealthHandler(adapter, true)).Methods("GET")
+	r.HandleFunc("/login", GetChallengeHandler(adapter)).Methods("GET")
+	r.HandleFunc("/login", PostLoginHandler(adapter)).Methods("POST")
+	r.HandleFunc("/logout", PostLogoutHandler(adapter)).Methods("POST")
+	r.HandleFunc("/orders", Authorize(adapter, auth.PermissionTrade, PostOrdersHandler(adapter))).Methods("POST")
+	r.HandleFunc("/orders/{orderId}", Authorize(adapter, auth.PermissionTrade, DeleteOrderHandler(adapter))).Methods("DELETE")
+	r.HandleFunc("/status/{orderId}", Authorize(adapter, auth.PermissionRead, GetStatusHandler(adapter))).Methods("GET")
+	r.HandleFunc("/whoami/{challenge}", WhoAmIHandler(adapter)).Methods("GET")
+	r.HandleFunc("/balances", Authorize(adapter, auth.PermissionRead, GetBalancesHandler(adapter))).Methods("GET")
+	r.HandleFunc("/swaps", Authorize(adapter, auth.PermissionRead, GetSwapsHandler(adapter))).Methods("GET")
+	r.HandleFunc("/swaps/{orderId}", Authorize(adapter, auth.PermissionRead, GetSwapHandler(adapter))).Methods("GET")
+	r.HandleFunc("/events", Authorize(adapter, auth.PermissionRead, GetEventsHandler(adapter))).Methods("GET")
+	r.HandleFunc("/swaps/{orderId}/actions/{action}", Authorize(adapter, auth.PermissionAdmin, PostSwapActionHandler(adapter))).Methods("POST")
+	r.HandleFunc("/withdrawals", Authorize(adapter, auth.PermissionTrade, PostWithdrawalsHandler(adapter))).Methods("POST")
+	r.HandleFunc("/withdrawals", Authorize(adapter, auth.PermissionRead, GetWithdrawalsHandler(adapter))).Methods("GET")
+}
+
+// Authorize only lets requests through if they have a session token with the
+// permission. The token is read from the Authorization header as a bearer
+// token, or from the token query parameter for clients that cannot set
+// headers, such as EventSource.
+func Authorize(adapter BoxHTTPAdapter, permission string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := adapter.Authorize(sessionToken(r), permission)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, CodeUnauthorized, "cannot authorize the request", err)
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, session)))
+	}
+}
+
+type sessionKey struct{}
+
+// SessionFromRequest returns the session that the request was authorized
+// with.
+func SessionFromRequest(r *http.Request) (Session, bool) {
+	session, ok := r.Context().Value(sessionKey{}).(Session)
+	return session, ok
+}
+
+func sessionToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return r.URL.Query().Get("token")
+}
+
+// GetChallengeHandler handles the get login request, it returns a challenge
+// that an authorized address signs to log in.
+func GetChallengeHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		challenge, err := adapter.NewChallenge()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot create a challenge", err)
+			return
+		}
+
+		challengeJSON, err := json.Marshal(challenge)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the challenge", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(challengeJSON)
+	}
+}
+
+// PostLoginHandler handles the post login request, it checks that the
+// challenge was signed by an authorized address and returns a session token.
+// The signed message is "Republic Protocol: login: " followed by the
+// challenge bytes.
+func PostLoginHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		login := Login{}
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "cannot decode json into login format", err)
+			return
+		}
+
+		session, err := adapter.Login(login)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, CodeUnauthorizedAddress, "cannot log in", err)
+			return
+		}
+
+		sessionJSON, err := json.Marshal(session)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the session", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write(sessionJSON)
+	}
+}
+
+// PostLogoutHandler ends the session of the request's token.
+func PostLogoutHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adapter.Logout(sessionToken(r))
+		w.WriteHeader(http.StatusNoContent)
+	}
 }
 
 // RecoveryHandler handles errors while processing the requests and populates the errors in the response
@@ -32,13 +153,26 @@ func RecoveryHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if r := recover(); r != nil {
-				writeError(w, http.StatusInternalServerError, fmt.Sprintf("%v", r))
+				writeError(w, http.StatusInternalServerError, CodeInternal, fmt.Sprintf("%v", r), nil)
 			}
 		}()
 		h.ServeHTTP(w, r)
 	})
 }
 
+// JSONHandler sets the content type of responses to JSON, handlers that
+// respond with something else set their own.
+func JSONHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		h.ServeHTTP(w, r)
+	})
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("%s %s is not part of the API", r.Method, r.URL.Path), nil)
+}
+
 // PostOrdersHandler handles post orders request, it gets the signed order id,
 // checks whether the signer is authorized, if the signer is authorized this
 // function adds the order id to the queue.
@@ -46,19 +180,19 @@ func PostOrdersHandler(boxHTTPAdapter BoxHTTPAdapter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		postOrder := PostOrder{}
 		if err := json.NewDecoder(r.Body).Decode(&postOrder); err != nil {
-			writeError(w, http.StatusBadRequest, fmt.Sprintf("cannot decode json into post order format: %v", err))
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "cannot decode json into post order format", err)
 			return
 		}
 
 		processedOrder, err := boxHTTPAdapter.PostOrder(postOrder)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot process the order: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot process the order", err)
 			return
 		}
 
 		orderJSON, err := json.Marshal(processedOrder)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot marshal the processed order: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the processed order", err)
 			return
 		}
 
@@ -67,6 +201,84 @@ func PostOrdersHandler(boxHTTPAdapter BoxHTTPAdapter) http.HandlerFunc {
 	}
 }
 
+// DeleteOrderHandler handles the delete order request, it stops the swapper
+// from watching an order that has not been matched yet. The signature query
+// parameter is the order ID prefixed with "Republic Protocol: cancel: " and
+// signed by an authorized address.
+func DeleteOrderHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		if err := adapter.CancelOrder(params["orderId"], r.URL.Query().Get("signature")); err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot cancel the order", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PostSwapActionHandler handles the post swap action request, it lets an
+// operator retry, refund, resolve, resubmit or abandon a stuck swap. The
+// action is recorded in the swap's history against the operator's address.
+func PostSwapActionHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		session, _ := SessionFromRequest(r)
+		if err := adapter.SwapAction(params["orderId"], params["action"], session.Address); err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, fmt.Sprintf("cannot %s the swap", params["action"]), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PostWithdrawalsHandler handles the post withdrawals request, it sends funds
+// that are not reserved for pending swaps from the swapper's own address.
+func PostWithdrawalsHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		postWithdrawal := PostWithdrawal{}
+		if err := json.NewDecoder(r.Body).Decode(&postWithdrawal); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "cannot decode json into post withdrawal format", err)
+			return
+		}
+
+		withdrawal, err := adapter.Withdraw(postWithdrawal)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot withdraw", err)
+			return
+		}
+
+		withdrawalJSON, err := json.Marshal(withdrawal)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the withdrawal", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write(withdrawalJSON)
+	}
+}
+
+// GetWithdrawalsHandler handles the get withdrawals request, it returns the
+// withdrawals that have been made, most recent first.
+func GetWithdrawalsHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		withdrawals, err := adapter.GetWithdrawals()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot get the withdrawals", err)
+			return
+		}
+
+		withdrawalsJSON, err := json.Marshal(withdrawals)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the withdrawals", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(withdrawalsJSON)
+	}
+}
+
 // WhoAmIHandler handles the get whoami request,it gets a challenge from the
 // caller signs it and sends back the signed challenge with it's version
 // information.
@@ -77,12 +289,12 @@ func WhoAmIHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
 		whoami, err := adapter.WhoAmI(params["challenge"])
 		if err != nil {
 
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot get the whoami information: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot get the whoami information", err)
 			return
 		}
 		whoamiJSON, err := json.Marshal(whoami)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot marshal whoami information: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal whoami information", err)
 			return
 		}
 
@@ -97,13 +309,13 @@ func GetStatusHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
 		params := mux.Vars(r)
 		status, err := adapter.GetStatus(params["orderId"])
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot get the status information: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot get the status information", err)
 			return
 		}
 
 		statusJSON, err := json.Marshal(status)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot marshal status information: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal status information", err)
 			return
 		}
 
@@ -116,13 +328,13 @@ func GetBalancesHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		balances, err := adapter.GetBalances()
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot get the balances: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot get the balances", err)
 			return
 		}
 
 		balancesJSON, err := json.Marshal(balances)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("cannot marshal the balance information: %v", err))
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the balance information", err)
 			return
 		}
 
@@ -131,8 +343,210 @@ func GetBalancesHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
 	}
 }
 
-func writeError(w http.ResponseWriter, statusCode int, err string) {
-	w.WriteHeader(statusCode)
-	w.Write([]byte(err))
-	return
+// GetHealthHandler reports the health of the swapper's dependencies. It
+// responds with 503 Service Unavailable if the swapper is not ready to take
+// orders, or if live is true, only if restarting the swapper could fix it.
+func GetHealthHandler(adapter BoxHTTPAdapter, live bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := adapter.Health()
+		reportJSON, err := json.Marshal(report)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the health report", err)
+			return
+		}
+
+		healthy := report.Ready
+		if live {
+			healthy = report.Live
+		}
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		w.Write(reportJSON)
+	}
 }
+
+// GetSwapsHandler handles the get swaps request, it lists the pending and
+// archived swaps that match the filters in the query string. The filters are
+// status, pair (for example BTC-ETH), role (requestor or responder), and from
+// and to (unix timestamps of when the swap started). Pages are selected using
+// limit and cursor, and order is either asc or desc.
+func GetSwapsHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseSwapQuery(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "invalid swap query", err)
+			return
+		}
+
+		swaps, err := adapter.GetSwaps(query)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot get the swaps", err)
+			return
+		}
+
+		swapsJSON, err := json.Marshal(swaps)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the swaps", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(swapsJSON)
+	}
+}
+
+// GetSwapHandler handles the get swap request, it returns the details of a
+// pending or archived swap and what the swap is waiting for.
+func GetSwapHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		if _, err := UnmarshalOrderID(params["orderId"]); err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidOrderID, "invalid order id", err)
+			return
+		}
+
+		details, err := adapter.GetSwap(params["orderId"])
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot get the swap", err)
+			return
+		}
+
+		detailsJSON, err := json.Marshal(details)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "cannot marshal the swap", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(detailsJSON)
+	}
+}
+
+// GetEventsHandler streams swap events as server-sent events. Events of a
+// single order can be selected with the orderId query parameter. Clients that
+// reconnect with the Last-Event-ID header, or the lastEventId query parameter,
+// receive the events they missed. A reset event is sent first if some of those
+// events are no longer available, in which case the swaps should be fetched
+// again.
+func GetEventsHandler(adapter BoxHTTPAdapter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, CodeInternal, "streaming is not supported", nil)
+			return
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("lastEventId")
+		}
+		var after uint64
+		if lastEventID != "" {
+			var err error
+			if after, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+				writeError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("invalid last event id: %s", lastEventID), nil)
+				return
+			}
+		}
+
+		sub, err := adapter.Subscribe(r.URL.Query().Get("orderId"), after)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidOrderID, "invalid order id", err)
+			return
+		}
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		if sub.Missed {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case event, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				eventJSON, err := json.Marshal(MarshalEvent(event))
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, eventJSON)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func parseSwapQuery(values url.Values) (store.SwapQuery, error) {
+	query := store.SwapQuery{
+		Status: strings.ToUpper(values.Get("status")),
+		Role:   strings.ToUpper(values.Get("role")),
+		Cursor: values.Get("cursor"),
+	}
+	if query.Role != "" && query.Role != store.RoleRequestor && query.Role != store.RoleResponder {
+		return query, fmt.Errorf("unknown role: %s", values.Get("role"))
+	}
+
+	if pair := values.Get("pair"); pair != "" {
+		tokens := strings.Split(strings.ToUpper(pair), "-")
+		if len(tokens) != 2 {
+			return query, fmt.Errorf("pair must be of the form BTC-ETH: %s", pair)
+		}
+		for _, token := range tokens {
+			code, err := cc.Code(token)
+			if err != nil {
+				return query, err
+			}
+			query.Currencies = append(query.Currencies, code)
+		}
+	}
+
+	var err error
+	if query.From, err = parseInt(values, "from"); err != nil {
+		return query, err
+	}
+	if query.To, err = parseInt(values, "to"); err != nil {
+		return query, err
+	}
+	limit, err := parseInt(values, "limit")
+	if err != nil {
+		return query, err
+	}
+	query.Limit = int(limit)
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("order must be asc or desc: %s", values.Get("order"))
+	}
+	return query, nil
+}
+
+func parseInt(values url.Values, key string) (int64, error) {
+	value := values.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a positive integer: %s", key, value)
+	}
+	return n, nil
+}
+
@@ -0,0 +1,1669 @@
+package http
+
+import (
+	"net/http"
+)
+
+// GetOpenAPIHandler serves the OpenAPI description of the API.
+func GetOpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(OpenAPI))
+	}
+}
+
+// OpenAPI describes the API served under /v1. It must be updated along with
+// the routes in NewServer.
+const OpenAPI = `{
+  "openapi": "3.0.0",
+  "info": {
+    "title": "RenEx Atomic Swapper",
+    "version": "1",
+    "description": "Every error response has an ErrorResponse body. Its code identifies the error and does not change between versions, unlike its message."
+  },
+  "servers": [
+    {
+      "url": "/v1"
+    }
+  ],
+  "components": {
+    "securitySchemes": {
+      "bearer": {
+        "type": "http",
+        "scheme": "bearer",
+        "description": "Session token returned by POST /login. GET /events also accepts it as the token query parameter."
+      }
+    },
+    "schemas": {
+      "ErrorResponse": {
+        "type": "object",
+        "properties": {
+          "error": {
+            "type": "object",
+            "properties": {
+              "code": {
+                "type": "string",
+                "enum": [
+                  "invalid_request",
+                  "invalid_order_id",
+                  "invalid_signature",
+                  "invalid_challenge",
+                  "invalid_amount",
+                  "invalid_cursor",
+                  "unknown_currency",
+                  "unknown_action",
+                  "unauthorized",
+                  "unauthorized_address",
+                  "unknown_challenge",
+                  "forbidden",
+                  "not_found",
+                  "swap_not_found",
+                  "order_matched",
+                  "swap_finished",
+                  "swap_not_failed",
+                  "swap_not_initiated",
+                  "swap_not_expired",
+                  "swap_not_complained",
+                  "swap_funded",
+                  "swap_in_flight",
+                  "insufficient_funds",
+                  "duplicate_withdrawal",
+                  "unfunded",
+                  "too_many_challenges",
+                  "not_ready",
+                  "internal"
+                ]
+              },
+              "message": {
+                "type": "string"
+              }
+            },
+            "required": [
+              "code",
+              "message"
+            ]
+          }
+        },
+        "required": [
+          "error"
+        ]
+      },
+      "Challenge": {
+        "type": "object",
+        "properties": {
+          "challenge": {
+            "type": "string"
+          },
+          "expiresAt": {
+            "type": "integer",
+            "format": "int64"
+          }
+        }
+      },
+      "Login": {
+        "type": "object",
+        "properties": {
+          "challenge": {
+            "type": "string"
+          },
+          "signature": {
+            "type": "string",
+            "description": "Signature of \"Republic Protocol: login: \" followed by the challenge bytes, as an Ethereum signed message"
+          }
+        },
+        "required": [
+          "challenge",
+          "signature"
+        ]
+      },
+      "Session": {
+        "type": "object",
+        "properties": {
+          "token": {
+            "type": "string"
+          },
+          "address": {
+            "type": "string"
+          },
+          "permissions": {
+            "type": "array",
+            "items": {
+              "type": "string",
+              "enum": [
+                "read",
+                "trade",
+                "admin"
+              ]
+            }
+          },
+          "expiresAt": {
+            "type": "integer",
+            "format": "int64"
+          }
+        }
+      },
+      "BoxInfo": {
+        "type": "object",
+        "properties": {
+          "challenge": {
+            "type": "string"
+          },
+          "version": {
+            "type": "string"
+          },
+          "authorizedAddresses": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "supportedCurrencies": {
+            "type": "array",
+            "items": {
+              "type": "string"
+            }
+          },
+          "certificateFingerprint": {
+            "type": "string"
+          }
+        }
+      },
+      "WhoAmI": {
+        "type": "object",
+        "properties": {
+          "boxInfo": {
+            "$ref": "#/components/schemas/BoxInfo"
+          },
+          "signature": {
+            "type": "string"
+          }
+        }
+      },
+      "PostOrder": {
+        "type": "object",
+        "properties": {
+          "orderID": {
+            "type": "string"
+          },
+          "signature": {
+            "type": "string",
+            "description": "Signature of \"Republic Protocol: open: \" followed by the order ID bytes"
+          },
+          "sendCurrency": {
+            "type": "string",
+            "description": "Used to check funding if RenEx does not have the order yet"
+          },
+          "maxSendAmount": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "warning": {
+            "type": "string",
+            "description": "Set in the response if the order was accepted without being funded"
+          }
+        },
+        "required": [
+          "orderID",
+          "signature"
+        ]
+      },
+      "Status": {
+        "type": "object",
+        "properties": {
+          "orderID": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string"
+          }
+        }
+      },
+      "Balance": {
+        "type": "object",
+        "properties": {
+          "currency": {
+            "type": "string"
+          },
+          "address": {
+            "type": "string"
+          },
+          "priorityCode": {
+            "type": "integer"
+          },
+          "amount": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "confirmed": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "unconfirmed": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "locked": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "available": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          }
+        }
+      },
+      "FeeBalance": {
+        "type": "object",
+        "properties": {
+          "trader": {
+            "type": "string"
+          },
+          "amount": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          }
+        }
+      },
+      "Balances": {
+        "type": "object",
+        "properties": {
+          "balances": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/Balance"
+            }
+          },
+          "renExFees": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/FeeBalance"
+            }
+          }
+        }
+      },
+      "Transactions": {
+        "type": "object",
+        "properties": {
+          "contract": {
+            "type": "string"
+          },
+          "initiate": {
+            "type": "string"
+          },
+          "redeem": {
+            "type": "string"
+          },
+          "refund": {
+            "type": "string"
+          }
+        }
+      },
+      "Swap": {
+        "type": "object",
+        "properties": {
+          "orderID": {
+            "type": "string"
+          },
+          "foreignOrderID": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string"
+          },
+          "archived": {
+            "type": "boolean"
+          },
+          "role": {
+            "type": "string",
+            "enum": [
+              "REQUESTOR",
+              "RESPONDER"
+            ]
+          },
+          "sendCurrency": {
+            "type": "string"
+          },
+          "receiveCurrency": {
+            "type": "string"
+          },
+          "sendValue": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "receiveValue": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "transactions": {
+            "$ref": "#/components/schemas/Transactions"
+          },
+          "startedAt": {
+            "type": "integer",
+            "format": "int64"
+          },
+          "finishedAt": {
+            "type": "integer",
+            "format": "int64"
+          }
+        }
+      },
+      "Swaps": {
+        "type": "object",
+        "properties": {
+          "swaps": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/Swap"
+            }
+          },
+          "nextCursor": {
+            "type": "string"
+          }
+        }
+      },
+      "SwapDetails": {
+        "allOf": [
+          {
+            "$ref": "#/components/schemas/Swap"
+          },
+          {
+            "type": "object",
+            "properties": {
+              "hashLock": {
+                "type": "string"
+              },
+              "expiry": {
+                "type": "integer",
+                "format": "int64"
+              },
+              "counterpartyTransactions": {
+                "$ref": "#/components/schemas/Transactions"
+              },
+              "nextAction": {
+                "type": "object",
+                "properties": {
+                  "description": {
+                    "type": "string"
+                  },
+                  "deadline": {
+                    "type": "integer",
+                    "format": "int64"
+                  }
+                }
+              },
+              "complaint": {
+                "type": "object",
+                "properties": {
+                  "reason": {
+                    "type": "string"
+                  },
+                  "time": {
+                    "type": "integer",
+                    "format": "int64"
+                  },
+                  "resolvedBy": {
+                    "type": "string"
+                  },
+                  "resolvedAt": {
+                    "type": "integer",
+                    "format": "int64"
+                  }
+                }
+              },
+              "error": {
+                "type": "object",
+                "properties": {
+                  "message": {
+                    "type": "string"
+                  },
+                  "time": {
+                    "type": "integer",
+                    "format": "int64"
+                  },
+                  "attempts": {
+                    "type": "integer"
+                  },
+                  "permanent": {
+                    "type": "boolean"
+                  },
+                  "retryAt": {
+                    "type": "integer",
+                    "format": "int64"
+                  },
+                  "refund": {
+                    "type": "boolean"
+                  }
+                }
+              },
+              "history": {
+                "type": "array",
+                "items": {
+                  "type": "object",
+                  "properties": {
+                    "status": {
+                      "type": "string"
+                    },
+                    "action": {
+                      "type": "string"
+                    },
+                    "actor": {
+                      "type": "string"
+                    },
+                    "error": {
+                      "type": "string"
+                    },
+                    "attempt": {
+                      "type": "integer"
+                    },
+                    "time": {
+                      "type": "integer",
+                      "format": "int64"
+                    }
+                  }
+                }
+              },
+              "pruned": {
+                "type": "boolean"
+              }
+            }
+          }
+        ]
+      },
+      "Check": {
+        "type": "object",
+        "properties": {
+          "name": {
+            "type": "string",
+            "enum": [
+              "bitcoin",
+              "ethereum",
+              "watchdog",
+              "store",
+              "keystore",
+              "watcher",
+              "guardian"
+            ]
+          },
+          "healthy": {
+            "type": "boolean"
+          },
+          "error": {
+            "type": "string"
+          },
+          "details": {
+            "type": "object",
+            "description": "Block heights, initialBlockDownload for bitcoin, syncing and latestBlockAgeSecs for ethereum"
+          }
+        }
+      },
+      "Health": {
+        "type": "object",
+        "properties": {
+          "live": {
+            "type": "boolean",
+            "description": "False if restarting the swapper could fix it"
+          },
+          "ready": {
+            "type": "boolean",
+            "description": "False if any check failed, orders are refused"
+          },
+          "checks": {
+            "type": "array",
+            "items": {
+              "$ref": "#/components/schemas/Check"
+            }
+          },
+          "time": {
+            "type": "integer",
+            "format": "int64"
+          }
+        }
+      },
+      "PostWithdrawal": {
+        "type": "object",
+        "properties": {
+          "currency": {
+            "type": "string"
+          },
+          "to": {
+            "type": "string"
+          },
+          "amount": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "nonce": {
+            "type": "string"
+          },
+          "signature": {
+            "type": "string",
+            "description": "Signature of \"Republic Protocol: withdraw: <amount> <currency> to <to> (nonce <nonce>)\""
+          }
+        },
+        "required": [
+          "currency",
+          "to",
+          "amount",
+          "nonce",
+          "signature"
+        ]
+      },
+      "Withdrawal": {
+        "type": "object",
+        "properties": {
+          "id": {
+            "type": "string"
+          },
+          "currency": {
+            "type": "string"
+          },
+          "to": {
+            "type": "string"
+          },
+          "amount": {
+            "type": "string",
+            "description": "Decimal amount in the base unit of the currency, satoshis or wei"
+          },
+          "authorizedBy": {
+            "type": "string"
+          },
+          "status": {
+            "type": "string",
+            "enum": [
+              "PENDING",
+              "SENT",
+              "FAILED"
+            ]
+          },
+          "txHash": {
+            "type": "string"
+          },
+          "error": {
+            "type": "string"
+          },
+          "time": {
+            "type": "integer",
+            "format": "int64"
+          }
+        }
+      }
+    }
+  },
+  "paths": {
+    "/openapi.json": {
+      "get": {
+        "summary": "This document",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "The OpenAPI document"
+          }
+        }
+      }
+    },
+    "/health": {
+      "get": {
+        "summary": "Check the health of the swapper's dependencies",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "Ready to take orders",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Health"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Not ready",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Health"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/health/live": {
+      "get": {
+        "summary": "Check the parts of the swapper that restarting could fix",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "Live",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Health"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Not live, restart the swapper",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Health"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/login": {
+      "get": {
+        "summary": "Get a challenge to log in with",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "A challenge",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Challenge"
+                }
+              }
+            }
+          },
+          "429": {
+            "description": "Too many challenges are waiting to be signed",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "summary": "Log in with a signed challenge",
+        "security": [],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/Login"
+              }
+            }
+          }
+        },
+        "responses": {
+          "201": {
+            "description": "A session",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Session"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/logout": {
+      "post": {
+        "summary": "End the session of the token",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "responses": {
+          "204": {
+            "description": "Logged out"
+          }
+        }
+      }
+    },
+    "/whoami/{challenge}": {
+      "get": {
+        "summary": "Get the swapper's information, signed along with the challenge",
+        "security": [],
+        "parameters": [
+          {
+            "name": "challenge",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Signed information",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/WhoAmI"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/orders": {
+      "post": {
+        "summary": "Post a signed order for the swapper to settle",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "trade",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/PostOrder"
+              }
+            }
+          }
+        },
+        "responses": {
+          "201": {
+            "description": "The order signed by the swapper",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/PostOrder"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "422": {
+            "description": "Order cannot be funded",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "503": {
+            "description": "Swapper is not ready to take orders",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/orders/{orderId}": {
+      "delete": {
+        "summary": "Stop watching an order that has not been matched",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "trade",
+        "parameters": [
+          {
+            "name": "orderId",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Hex encoded order ID"
+          },
+          {
+            "name": "signature",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Signature of \"Republic Protocol: cancel: \" followed by the order ID bytes"
+          }
+        ],
+        "responses": {
+          "204": {
+            "description": "Order withdrawn"
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Swap not found",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "Conflicts with the state of the swap or wallet",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/status/{orderId}": {
+      "get": {
+        "summary": "Get the status of an order",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "read",
+        "parameters": [
+          {
+            "name": "orderId",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Hex encoded order ID"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Status",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Status"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/balances": {
+      "get": {
+        "summary": "Get the swapper's balances and the RenEx fee balances of authorized traders",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "read",
+        "responses": {
+          "200": {
+            "description": "Balances",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Balances"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/swaps": {
+      "get": {
+        "summary": "List swaps",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "read",
+        "parameters": [
+          {
+            "name": "status",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Status of the swaps"
+          },
+          {
+            "name": "pair",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Currencies traded, for example BTC-ETH"
+          },
+          {
+            "name": "role",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "requestor or responder"
+          },
+          {
+            "name": "from",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "integer",
+              "format": "int64"
+            },
+            "description": "Unix time the swaps started after"
+          },
+          {
+            "name": "to",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "integer",
+              "format": "int64"
+            },
+            "description": "Unix time the swaps started before"
+          },
+          {
+            "name": "limit",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "integer"
+            },
+            "description": "Number of swaps in a page"
+          },
+          {
+            "name": "cursor",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "nextCursor of the previous page"
+          },
+          {
+            "name": "order",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "asc or desc"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "A page of swaps",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Swaps"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/swaps/{orderId}": {
+      "get": {
+        "summary": "Get the details of a swap",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "read",
+        "parameters": [
+          {
+            "name": "orderId",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Hex encoded order ID"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Swap details",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/SwapDetails"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Swap not found",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/swaps/{orderId}/actions/{action}": {
+      "post": {
+        "summary": "Act on a stuck swap",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "admin",
+        "parameters": [
+          {
+            "name": "orderId",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Hex encoded order ID"
+          },
+          {
+            "name": "action",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string",
+              "enum": [
+                "retry",
+                "refund",
+                "resolve",
+                "resubmit",
+                "abandon"
+              ]
+            }
+          }
+        ],
+        "responses": {
+          "204": {
+            "description": "Action taken"
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Swap not found",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "Conflicts with the state of the swap or wallet",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/events": {
+      "get": {
+        "summary": "Stream swap events as server-sent events",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "read",
+        "parameters": [
+          {
+            "name": "orderId",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Only stream the events of this order"
+          },
+          {
+            "name": "lastEventId",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Resume after this event, instead of the Last-Event-ID header"
+          },
+          {
+            "name": "token",
+            "in": "query",
+            "required": false,
+            "schema": {
+              "type": "string"
+            },
+            "description": "Session token, for clients that cannot set headers"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "Stream of events",
+            "content": {
+              "text/event-stream": {
+                "schema": {
+                  "type": "string"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "/withdrawals": {
+      "get": {
+        "summary": "List withdrawals, most recent first",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "read",
+        "responses": {
+          "200": {
+            "description": "Withdrawals",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "array",
+                  "items": {
+                    "$ref": "#/components/schemas/Withdrawal"
+                  }
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      },
+      "post": {
+        "summary": "Withdraw funds that are not reserved for pending swaps",
+        "security": [
+          {
+            "bearer": []
+          }
+        ],
+        "x-permission": "trade",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {
+              "schema": {
+                "$ref": "#/components/schemas/PostWithdrawal"
+              }
+            }
+          }
+        },
+        "responses": {
+          "201": {
+            "description": "The withdrawal",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/Withdrawal"
+                }
+              }
+            }
+          },
+          "400": {
+            "description": "Invalid request",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "401": {
+            "description": "Missing or expired session token, or unauthorized signer",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "403": {
+            "description": "Session does not have the permission",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "409": {
+            "description": "Conflicts with the state of the swap or wallet",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          },
+          "500": {
+            "description": "Internal error",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorResponse"
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,49 @@
+package http_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/adapters/http"
+)
+
+var _ = Describe("TLS certificates", func() {
+	var dir, certFile, keyFile string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "swapper-tls")
+		Expect(err).ShouldNot(HaveOccurred())
+		certFile = filepath.Join(dir, "tls", "cert.pem")
+		keyFile = filepath.Join(dir, "tls", "key.pem")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("generates a self-signed certificate that is kept across restarts", func() {
+		cert, err := LoadCertificate(certFile, keyFile, true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(Fingerprint(cert)).Should(HaveLen(64))
+
+		info, err := os.Stat(keyFile)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Mode().Perm()).Should(Equal(os.FileMode(0600)))
+
+		reloaded, err := LoadCertificate(certFile, keyFile, true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(Fingerprint(reloaded)).Should(Equal(Fingerprint(cert)))
+	})
+
+	It("does not generate configured certificates", func() {
+		_, err := LoadCertificate(certFile, keyFile, false)
+		Expect(err).Should(HaveOccurred())
+		_, err = os.Stat(certFile)
+		Expect(os.IsNotExist(err)).Should(BeTrue())
+	})
+})
@@ -0,0 +1,25 @@
+package bolt_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/adapters/store/bolt"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/storetest"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Bolt store", func() {
+	storetest.ItBehavesLikeAStore(func() (store.Store, func()) {
+		dir, err := ioutil.TempDir("", "swapper-bolt")
+		Expect(err).ShouldNot(HaveOccurred())
+		db, err := NewBoltStore(dir + "/swapper.bolt")
+		Expect(err).ShouldNot(HaveOccurred())
+		return db, func() {
+			Expect(os.RemoveAll(dir)).ShouldNot(HaveOccurred())
+		}
+	})
+})
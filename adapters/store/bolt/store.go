@@ -0,0 +1,123 @@
+package bolt
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("swapper")
+
+type boltStore struct {
+	db *bolt.DB
+}
+
+type boltOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+type boltBatch struct {
+	db  *bolt.DB
+	ops []boltOp
+}
+
+// NewBoltStore opens, or creates, a single file BoltDB database at the path.
+func NewBoltStore(path string) (store.Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStore{
+		db: db,
+	}, nil
+}
+
+func (bs *boltStore) Read(key []byte) ([]byte, error) {
+	var value []byte
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucket).Get(key)
+		if v == nil {
+			return store.ErrKeyNotFound
+		}
+		value = append([]byte{}, v...)
+		return nil
+	})
+	return value, err
+}
+
+func (bs *boltStore) Write(key []byte, value []byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, value)
+	})
+}
+
+func (bs *boltStore) Delete(key []byte) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+func (bs *boltStore) Iterate(prefix []byte, f func(key, value []byte) error) error {
+	return bs.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if err := f(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *boltStore) NewBatch() store.Batch {
+	return &boltBatch{
+		db: bs.db,
+	}
+}
+
+func (bs *boltStore) Close() error {
+	return bs.db.Close()
+}
+
+func (batch *boltBatch) Write(key []byte, value []byte) {
+	batch.ops = append(batch.ops, boltOp{
+		key:   append([]byte{}, key...),
+		value: append([]byte{}, value...),
+	})
+}
+
+func (batch *boltBatch) Delete(key []byte) {
+	batch.ops = append(batch.ops, boltOp{
+		key:    append([]byte{}, key...),
+		delete: true,
+	})
+}
+
+func (batch *boltBatch) Commit() error {
+	return batch.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, op := range batch.ops {
+			if op.delete {
+				if err := b.Delete(op.key); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := b.Put(op.key, op.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
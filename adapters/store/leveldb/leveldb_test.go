@@ -0,0 +1,25 @@
+package leveldb_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/adapters/store/leveldb"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/storetest"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("LevelDB store", func() {
+	storetest.ItBehavesLikeAStore(func() (store.Store, func()) {
+		dir, err := ioutil.TempDir("", "swapper-leveldb")
+		Expect(err).ShouldNot(HaveOccurred())
+		db, err := NewLDBStore(dir)
+		Expect(err).ShouldNot(HaveOccurred())
+		return db, func() {
+			Expect(os.RemoveAll(dir)).ShouldNot(HaveOccurred())
+		}
+	})
+})
@@ -3,12 +3,18 @@ package leveldb
 import (
 	"github.com/republicprotocol/renex-swapper-go/services/store"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 type ldbStore struct {
 	db *leveldb.DB
 }
 
+type ldbBatch struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+}
+
 func NewLDBStore(path string) (store.Store, error) {
 	db, err := leveldb.OpenFile(path, nil)
 	if err != nil {
@@ -20,7 +26,11 @@ func NewLDBStore(path string) (store.Store, error) {
 }
 
 func (ldb *ldbStore) Read(key []byte) ([]byte, error) {
-	return ldb.db.Get(key, nil)
+	value, err := ldb.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, store.ErrKeyNotFound
+	}
+	return value, err
 }
 
 func (ldb *ldbStore) Write(key []byte, value []byte) error {
@@ -31,6 +41,36 @@ func (ldb *ldbStore) Delete(key []byte) error {
 	return ldb.db.Delete(key, nil)
 }
 
+func (ldb *ldbStore) Iterate(prefix []byte, f func(key, value []byte) error) error {
+	iter := ldb.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		if err := f(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func (ldb *ldbStore) NewBatch() store.Batch {
+	return &ldbBatch{
+		db:    ldb.db,
+		batch: new(leveldb.Batch),
+	}
+}
+
 func (ldb *ldbStore) Close() error {
 	return ldb.db.Close()
 }
+
+func (batch *ldbBatch) Write(key []byte, value []byte) {
+	batch.batch.Put(key, value)
+}
+
+func (batch *ldbBatch) Delete(key []byte) {
+	batch.batch.Delete(key)
+}
+
+func (batch *ldbBatch) Commit() error {
+	return batch.db.Write(batch.batch, nil)
+}
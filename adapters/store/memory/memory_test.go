@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	. "github.com/onsi/ginkgo"
+
+	. "github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/storetest"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Memory store", func() {
+	storetest.ItBehavesLikeAStore(func() (store.Store, func()) {
+		return NewMemoryStore(), func() {}
+	})
+})
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+type memoryStore struct {
+	mu      *sync.RWMutex
+	records map[string][]byte
+}
+
+type memoryBatch struct {
+	store   *memoryStore
+	writes  map[string][]byte
+	deletes map[string]bool
+	order   []string
+}
+
+// NewMemoryStore returns a Store that keeps all of its records in memory. It
+// is intended for tests.
+func NewMemoryStore() store.Store {
+	return &memoryStore{
+		mu:      new(sync.RWMutex),
+		records: map[string][]byte{},
+	}
+}
+
+func (mem *memoryStore) Read(key []byte) ([]byte, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	value, ok := mem.records[string(key)]
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return copyBytes(value), nil
+}
+
+func (mem *memoryStore) Write(key []byte, value []byte) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	mem.records[string(key)] = copyBytes(value)
+	return nil
+}
+
+func (mem *memoryStore) Delete(key []byte) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	delete(mem.records, string(key))
+	return nil
+}
+
+func (mem *memoryStore) Iterate(prefix []byte, f func(key, value []byte) error) error {
+	mem.mu.RLock()
+	keys := []string{}
+	values := map[string][]byte{}
+	for key, value := range mem.records {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+			values[key] = copyBytes(value)
+		}
+	}
+	mem.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := f([]byte(key), values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mem *memoryStore) NewBatch() store.Batch {
+	return &memoryBatch{
+		store:   mem,
+		writes:  map[string][]byte{},
+		deletes: map[string]bool{},
+	}
+}
+
+func (mem *memoryStore) Close() error {
+	return nil
+}
+
+func (batch *memoryBatch) Write(key []byte, value []byte) {
+	batch.writes[string(key)] = copyBytes(value)
+	delete(batch.deletes, string(key))
+	batch.order = append(batch.order, string(key))
+}
+
+func (batch *memoryBatch) Delete(key []byte) {
+	batch.deletes[string(key)] = true
+	delete(batch.writes, string(key))
+	batch.order = append(batch.order, string(key))
+}
+
+func (batch *memoryBatch) Commit() error {
+	batch.store.mu.Lock()
+	defer batch.store.mu.Unlock()
+	for _, key := range batch.order {
+		if value, ok := batch.writes[key]; ok {
+			batch.store.records[key] = value
+		}
+		if batch.deletes[key] {
+			delete(batch.store.records, key)
+		}
+	}
+	return nil
+}
+
+func copyBytes(b []byte) []byte {
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/adapters/store/sqlite"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/storetest"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("SQLite store", func() {
+	storetest.ItBehavesLikeAStore(func() (store.Store, func()) {
+		dir, err := ioutil.TempDir("", "swapper-sqlite")
+		Expect(err).ShouldNot(HaveOccurred())
+		db, err := NewSQLiteStore(dir + "/swapper.sqlite")
+		Expect(err).ShouldNot(HaveOccurred())
+		return db, func() {
+			Expect(os.RemoveAll(dir)).ShouldNot(HaveOccurred())
+		}
+	})
+})
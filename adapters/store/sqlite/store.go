@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	// Registers the sqlite3 driver with database/sql.
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+type sqliteOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+type sqliteBatch struct {
+	db  *sql.DB
+	ops []sqliteOp
+}
+
+// NewSQLiteStore opens, or creates, a SQLite database at the path. Records are
+// kept in the "records" table, which can be inspected using the sqlite3 shell,
+// for example:
+//
+//	SELECT CAST(key AS TEXT), hex(key), value FROM records;
+func NewSQLiteStore(path string) (store.Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only allows a single writer, so all queries share a connection
+	// rather than fail with "database is locked".
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS records (key BLOB PRIMARY KEY, value BLOB NOT NULL)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{
+		db: db,
+	}, nil
+}
+
+func (ss *sqliteStore) Read(key []byte) ([]byte, error) {
+	var value []byte
+	err := ss.db.QueryRow(`SELECT value FROM records WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrKeyNotFound
+	}
+	return value, err
+}
+
+func (ss *sqliteStore) Write(key []byte, value []byte) error {
+	_, err := ss.db.Exec(`INSERT OR REPLACE INTO records (key, value) VALUES (?, ?)`, key, value)
+	return err
+}
+
+func (ss *sqliteStore) Delete(key []byte) error {
+	_, err := ss.db.Exec(`DELETE FROM records WHERE key = ?`, key)
+	return err
+}
+
+func (ss *sqliteStore) Iterate(prefix []byte, f func(key, value []byte) error) error {
+	var rows *sql.Rows
+	var err error
+	if len(prefix) == 0 {
+		rows, err = ss.db.Query(`SELECT key, value FROM records ORDER BY key`)
+	} else {
+		rows, err = ss.db.Query(`SELECT key, value FROM records WHERE substr(key, 1, ?) = ? ORDER BY key`, len(prefix), prefix)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The rows are read before calling the function, which would otherwise be
+	// unable to read from the store while holding the only connection.
+	keys, values := [][]byte{}, [][]byte{}
+	for rows.Next() {
+		var key, value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range keys {
+		if err := f(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ss *sqliteStore) NewBatch() store.Batch {
+	return &sqliteBatch{
+		db: ss.db,
+	}
+}
+
+func (ss *sqliteStore) Close() error {
+	return ss.db.Close()
+}
+
+func (batch *sqliteBatch) Write(key []byte, value []byte) {
+	batch.ops = append(batch.ops, sqliteOp{
+		key:   append([]byte{}, key...),
+		value: append([]byte{}, value...),
+	})
+}
+
+func (batch *sqliteBatch) Delete(key []byte) {
+	batch.ops = append(batch.ops, sqliteOp{
+		key:    append([]byte{}, key...),
+		delete: true,
+	})
+}
+
+func (batch *sqliteBatch) Commit() error {
+	tx, err := batch.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, op := range batch.ops {
+		if op.delete {
+			_, err = tx.Exec(`DELETE FROM records WHERE key = ?`, op.key)
+		} else {
+			_, err = tx.Exec(`INSERT OR REPLACE INTO records (key, value) VALUES (?, ?)`, op.key, op.value)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
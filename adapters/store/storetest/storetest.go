@@ -0,0 +1,169 @@
+// Package storetest contains the conformance tests that every implementation
+// of the store.Store interface must pass.
+package storetest
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+// ItBehavesLikeAStore runs the conformance tests against Stores returned by
+// newStore. The cleanup function returned alongside each Store is called
+// after it has been closed.
+func ItBehavesLikeAStore(newStore func() (store.Store, func())) {
+	var db store.Store
+	var cleanup func()
+
+	BeforeEach(func() {
+		db, cleanup = newStore()
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).ShouldNot(HaveOccurred())
+		cleanup()
+	})
+
+	Context("when reading and writing", func() {
+		It("returns ErrKeyNotFound for missing keys", func() {
+			_, err := db.Read([]byte("missing"))
+			Expect(err).Should(Equal(store.ErrKeyNotFound))
+		})
+
+		It("reads the value that was written", func() {
+			Expect(db.Write([]byte("key"), []byte("value"))).ShouldNot(HaveOccurred())
+			value, err := db.Read([]byte("key"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal([]byte("value")))
+		})
+
+		It("overwrites existing values", func() {
+			Expect(db.Write([]byte("key"), []byte("first"))).ShouldNot(HaveOccurred())
+			Expect(db.Write([]byte("key"), []byte("second"))).ShouldNot(HaveOccurred())
+			value, err := db.Read([]byte("key"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal([]byte("second")))
+		})
+
+		It("supports binary keys and values", func() {
+			key := []byte{'S', ':', 0x00, 0xFF, 0x10}
+			value := []byte{0x00, 0x01, 0xFE, 0xFF}
+			Expect(db.Write(key, value)).ShouldNot(HaveOccurred())
+			stored, err := db.Read(key)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(stored).Should(Equal(value))
+		})
+
+		It("does not alias the slices that are read or written", func() {
+			value := []byte("value")
+			Expect(db.Write([]byte("key"), value)).ShouldNot(HaveOccurred())
+			value[0] = 'X'
+			stored, err := db.Read([]byte("key"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(stored).Should(Equal([]byte("value")))
+			stored[0] = 'Y'
+			stored, err = db.Read([]byte("key"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(stored).Should(Equal([]byte("value")))
+		})
+
+		It("deletes keys", func() {
+			Expect(db.Write([]byte("key"), []byte("value"))).ShouldNot(HaveOccurred())
+			Expect(db.Delete([]byte("key"))).ShouldNot(HaveOccurred())
+			_, err := db.Read([]byte("key"))
+			Expect(err).Should(Equal(store.ErrKeyNotFound))
+		})
+
+		It("does not return an error when deleting missing keys", func() {
+			Expect(db.Delete([]byte("missing"))).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("when using batches", func() {
+		It("does not apply the batch before it is committed", func() {
+			batch := db.NewBatch()
+			batch.Write([]byte("key"), []byte("value"))
+			_, err := db.Read([]byte("key"))
+			Expect(err).Should(Equal(store.ErrKeyNotFound))
+		})
+
+		It("applies every write and delete when the batch is committed", func() {
+			Expect(db.Write([]byte("deleted"), []byte("value"))).ShouldNot(HaveOccurred())
+
+			batch := db.NewBatch()
+			batch.Write([]byte("first"), []byte("1"))
+			batch.Write([]byte("second"), []byte("2"))
+			batch.Delete([]byte("deleted"))
+			Expect(batch.Commit()).ShouldNot(HaveOccurred())
+
+			value, err := db.Read([]byte("first"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal([]byte("1")))
+			value, err = db.Read([]byte("second"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal([]byte("2")))
+			_, err = db.Read([]byte("deleted"))
+			Expect(err).Should(Equal(store.ErrKeyNotFound))
+		})
+
+		It("applies the operations of a batch in order", func() {
+			batch := db.NewBatch()
+			batch.Write([]byte("key"), []byte("first"))
+			batch.Delete([]byte("key"))
+			batch.Write([]byte("key"), []byte("second"))
+			batch.Write([]byte("other"), []byte("value"))
+			batch.Delete([]byte("other"))
+			Expect(batch.Commit()).ShouldNot(HaveOccurred())
+
+			value, err := db.Read([]byte("key"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(value).Should(Equal([]byte("second")))
+			_, err = db.Read([]byte("other"))
+			Expect(err).Should(Equal(store.ErrKeyNotFound))
+		})
+	})
+
+	Context("when iterating", func() {
+		BeforeEach(func() {
+			Expect(db.Write([]byte("Status:b"), []byte("2"))).ShouldNot(HaveOccurred())
+			Expect(db.Write([]byte("Status:a"), []byte("1"))).ShouldNot(HaveOccurred())
+			Expect(db.Write([]byte("Status:c"), []byte("3"))).ShouldNot(HaveOccurred())
+			Expect(db.Write([]byte("Match:a"), []byte("4"))).ShouldNot(HaveOccurred())
+			Expect(db.Write([]byte("Statuses"), []byte("5"))).ShouldNot(HaveOccurred())
+		})
+
+		It("visits the keys with the prefix in ascending order", func() {
+			keys, values := []string{}, []string{}
+			Expect(db.Iterate([]byte("Status:"), func(key, value []byte) error {
+				keys = append(keys, string(key))
+				values = append(values, string(value))
+				return nil
+			})).ShouldNot(HaveOccurred())
+			Expect(keys).Should(Equal([]string{"Status:a", "Status:b", "Status:c"}))
+			Expect(values).Should(Equal([]string{"1", "2", "3"}))
+		})
+
+		It("visits every key when the prefix is empty", func() {
+			n := 0
+			Expect(db.Iterate([]byte{}, func(key, value []byte) error {
+				n++
+				return nil
+			})).ShouldNot(HaveOccurred())
+			Expect(n).Should(Equal(5))
+		})
+
+		It("stops and returns the error of the function", func() {
+			errStop := errors.New("stop")
+			n := 0
+			err := db.Iterate([]byte("Status:"), func(key, value []byte) error {
+				n++
+				return errStop
+			})
+			Expect(err).Should(Equal(errStop))
+			Expect(n).Should(Equal(1))
+		})
+	})
+}
@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	bindings "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/bindings/eth"
+	ethClient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/eth"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/services/wallet"
+)
+
+// bitcoinFee is the fee that is paid by the transactions that fund bitcoin
+// swaps.
+const bitcoinFee = 10000
+
+// initiateGasLimit is the gas limit of the transactions that fund ethereum
+// swaps.
+const initiateGasLimit = 3000000
+
+// decimals of the base unit of each currency
+var decimals = map[uint32]int64{
+	cc.BITCOINCC:  8,
+	cc.ETHEREUMCC: 18,
+}
+
+func (adapter *walletAdapter) OrderFunding(orderID [32]byte) (wallet.Funding, error) {
+	conn, err := ethClient.Connect(adapter.network)
+	if err != nil {
+		return wallet.Funding{}, err
+	}
+	settlement, err := bindings.NewRenExSettlement(conn.RenExSettlementAddress(), bind.ContractBackend(conn.Client()))
+	if err != nil {
+		return wallet.Funding{}, err
+	}
+	details, err := settlement.OrderDetails(&bind.CallOpts{}, orderID)
+	if err != nil {
+		return wallet.Funding{}, err
+	}
+	if details.Tokens == 0 {
+		return wallet.Funding{}, wallet.ErrOrderNotFound
+	}
+
+	// Prices are quoted in the priority token and volumes are in the other
+	// token. Buy orders spend the priority token, sell orders spend the other.
+	priorityToken, nonPriorityToken := uint32(details.Tokens), uint32(details.Tokens>>32)
+	volume := tupleToVolume(details.VolumeC, details.VolumeQ)
+	if details.Parity == 1 {
+		return orderFunding(nonPriorityToken, volume)
+	}
+	return orderFunding(priorityToken, volume.Mul(volume, tupleToPrice(details.PriceC, details.PriceQ)))
+}
+
+func (adapter *walletAdapter) EstimateFee(currency uint32) (*big.Int, error) {
+	switch currency {
+	case cc.BITCOINCC:
+		return big.NewInt(bitcoinFee), nil
+	case cc.ETHEREUMCC:
+		conn, err := ethClient.Connect(adapter.network)
+		if err != nil {
+			return nil, err
+		}
+		gasPrice, err := conn.Client().SuggestGasPrice(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return gasPrice.Mul(gasPrice, big.NewInt(initiateGasLimit)), nil
+	}
+	return nil, fmt.Errorf("unsupported currency %s", cc.Name(currency))
+}
+
+// orderFunding converts an amount of whole tokens into the base unit of the
+// currency, rounding up.
+func orderFunding(currency uint32, amount *big.Rat) (wallet.Funding, error) {
+	d, ok := decimals[currency]
+	if !ok {
+		return wallet.Funding{}, fmt.Errorf("unsupported currency %s", cc.Name(currency))
+	}
+	amount.Mul(amount, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(d), nil)))
+	base, rem := new(big.Int).QuoRem(amount.Num(), amount.Denom(), new(big.Int))
+	if rem.Sign() > 0 {
+		base.Add(base, big.NewInt(1))
+	}
+	return wallet.Funding{
+		Currency: currency,
+		Amount:   base,
+	}, nil
+}
+
+// tupleToPrice decodes a RenEx price, 0.005 * c * 10^(q - 26).
+func tupleToPrice(c, q uint64) *big.Rat {
+	return tupleToRat(5*c, int64(q)-26-3)
+}
+
+// tupleToVolume decodes a RenEx volume, 0.2 * c * 10^(q - 12).
+func tupleToVolume(c, q uint64) *big.Rat {
+	return tupleToRat(2*c, int64(q)-12-1)
+}
+
+func tupleToRat(c uint64, exp int64) *big.Rat {
+	value := new(big.Rat).SetInt(new(big.Int).SetUint64(c))
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(abs(exp)), nil))
+	if exp < 0 {
+		return value.Quo(value, pow)
+	}
+	return value.Mul(value, pow)
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	bindings "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/bindings/eth"
+	btcClient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/btc"
+	ethClient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/eth"
+	"github.com/republicprotocol/renex-swapper-go/adapters/configs/keystore"
+	"github.com/republicprotocol/renex-swapper-go/adapters/configs/network"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/services/wallet"
+)
+
+type walletAdapter struct {
+	network network.Config
+	keystr  keystore.Keystore
+}
+
+// NewWalletAdapter returns a wallet.Adapter that sends funds from the
+// addresses of the keys in the keystore.
+func NewWalletAdapter(network network.Config, keystr keystore.Keystore) wallet.Adapter {
+	return &walletAdapter{
+		network: network,
+		keystr:  keystr,
+	}
+}
+
+func (adapter *walletAdapter) ChainBalance(currency uint32) (wallet.Balance, error) {
+	key, err := adapter.keystr.GetKey(currency, 0)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	addr, err := key.GetAddress()
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+
+	switch currency {
+	case cc.BITCOINCC:
+		return bitcoinBalance(adapter.network, string(addr))
+	case cc.ETHEREUMCC:
+		return ethereumBalance(adapter.network, common.BytesToAddress(addr))
+	}
+	return wallet.Balance{}, fmt.Errorf("unsupported currency %s", cc.Name(currency))
+}
+
+func (adapter *walletAdapter) FeeBalance(trader string) (*big.Int, error) {
+	conn, err := ethClient.Connect(adapter.network)
+	if err != nil {
+		return nil, err
+	}
+	settlement, err := bindings.NewRenExSettlement(conn.RenExSettlementAddress(), bind.ContractBackend(conn.Client()))
+	if err != nil {
+		return nil, err
+	}
+	balancesAddr, err := settlement.RenExBalancesContract(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	renExBalances, err := bindings.NewRenExBalances(balancesAddr, bind.ContractBackend(conn.Client()))
+	if err != nil {
+		return nil, err
+	}
+	ether, err := renExBalances.ETHEREUM(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	tokens, balances, err := renExBalances.GetBalances(&bind.CallOpts{}, common.HexToAddress(trader))
+	if err != nil {
+		return nil, err
+	}
+	for i, token := range tokens {
+		if token == ether && i < len(balances) {
+			return balances[i], nil
+		}
+	}
+	return big.NewInt(0), nil
+}
+
+func (adapter *walletAdapter) Transfer(currency uint32, to string, amount *big.Int) (string, error) {
+	key, err := adapter.keystr.GetKey(currency, 0)
+	if err != nil {
+		return "", err
+	}
+
+	switch currency {
+	case cc.BITCOINCC:
+		if !amount.IsInt64() {
+			return "", fmt.Errorf("amount is too large: %v", amount)
+		}
+		addr, err := key.GetAddress()
+		if err != nil {
+			return "", err
+		}
+		conn, err := btcClient.Connect(adapter.network)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Shutdown()
+		return conn.Transfer(string(addr), to, amount.Int64())
+	case cc.ETHEREUMCC:
+		if !common.IsHexAddress(to) {
+			return "", fmt.Errorf("invalid ethereum address: %s", to)
+		}
+		privKey, err := key.GetKey()
+		if err != nil {
+			return "", err
+		}
+		conn, err := ethClient.Connect(adapter.network)
+		if err != nil {
+			return "", err
+		}
+		return conn.SendEther(common.HexToAddress(to), bind.NewKeyedTransactor(privKey), amount)
+	}
+	return "", fmt.Errorf("unsupported currency %s", cc.Name(currency))
+}
+
+// bitcoinBalance returns the balance of the address, counting unspent outputs
+// with no confirmations as unconfirmed.
+func bitcoinBalance(conf network.Config, address string) (wallet.Balance, error) {
+	conn, err := btcClient.Connect(conf)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	defer conn.Shutdown()
+
+	btcAddr, err := btcutil.DecodeAddress(address, conn.ChainParams)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	confirmed, err := sumUnspent(conn, btcAddr, 1, 9999999)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	unconfirmed, err := sumUnspent(conn, btcAddr, 0, 0)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	return wallet.Balance{
+		Address:     address,
+		Confirmed:   confirmed,
+		Unconfirmed: unconfirmed,
+	}, nil
+}
+
+func sumUnspent(conn btcClient.Conn, address btcutil.Address, minConf, maxConf int) (*big.Int, error) {
+	utxos, err := conn.Client.ListUnspentMinMaxAddresses(minConf, maxConf, []btcutil.Address{address})
+	if err != nil {
+		return nil, err
+	}
+	sum := big.NewInt(0)
+	for _, utxo := range utxos {
+		amount, err := btcutil.NewAmount(utxo.Amount)
+		if err != nil {
+			return nil, err
+		}
+		sum.Add(sum, big.NewInt(int64(amount)))
+	}
+	return sum, nil
+}
+
+// ethereumBalance returns the balance of the address in the latest block, and
+// the change to it in the pending block.
+func ethereumBalance(conf network.Config, address common.Address) (wallet.Balance, error) {
+	conn, err := ethClient.Connect(conf)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	confirmed, err := conn.Client().BalanceAt(context.Background(), address, nil)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	pending, err := conn.Client().PendingBalanceAt(context.Background(), address)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	return wallet.Balance{
+		Address:     address.String(),
+		Confirmed:   confirmed,
+		Unconfirmed: new(big.Int).Sub(pending, confirmed),
+	}, nil
+}
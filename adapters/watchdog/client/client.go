@@ -1,57 +1,131 @@
 package client
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/general"
+	"github.com/republicprotocol/renex-swapper-go/adapters/configs/keystore"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
 	"github.com/republicprotocol/renex-swapper-go/services/watchdog"
 )
 
 type watchdogHTTPClient struct {
-	ipAddress string
+	url        string
+	key        keystore.Key
+	httpClient *http.Client
+	pingClient *http.Client
 }
 
-// NewWatchdogHTTPClient creates a new WatchdogClient interface, that interacts
-// with Watchdog over http.
-func NewWatchdogHTTPClient(config config.Config) watchdog.WatchdogClient {
-	return &watchdogHTTPClient{
-		ipAddress: config.WatchdogURL(),
-	}
+// complaint is a complaint as it is sent to the watchdog.
+type complaint struct {
+	Type            string   `json:"type"`
+	PersonalOrderID string   `json:"personalOrderId"`
+	ForeignOrderID  string   `json:"foreignOrderId"`
+	Evidence        evidence `json:"evidence"`
+	Time            int64    `json:"time"`
 }
 
-func (client *watchdogHTTPClient) ComplainDelayedAddressSubmission(orderID [32]byte) error {
-	return client.watch(orderID)
+type evidence struct {
+	Reason                   string                  `json:"reason"`
+	Expected                 string                  `json:"expected,omitempty"`
+	Observed                 string                  `json:"observed,omitempty"`
+	Transactions             swapDomain.Transactions `json:"transactions"`
+	CounterpartyTransactions swapDomain.Transactions `json:"counterpartyTransactions"`
 }
 
-func (client *watchdogHTTPClient) ComplainDelayedRequestorInitiation(orderID [32]byte) error {
-	return client.watch(orderID)
+// signedComplaint is the body of a complaint request. The signature is an
+// Ethereum signed message of the complaint's JSON, by the swapper's Ethereum
+// key, so that the watchdog can check which trader complained.
+type signedComplaint struct {
+	Complaint json.RawMessage `json:"complaint"`
+	Address   string          `json:"address"`
+	Signature string          `json:"signature"`
 }
 
-func (client *watchdogHTTPClient) ComplainWrongRequestorInitiation(orderID [32]byte) error {
-	return client.watch(orderID)
+// NewWatchdogHTTPClient creates a new WatchdogClient interface, that interacts
+// with Watchdog over http. Complaints are signed with the Ethereum key.
+func NewWatchdogHTTPClient(config config.Config, key keystore.Key) watchdog.WatchdogClient {
+	return &watchdogHTTPClient{
+		url:        config.WatchdogURL(),
+		key:        key,
+		httpClient: &http.Client{Timeout: config.WatchdogTimeout()},
+		pingClient: &http.Client{Timeout: config.WatchdogPingTimeout()},
+	}
 }
 
-func (client *watchdogHTTPClient) ComplainDelayedResponderInitiation(orderID [32]byte) error {
-	return client.watch(orderID)
+// Complain posts the signed complaint to the watchdog, which acknowledges it
+// with a 2xx status code.
+func (client *watchdogHTTPClient) Complain(c watchdog.Complaint) error {
+	body, err := client.sign(c)
+	if err != nil {
+		return err
+	}
+	resp, err := client.httpClient.Post(client.url+"/complaints", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
 }
 
-func (client *watchdogHTTPClient) ComplainWrongResponderInitiation(orderID [32]byte) error {
-	return client.watch(orderID)
+// Ping returns an error if the watchdog cannot be reached, or responds with a
+// server error.
+func (client *watchdogHTTPClient) Ping() error {
+	resp, err := client.pingClient.Get(client.url + "/")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("Unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
 }
 
-func (client *watchdogHTTPClient) ComplainDelayedRequestorRedemption(orderID [32]byte) error {
-	return client.watch(orderID)
-}
+func (client *watchdogHTTPClient) sign(c watchdog.Complaint) ([]byte, error) {
+	message, err := json.Marshal(complaint{
+		Type:            c.Type,
+		PersonalOrderID: "0x" + hex.EncodeToString(c.PersonalOrderID[:]),
+		ForeignOrderID:  "0x" + hex.EncodeToString(c.ForeignOrderID[:]),
+		Evidence: evidence{
+			Reason:                   c.Evidence.Reason,
+			Expected:                 c.Evidence.Expected,
+			Observed:                 c.Evidence.Observed,
+			Transactions:             c.Evidence.Transactions,
+			CounterpartyTransactions: c.Evidence.CounterpartyTransactions,
+		},
+		Time: c.Time,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-func (client *watchdogHTTPClient) watch(orderID [32]byte) error {
-	resp, err := http.Post(fmt.Sprintf("https://"+client.ipAddress+"/watch?orderID="+hex.EncodeToString(orderID[:])), "text", nil)
+	privKey, err := client.key.GetKey()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	address, err := client.key.GetAddress()
+	if err != nil {
+		return nil, err
 	}
-	if resp.StatusCode == 200 {
-		return nil
+	signatureData := ethCrypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), message)
+	signature, err := ethCrypto.Sign(signatureData, privKey)
+	if err != nil {
+		return nil, err
 	}
-	return fmt.Errorf("Unexpected status code: %d", resp.StatusCode)
+
+	return json.Marshal(signedComplaint{
+		Complaint: message,
+		Address:   common.BytesToAddress(address).Hex(),
+		Signature: "0x" + hex.EncodeToString(signature),
+	})
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	netHttp "net/http"
+	"os"
+	"strings"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/republicprotocol/renex-swapper-go/drivers/httpclient"
+	"github.com/republicprotocol/renex-swapper-go/services/admin"
+)
+
+func main() {
+	url := flag.String("url", "http://127.0.0.1:18516", "Address of the swapper's HTTP API")
+	socket := flag.String("socket", "", "Connect to the swapper over this unix socket instead")
+	fingerprint := flag.String("fingerprint", "", "SHA-256 fingerprint of the swapper's self-signed TLS certificate")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <action> <orderId>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Actions: show, %s, %s, %s, %s, %s\n\n", admin.ActionRetry, admin.ActionRefund, admin.ActionResolve, admin.ActionResubmit, admin.ActionAbandon)
+		fmt.Fprintln(os.Stderr, "The session token is read from SWAPPER_TOKEN, otherwise the swapper is")
+		fmt.Fprintln(os.Stderr, "logged in to with the hex encoded private key in SWAPPER_ADMIN_KEY.")
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	action, orderID := flag.Arg(0), flag.Arg(1)
+
+	baseURL := *url
+	if *socket != "" {
+		baseURL = "http://swapper"
+	}
+	client := httpclient.NewClient(baseURL, buildClient(*socket, *fingerprint), os.Getenv("SWAPPER_TOKEN"))
+	if os.Getenv("SWAPPER_TOKEN") == "" {
+		if err := login(client, os.Getenv("SWAPPER_ADMIN_KEY")); err != nil {
+			log.Fatal("cannot log in: ", err)
+		}
+	}
+
+	if action == "show" {
+		details, err := client.Swap(orderID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	if err := client.SwapAction(orderID, action); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %s\n", action, orderID)
+}
+
+// buildClient returns an HTTP client that connects over the unix socket if
+// one is given, and that pins the certificate to the fingerprint if one is
+// given.
+func buildClient(socket, fingerprint string) *netHttp.Client {
+	transport := &netHttp.Transport{}
+	if socket != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		}
+	}
+	if fingerprint != "" {
+		transport.TLSClientConfig = &tls.Config{
+			// The certificate is self-signed, so it is verified against the
+			// fingerprint instead of a certificate authority
+			InsecureSkipVerify: true,
+			VerifyPeerCertificate: func(certs [][]byte, _ [][]*x509.Certificate) error {
+				if len(certs) == 0 {
+					return errors.New("no certificate was presented")
+				}
+				hash := sha256.Sum256(certs[0])
+				if !strings.EqualFold(hex.EncodeToString(hash[:]), fingerprint) {
+					return fmt.Errorf("certificate fingerprint %s does not match", hex.EncodeToString(hash[:]))
+				}
+				return nil
+			},
+		}
+	}
+	return &netHttp.Client{Transport: transport}
+}
+
+// login logs in with the hex encoded private key.
+func login(client httpclient.Client, key string) error {
+	if key == "" {
+		return errors.New("set SWAPPER_TOKEN or SWAPPER_ADMIN_KEY")
+	}
+	privKey, err := ethCrypto.HexToECDSA(strings.TrimPrefix(key, "0x"))
+	if err != nil {
+		return err
+	}
+	_, err = client.Login(privKey)
+	return err
+}
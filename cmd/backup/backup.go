@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/republicprotocol/renex-swapper-go/adapters/cli"
+	config "github.com/republicprotocol/renex-swapper-go/adapters/configs/general"
+	"github.com/republicprotocol/renex-swapper-go/adapters/configs/keystore"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+// keyInfo describes a key in the keystore without revealing the private key.
+type keyInfo struct {
+	PriorityCode uint32 `json:"priorityCode"`
+	Network      string `json:"network"`
+	Address      string `json:"address"`
+}
+
+func main() {
+	home := getHome()
+
+	confPath := flag.String("config", home+"/.swapper/config.json", "Location of the config file")
+	keystrPath := flag.String("keystore", home+"/.swapper/keystore.json", "Location of the keystore file")
+	exportPath := flag.String("export", "", "Export the swaps to an encrypted archive at this location")
+	importPath := flag.String("import", "", "Import the swaps from an encrypted archive at this location")
+
+	flag.Parse()
+
+	if (*exportPath == "") == (*importPath == "") {
+		log.Fatal("Exactly one of -export and -import must be given")
+	}
+
+	conf, err := config.LoadConfig(*confPath)
+	if err != nil {
+		panic(err)
+	}
+
+	keystr, err := keystore.Load(*keystrPath)
+	if err != nil {
+		panic(err)
+	}
+
+	keys, err := keystoreInfo(keystr)
+	if err != nil {
+		panic(err)
+	}
+
+	db, err := cli.BuildStore(conf)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	if *importPath != "" {
+		// New stores are marked with the current schema version before the
+		// cipher records are written, so that they are not mistaken for old
+		// stores. Old stores must be migrated by the swapper first.
+		version, err := store.LoadSchemaVersion(db)
+		if err != nil {
+			panic(err)
+		}
+		if version != store.SchemaVersion {
+			log.Fatal("Start the swapper once to migrate the store before importing")
+		}
+		if _, err := store.Migrate(db, store.MigrateOptions{}); err != nil {
+			panic(err)
+		}
+	}
+
+	passphrase, err := cli.ReadPassphrase("SWAPPER_PASSPHRASE", "Enter your swap store passphrase: ")
+	if err != nil {
+		panic(err)
+	}
+	cipher, err := store.LoadCipher(db, passphrase)
+	if err != nil {
+		panic(err)
+	}
+	archivePassphrase, err := cli.ReadPassphrase("SWAPPER_ARCHIVE_PASSPHRASE", "Enter the archive passphrase: ")
+	if err != nil {
+		panic(err)
+	}
+
+	if *exportPath != "" {
+		if err := exportArchive(db, cipher, *exportPath, archivePassphrase, keys); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := importArchive(db, cipher, *importPath, archivePassphrase, keys); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func exportArchive(db store.Store, cipher store.Cipher, path, passphrase string, keys []keyInfo) error {
+	keysBytes, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := store.ExportArchive(db, cipher, f, passphrase, keysBytes)
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	log.Println(fmt.Sprintf("Exported %d records to %s", info.Records, path))
+	return f.Sync()
+}
+
+func importArchive(db store.Store, cipher store.Cipher, path, passphrase string, keys []keyInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := store.ReadArchiveInfo(f, passphrase)
+	if err != nil {
+		return err
+	}
+	log.Println(fmt.Sprintf("Archive was created at %s with %d records", time.Unix(info.CreatedAt, 0).Format(time.RFC3339), info.Records))
+
+	archivedKeys := []keyInfo{}
+	if err := json.Unmarshal(info.Keystore, &archivedKeys); err != nil {
+		return err
+	}
+	for _, archived := range archivedKeys {
+		if !hasKey(keys, archived) {
+			log.Println(fmt.Sprintf("WARNING: the swaps were exported from a swapper using the %s address %s, which is not in this keystore. Refunds to that address will need the original keystore.", archived.Network, archived.Address))
+		}
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	info, err = store.ImportArchive(db, cipher, f, passphrase)
+	if err != nil {
+		return err
+	}
+	log.Println(fmt.Sprintf("Imported %d records from %s", info.Records, path))
+	return nil
+}
+
+func hasKey(keys []keyInfo, key keyInfo) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func keystoreInfo(keystr keystore.Keystore) ([]keyInfo, error) {
+	keys := []keyInfo{}
+	for _, code := range []uint32{0, 1} {
+		key, err := keystr.GetKey(code, 0)
+		if err != nil {
+			return nil, err
+		}
+		address, err := key.GetAddress()
+		if err != nil {
+			return nil, err
+		}
+		info := keyInfo{
+			PriorityCode: code,
+			Network:      key.Chain(),
+			Address:      string(address),
+		}
+		if code == 1 {
+			info.Address = "0x" + hex.EncodeToString(address)
+		}
+		keys = append(keys, info)
+	}
+	return keys, nil
+}
+
+func getHome() string {
+	winHome := os.Getenv("userprofile")
+	unixHome := os.Getenv("HOME")
+
+	if winHome != "" {
+		return winHome
+	}
+
+	if unixHome != "" {
+		return unixHome
+	}
+
+	panic("unknown Operating System")
+}
@@ -1,39 +1,122 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	netHttp "net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/republicprotocol/renex-swapper-go/services/logger"
 	"github.com/republicprotocol/renex-swapper-go/services/watchdog"
 
 	"github.com/btcsuite/btcutil"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/republicprotocol/renex-swapper-go/adapters/atoms"
 	"github.com/republicprotocol/renex-swapper-go/adapters/blockchain/binder"
 	btcClient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/btc"
 	ethClient "github.com/republicprotocol/renex-swapper-go/adapters/blockchain/clients/eth"
+	"github.com/republicprotocol/renex-swapper-go/adapters/cli"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/general"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/keystore"
 	"github.com/republicprotocol/renex-swapper-go/adapters/configs/network"
 	"github.com/republicprotocol/renex-swapper-go/adapters/http"
 	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
-	"github.com/republicprotocol/renex-swapper-go/adapters/store/leveldb"
+	walletAdapter "github.com/republicprotocol/renex-swapper-go/adapters/wallet"
 	"github.com/republicprotocol/renex-swapper-go/adapters/watchdog/client"
+	"github.com/republicprotocol/renex-swapper-go/services/admin"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
 	"github.com/republicprotocol/renex-swapper-go/services/guardian"
+	"github.com/republicprotocol/renex-swapper-go/services/health"
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
 	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/wallet"
 	"github.com/republicprotocol/renex-swapper-go/services/watch"
 )
 
+// ethereumNonces reports the pending transactions of the swapper's ethereum
+// address.
+type ethereumNonces struct {
+	conn    ethClient.Conn
+	address common.Address
+}
+
+func (nonces ethereumNonces) PendingNonces() (uint64, error) {
+	return nonces.conn.PendingNonces(nonces.address)
+}
+
+// healthAdapter reaches the nodes, watchdog and keys that the health checks
+// cover.
+type healthAdapter struct {
+	btcConn  btcClient.Conn
+	ethConn  ethClient.Conn
+	watchdog watchdog.WatchdogClient
+	keystr   keystore.Keystore
+}
+
+func (adapter healthAdapter) BitcoinInfo() (health.BitcoinInfo, error) {
+	info, err := adapter.btcConn.ChainInfo()
+	if err != nil {
+		return health.BitcoinInfo{}, err
+	}
+	return health.BitcoinInfo{
+		Blocks:               info.Blocks,
+		Headers:              info.Headers,
+		InitialBlockDownload: info.InitialBlockDownload,
+	}, nil
+}
+
+func (adapter healthAdapter) EthereumInfo() (health.EthereumInfo, error) {
+	status, err := adapter.ethConn.SyncStatus()
+	if err != nil {
+		return health.EthereumInfo{}, err
+	}
+	return health.EthereumInfo{
+		Syncing:         status.Syncing,
+		CurrentBlock:    status.CurrentBlock,
+		HighestBlock:    status.HighestBlock,
+		LatestBlock:     status.LatestBlock,
+		LatestBlockTime: status.LatestBlockTime,
+	}, nil
+}
+
+func (adapter healthAdapter) PingWatchdog() error {
+	return adapter.watchdog.Ping()
+}
+
+// UnlockKeys checks that the bitcoin and ethereum keys can be read from the
+// keystore.
+func (adapter healthAdapter) UnlockKeys() error {
+	for _, code := range []uint32{0, 1} {
+		key, err := adapter.keystr.GetKey(code, 0)
+		if err != nil {
+			return err
+		}
+		if _, err := key.GetKey(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type watchAdapter struct {
 	atoms.AtomBuilder
 	binder.Binder
-	watchdog.WatchdogClient
+	watchdog.Notifier
 	logger.Logger
+	events.Publisher
+	shutdown.Broadcaster
 }
 
 func main() {
@@ -43,6 +126,8 @@ func main() {
 	confPath := flag.String("config", home+"/.swapper/config.json", "Location of the config file")
 	keystrPath := flag.String("keystore", home+"/.swapper/keystore.json", "Location of the keystore file")
 	networkPath := flag.String("network", home+"/.swapper/network.json", "Location of the network file")
+	backupPath := flag.String("backups", home+"/.swapper/backups", "Directory the store is backed up to before it is migrated")
+	migrateDryRun := flag.Bool("migrate-dry-run", false, "Report the store migrations that would be applied and exit")
 
 	flag.Parse()
 
@@ -59,23 +144,58 @@ func main() {
 	log.Println("Swapper is syncing with the bitcoin node, this might take few minutes to complete")
 	net, err := network.LoadNetwork(*networkPath)
 
-	dbLoc, err := conf.StoreLocation()
+	db, err := cli.BuildStore(conf)
 	if err != nil {
 		panic(err)
 	}
 
-	db, err := leveldb.NewLDBStore(dbLoc)
+	if *migrateDryRun {
+		if err := migrateStore(db, nil, *backupPath, true); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	// The cipher is loaded before migrating, so that the secrets in the
+	// backup of an old store are encrypted.
+	passphrase, err := cli.ReadPassphrase("SWAPPER_PASSPHRASE", "Enter your swap store passphrase: ")
+	if err != nil {
+		panic(err)
+	}
+	cipher, err := store.LoadCipher(db, passphrase)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := migrateStore(db, cipher, *backupPath, false); err != nil {
+		panic(err)
+	}
+
+	n, err := store.EncryptRecords(db, cipher)
+	if err != nil {
+		panic(err)
+	}
+	if n > 0 {
+		log.Println(fmt.Sprintf("Encrypted %d records that were stored in plain text", n))
+	}
+	state := store.NewState(db, cipher, loggerAdapter.NewStdOutLogger())
+	go pruneArchive(state, conf.ArchiveRetention())
+
+	broker := events.NewBroker(events.DefaultHistory)
+	sd := shutdown.NewShutdown()
+
+	watchdogClient, err := buildWatchdogClient(conf, keystr)
 	if err != nil {
 		panic(err)
 	}
-	state := store.NewState(db, loggerAdapter.NewStdOutLogger())
+	outbox := watchdog.NewOutbox(watchdogClient, state)
 
-	watcher, err := buildWatcher(conf, net, keystr, state)
+	watcher, err := buildWatcher(conf, net, keystr, state, broker, sd, outbox)
 	if err != nil {
 		panic(err)
 	}
 
-	guardian, err := buildGuardian(net, keystr, state)
+	guardian, err := buildGuardian(conf, net, keystr, state, broker, sd)
 	if err != nil {
 		panic(err)
 	}
@@ -86,6 +206,8 @@ func main() {
 	errCh2 := guardian.Start()
 	guardian.Notify()
 
+	errCh3 := outbox.Start()
+
 	go func() {
 		for err := range errCh1 {
 			log.Println("Watcher Error :", err)
@@ -98,33 +220,350 @@ func main() {
 		}
 	}()
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
 	go func() {
-		_ = <-c
-		log.Println("Stopping the swapper service")
-		watcher.Stop()
-		log.Println("Stopping the guardian service")
-		guardian.Stop()
-		log.Println("Stopping the atom box safely")
-		os.Exit(1)
+		for err := range errCh3 {
+			log.Println("Watchdog Outbox Error :", err)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	tlsConfig, fingerprint, err := buildTLS(conf)
+	if err != nil {
+		panic(err)
+	}
+
+	admin := admin.NewAdmin(state, watcher, guardian, broker)
+	wallet := wallet.NewWallet(walletAdapter.NewWalletAdapter(net, keystr), state)
+	if err := serveMetrics(conf, net, keystr, state, wallet); err != nil {
+		panic(err)
+	}
+
+	checker, err := buildChecker(conf, net, keystr, db, watchdogClient, watcher, guardian)
+	if err != nil {
+		panic(err)
+	}
+
+	httpAdapter := http.NewBoxHttpAdapter(conf, net, keystr, watcher, state, broker, admin, wallet, checker, fingerprint)
+	servers, serveErrs, err := serve(conf, *port, tlsConfig, http.NewServer(httpAdapter))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	select {
+	case err := <-serveErrs:
+		log.Fatal(err)
+	case sig := <-signals:
+		log.Println(fmt.Sprintf("Received %v, stopping the swapper", sig))
+	}
+	stop(conf.ShutdownTimeout(), servers, broker, sd, watcher, guardian, outbox, db)
+	log.Println("Stopped the swapper")
+}
+
+// stop shuts the swapper down without losing the progress of its swaps. It
+// refuses to broadcast new transactions, stops serving the HTTP API and
+// closes the event streams, and then waits for the swaps and refunds that are
+// running to stop at a persisted checkpoint and for the complaints that are
+// being sent, all before the timeout. Transactions that are being broadcast
+// are waited for however long they take, so that they are persisted. The
+// store is only closed once nothing is using it. Swaps that did not stop in
+// time are resumed from their last checkpoint when the swapper is restarted,
+// and complaints that were not acknowledged are sent again.
+func stop(timeout time.Duration, servers []*netHttp.Server, broker events.Broker, sd shutdown.Shutdown, watcher watch.Watch, guardian guardian.Guardian, outbox watchdog.Outbox, db store.Store) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	sd.Begin()
+	log.Println("Stopping the HTTP API")
+	for _, server := range servers {
+		server.RegisterOnShutdown(broker.Close)
+		if err := server.Shutdown(ctx); err != nil {
+			log.Println("Failed to stop the HTTP API:", err)
+		}
+	}
+
+	log.Println("Stopping the watcher and the guardian")
+	stopped := true
+	drained := make(chan bool, 2)
+	go func() {
+		drained <- watcher.Drain(time.Until(deadline))
 	}()
+	go func() {
+		drained <- guardian.Drain(time.Until(deadline))
+	}()
+	for i := 0; i < 2; i++ {
+		if !<-drained {
+			stopped = false
+			log.Println("Some swaps did not stop in time, they will be resumed from their last checkpoint")
+		}
+	}
+
+	log.Println("Waiting for the transactions that are being broadcast")
+	sd.Wait()
 
-	httpAdapter := http.NewBoxHttpAdapter(conf, net, keystr, watcher)
-	log.Println(fmt.Sprintf("0.0.0.0:%s", *port))
-	log.Fatal(netHttp.ListenAndServe(fmt.Sprintf(":%s", *port), http.NewServer(httpAdapter)))
+	log.Println("Stopping the watchdog outbox")
+	if !outbox.Drain(time.Until(deadline)) {
+		stopped = false
+		log.Println("Some complaints were not acknowledged in time, they will be sent again")
+	}
 
+	if !stopped {
+		log.Println("Leaving the store open for the swaps that are still running")
+		return
+	}
+	log.Println("Closing the store")
+	if err := db.Close(); err != nil {
+		log.Println("Failed to close the store:", err)
+	}
 }
 
-func buildGuardian(net network.Config, keystore keystore.Keystore, state store.State) (guardian.Guardian, error) {
+// serveMetrics serves the Prometheus metrics in the background, unless they
+// are disabled.
+func serveMetrics(conf config.Config, net network.Config, keystr keystore.Keystore, state store.State, wallet wallet.Wallet) error {
+	addr := conf.MetricsAddress()
+	if addr == "" {
+		return nil
+	}
+
+	ethConn, err := ethClient.Connect(net)
+	if err != nil {
+		return err
+	}
+	ethKey, err := keystr.GetKey(1, 0)
+	if err != nil {
+		return err
+	}
+	ethAddr, err := ethKey.GetAddress()
+	if err != nil {
+		return err
+	}
+	prometheus.MustRegister(metrics.NewCollector(state, wallet, ethereumNonces{ethConn, common.BytesToAddress(ethAddr)}))
+
+	mux := netHttp.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &netHttp.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	go func() {
+		log.Println(fmt.Sprintf("Serving metrics on http://%s/metrics", addr))
+		if err := server.ListenAndServe(); err != nil {
+			log.Println("Failed to serve the metrics:", err)
+		}
+	}()
+	return nil
+}
+
+func buildChecker(conf config.Config, net network.Config, keystr keystore.Keystore, db store.Store, watchdogClient watchdog.WatchdogClient, watcher watch.Watch, guardian guardian.Guardian) (health.Checker, error) {
+	btcConn, err := btcClient.Connect(net)
+	if err != nil {
+		return nil, err
+	}
+	ethConn, err := ethClient.Connect(net)
+	if err != nil {
+		return nil, err
+	}
+	adapter := healthAdapter{
+		btcConn:  btcConn,
+		ethConn:  ethConn,
+		watchdog: watchdogClient,
+		keystr:   keystr,
+	}
+	return health.NewChecker(adapter, db, watcher, guardian, conf.MaxBlockAge()), nil
+}
+
+// buildWatchdogClient returns a client that signs complaints with the
+// swapper's Ethereum key.
+func buildWatchdogClient(conf config.Config, keystr keystore.Keystore) (watchdog.WatchdogClient, error) {
+	ethKey, err := keystr.GetKey(1, 0)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewWatchdogHTTPClient(conf, ethKey), nil
+}
+
+// buildTLS loads the certificate that the HTTP API is served with, generating
+// a self-signed one if none is configured. It returns a nil config if TLS is
+// not enabled.
+func buildTLS(conf config.Config) (*tls.Config, string, error) {
+	if !conf.HTTP.TLS.Enabled {
+		return nil, "", nil
+	}
+
+	certFile, keyFile, err := conf.TLSFiles()
+	if err != nil {
+		return nil, "", err
+	}
+	cert, err := http.LoadCertificate(certFile, keyFile, conf.SelfSigned())
+	if err != nil {
+		return nil, "", err
+	}
+
+	fingerprint := http.Fingerprint(cert)
+	if conf.SelfSigned() {
+		log.Println(fmt.Sprintf("Using the self-signed certificate at %s with the SHA-256 fingerprint %s", certFile, fingerprint))
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, fingerprint, nil
+}
+
+// readHeaderTimeout is how long the HTTP servers wait for the headers of a
+// request, so that slow clients cannot hold connections open.
+const readHeaderTimeout = 10 * time.Second
+
+// serve serves the HTTP API on the listen address, and on the unix socket if
+// one is configured, in the background. It returns the servers, and a channel
+// that receives the error that stops any of them. It refuses to serve the API
+// on an address that is not a loopback address without TLS.
+func serve(conf config.Config, port string, tlsConfig *tls.Config, handler netHttp.Handler) ([]*netHttp.Server, <-chan error, error) {
+	addr := conf.ListenAddress(port)
+	if tlsConfig == nil && !isLoopback(addr) {
+		return nil, nil, fmt.Errorf("refusing to serve the HTTP API on %s without TLS", addr)
+	}
+
+	errs := make(chan error, 2)
+	servers := []*netHttp.Server{}
+	if conf.HTTP.UnixSocket != "" {
+		listener, err := listenUnix(conf.HTTP.UnixSocket)
+		if err != nil {
+			return nil, nil, err
+		}
+		server := &netHttp.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+		servers = append(servers, server)
+		go func() {
+			log.Println(fmt.Sprintf("Listening on unix://%s", conf.HTTP.UnixSocket))
+			errs <- server.Serve(listener)
+		}()
+	}
+
+	server := &netHttp.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	servers = append(servers, server)
+	go func() {
+		if tlsConfig == nil {
+			log.Println(fmt.Sprintf("Listening on http://%s", addr))
+			errs <- server.ListenAndServe()
+			return
+		}
+		log.Println(fmt.Sprintf("Listening on https://%s", addr))
+		errs <- server.ListenAndServeTLS("", "")
+	}()
+	return servers, errs, nil
+}
+
+// listenUnix listens on a unix socket that only the user running the swapper
+// can connect to. The socket is created with a restrictive umask, so that it
+// is never accessible to other users, even before it is chmodded.
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	umask := syscall.Umask(0077)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(umask)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// migrateStore upgrades the store to the current schema version, backing up
+// its records first if any migrations are pending. Secrets are encrypted in
+// the backup using the cipher.
+func migrateStore(db store.Store, cipher store.Cipher, backupPath string, dryRun bool) error {
+	version, err := store.LoadSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	options := store.MigrateOptions{
+		DryRun: dryRun,
+		Cipher: cipher,
+	}
+	if version < store.SchemaVersion && !dryRun {
+		if err := os.MkdirAll(backupPath, 0700); err != nil {
+			return err
+		}
+		backupFile := filepath.Join(backupPath, fmt.Sprintf("store-v%d-%d.json", version, time.Now().Unix()))
+		f, err := os.OpenFile(backupFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		log.Println(fmt.Sprintf("Backing up the store to %s", backupFile))
+		options.Backup = f
+	}
+
+	report, err := store.Migrate(db, options)
+	if err != nil {
+		return err
+	}
+	for _, migration := range report.Applied {
+		log.Println("Migrating the store:", migration)
+	}
+	if dryRun {
+		log.Println(fmt.Sprintf("Store is at schema version %d, migrating would bring it to version %d and update %d records", report.From, report.To, report.Records))
+		return nil
+	}
+	if len(report.Applied) > 0 {
+		log.Println(fmt.Sprintf("Migrated the store from version %d to %d, updating %d records", report.From, report.To, report.Records))
+	}
+	return nil
+}
+
+// pruneArchive periodically deletes the details of the swaps that finished
+// before the retention period.
+func pruneArchive(state store.State, retention time.Duration) {
+	if retention == 0 {
+		return
+	}
+	for {
+		n, err := state.PruneArchive(time.Now().Add(-retention).Unix())
+		if err != nil {
+			log.Println("Failed to prune the swap archive:", err)
+		} else if n > 0 {
+			log.Println(fmt.Sprintf("Pruned the details of %d archived swaps", n))
+		}
+		time.Sleep(24 * time.Hour)
+	}
+}
+
+func buildGuardian(conf config.Config, net network.Config, keystore keystore.Keystore, state store.State, publisher events.Publisher, broadcaster shutdown.Broadcaster) (guardian.Guardian, error) {
 	atomBuilder, err := atoms.NewAtomBuilder(net, keystore)
 	if err != nil {
 		return nil, err
 	}
-	return guardian.NewGuardian(atomBuilder, state), nil
+	return guardian.NewGuardian(atomBuilder, state, publisher, broadcaster, conf.Concurrency()), nil
 }
 
-func buildWatcher(gen config.Config, net network.Config, keystore keystore.Keystore, state store.State) (watch.Watch, error) {
+func buildWatcher(gen config.Config, net network.Config, keystore keystore.Keystore, state store.State, publisher events.Publisher, broadcaster shutdown.Broadcaster, outbox watchdog.Notifier) (watch.Watch, error) {
 	ethConn, err := ethClient.Connect(net)
 	if err != nil {
 		return nil, err
@@ -170,17 +609,17 @@ func buildWatcher(gen config.Config, net network.Config, keystore keystore.Keyst
 
 	ethBinder, err := binder.NewBinder(privKey, ethConn)
 
-	watchdog := client.NewWatchdogHTTPClient(gen)
-
 	atomBuilder, err := atoms.NewAtomBuilder(net, keystore)
 	wAdapter := watchAdapter{
 		atomBuilder,
 		ethBinder,
-		watchdog,
+		outbox,
 		loggerAdapter.NewStdOutLogger(),
+		publisher,
+		broadcaster,
 	}
 
-	watcher := watch.NewWatch(&wAdapter, state)
+	watcher := watch.NewWatch(&wAdapter, state, gen.Concurrency())
 	return watcher, nil
 }
 
@@ -1,6 +1,31 @@
 package cc
 
+import "fmt"
+
 const (
 	BITCOINCC  uint32 = 0
 	ETHEREUMCC uint32 = 1
 )
+
+var names = map[uint32]string{
+	BITCOINCC:  "BTC",
+	ETHEREUMCC: "ETH",
+}
+
+// Name returns the ticker of the currency with the priority code.
+func Name(code uint32) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", code)
+}
+
+// Code returns the priority code of the currency with the ticker.
+func Code(name string) (uint32, error) {
+	for code, n := range names {
+		if n == name {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown currency: %s", name)
+}
@@ -2,3 +2,31 @@ package swap
 
 // ID is the swap ID
 type ID [32]byte
+
+// Transactions are the hashes of the transactions submitted for one side of a
+// swap, and a reference to the contract that locks its funds. A hash is empty
+// if the transaction has not been submitted.
+type Transactions struct {
+	Contract string `json:"contract,omitempty"`
+	Initiate string `json:"initiate,omitempty"`
+	Redeem   string `json:"redeem,omitempty"`
+	Refund   string `json:"refund,omitempty"`
+}
+
+// Merge returns the Transactions with the hashes that are empty filled in from
+// the other Transactions.
+func (txs Transactions) Merge(other Transactions) Transactions {
+	if txs.Contract == "" {
+		txs.Contract = other.Contract
+	}
+	if txs.Initiate == "" {
+		txs.Initiate = other.Initiate
+	}
+	if txs.Redeem == "" {
+		txs.Redeem = other.Redeem
+	}
+	if txs.Refund == "" {
+		txs.Refund = other.Refund
+	}
+	return txs
+}
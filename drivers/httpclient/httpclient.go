@@ -1,174 +1,208 @@
-package main
+// Package httpclient is a client of the swapper's HTTP API.
+package httpclient
 
 import (
-	"crypto/rand"
+	"bytes"
+	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"io/ioutil"
+	netHttp "net/http"
+	"net/url"
+	"strings"
 
-	"github.com/btcsuite/btcutil"
-
-	"github.com/ethereum/go-ethereum/common"
 	ethCrypto "github.com/ethereum/go-ethereum/crypto"
-
-	"math/big"
-
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/crypto"
-	btcclient "github.com/republicprotocol/renex-swapper-go/adapters/clients/btc"
-	ethclient "github.com/republicprotocol/renex-swapper-go/adapters/clients/eth"
-	"github.com/republicprotocol/renex-swapper-go/adapters/config"
-	"github.com/republicprotocol/renex-swapper-go/adapters/keystore"
-	"github.com/republicprotocol/renex-swapper-go/adapters/owner"
-	wal "github.com/republicprotocol/renex-swapper-go/adapters/wallet/eth"
-	"github.com/republicprotocol/renex-swapper-go/domains/match"
-	"github.com/republicprotocol/renex-swapper-go/services/swap"
+	"github.com/republicprotocol/renex-swapper-go/adapters/http"
 )
 
-func main() {
-
-	var aliceOrderID, bobOrderID [32]byte
-	
-	var conf = os.Getenv("HOME") + "/go/src/github.com/republicprotocol/renex-swapper-go/secrets/test/configA.json"
-	var keyA = os.Getenv("HOME") + "/go/src/github.com/republicprotocol/renex-swapper-go/secrets/test/keystoreA.json"
-	var keyB = os.Getenv("HOME") + "/go/src/github.com/republicprotocol/renex-swapper-go/secrets/test/keystoreB.json"
-	var ownPath = os.Getenv("HOME") + "/go/src/github.com/republicprotocol/renex-swapper-go/secrets/owner.json"
+// Client calls the version of the API that it was built with. Errors returned
+// by the swapper are returned as an http.APIError.
+type Client interface {
+	// Login signs a challenge from the swapper with the private key, and uses
+	// the session for the requests that follow.
+	Login(privKey *ecdsa.PrivateKey) (http.Session, error)
+	Logout() error
+
+	WhoAmI(challenge string) (http.WhoAmI, error)
+	PostOrder(order http.PostOrder) (http.PostOrder, error)
+	CancelOrder(orderID, signature string) error
+	Status(orderID string) (http.Status, error)
+	Balances() (http.Balances, error)
+
+	// Swaps lists the swaps that match the query, which uses the parameters
+	// of GET /swaps.
+	Swaps(query url.Values) (http.Swaps, error)
+	Swap(orderID string) (http.SwapDetails, error)
+	SwapAction(orderID, action string) error
+
+	Withdraw(withdrawal http.PostWithdrawal) (http.Withdrawal, error)
+	Withdrawals() ([]http.Withdrawal, error)
+}
 
-	ksA := keystore.NewKeystore(keyA)
-	ksB := keystore.NewKeystore(keyB)
+type client struct {
+	url   string
+	http  *netHttp.Client
+	token string
+}
 
-	own, err := owner.LoadOwner(ownPath)
-	if err != nil {
-		panic(err)
+// NewClient returns a Client of the swapper at the URL, which does not
+// include the /v1 prefix. The token is the session to use, and can be empty
+// if Login is called.
+func NewClient(url string, httpClient *netHttp.Client, token string) Client {
+	return &client{
+		url:   strings.TrimSuffix(url, "/") + "/v1",
+		http:  httpClient,
+		token: token,
 	}
+}
 
-	ownerECDSA, err := crypto.HexToECDSA(own.Kovan)
+// Sign signs the message, followed by the bytes, as an Ethereum signed
+// message and returns the hex encoded signature. Messages are the prefixes
+// that the API documents, such as "Republic Protocol: open: ".
+func Sign(privKey *ecdsa.PrivateKey, message string, data []byte) (string, error) {
+	msg := append([]byte(message), data...)
+	hash := ethCrypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(msg))), msg)
+	signature, err := ethCrypto.Sign(hash, privKey)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	owner := bind.NewKeyedTransactor(ownerECDSA)
+	return hex.EncodeToString(signature), nil
+}
 
-	config, err := config.LoadConfig(conf)
+func (client *client) Login(privKey *ecdsa.PrivateKey) (http.Session, error) {
+	challenge := http.Challenge{}
+	if err := client.do("GET", "/login", nil, &challenge); err != nil {
+		return http.Session{}, err
+	}
+	challengeBytes, err := hex.DecodeString(challenge.Challenge)
 	if err != nil {
-		panic(err)
+		return http.Session{}, err
 	}
-
-	ethConn, err := ethclient.Connect(config)
+	signature, err := Sign(privKey, "Republic Protocol: login: ", challengeBytes)
 	if err != nil {
-		panic(err)
+		return http.Session{}, err
 	}
 
-	btcConn, err := btcclient.Connect(config)
-	if err != nil {
-		panic(err)
+	session := http.Session{}
+	if err := client.do("POST", "/login", http.Login{
+		Challenge: challenge.Challenge,
+		Signature: signature,
+	}, &session); err != nil {
+		return http.Session{}, err
 	}
+	client.token = session.Token
+	return session, nil
+}
 
-	err = deposit(ksA, ksB, owner, ethConn, btcConn)
-	if err != nil {
-		panic(err)
+func (client *client) Logout() error {
+	if err := client.do("POST", "/logout", nil, nil); err != nil {
+		return err
 	}
+	client.token = ""
+	return nil
+}
 
-	rand.Read(aliceOrderID[:])
-	rand.Read(bobOrderID[:])
+func (client *client) WhoAmI(challenge string) (http.WhoAmI, error) {
+	whoami := http.WhoAmI{}
+	err := client.do("GET", "/whoami/"+url.PathEscape(challenge), nil, &whoami)
+	return whoami, err
+}
 
-	aliceSendValue := big.NewInt(10000000)
-	bobSendValue := big.NewInt(10000000)
+func (client *client) PostOrder(order http.PostOrder) (http.PostOrder, error) {
+	signed := http.PostOrder{}
+	err := client.do("POST", "/orders", order, &signed)
+	return signed, err
+}
 
-	aliceCurrency := uint32(1)
-	bobCurrency := uint32(0)
+func (client *client) CancelOrder(orderID, signature string) error {
+	return client.do("DELETE", "/orders/"+orderID+"?signature="+url.QueryEscape(signature), nil, nil)
+}
 
-	atomMatch := match.NewMatch(aliceOrderID, bobOrderID, aliceSendValue, bobSendValue, aliceCurrency, bobCurrency)
-	mockWallet, err := wal.NewEthereumWallet(ethConn, *owner)
-	if err != nil {
-		panic(err)
-	}
+func (client *client) Status(orderID string) (http.Status, error) {
+	status := http.Status{}
+	err := client.do("GET", "/status/"+orderID, nil, &status)
+	return status, err
+}
 
-	err = mockWallet.SetMatch(atomMatch)
-	if err != nil {
-		panic(err)
-	}
+func (client *client) Balances() (http.Balances, error) {
+	balances := http.Balances{}
+	err := client.do("GET", "/balances", nil, &balances)
+	return balances, err
+}
 
-	messageAlice := append([]byte("Republic Protocol: open: "), aliceOrderID[:]...)
-	signatureDataAlice := ethCrypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(messageAlice))), messageAlice)
+func (client *client) Swaps(query url.Values) (http.Swaps, error) {
+	swaps := http.Swaps{}
+	err := client.do("GET", "/swaps?"+query.Encode(), nil, &swaps)
+	return swaps, err
+}
 
-	sigAlice, err := ethCrypto.Sign(signatureDataAlice[:], ownerECDSA)
-	if err != nil {
-		panic(err)
-	}
+func (client *client) Swap(orderID string) (http.SwapDetails, error) {
+	details := http.SwapDetails{}
+	err := client.do("GET", "/swaps/"+orderID, nil, &details)
+	return details, err
+}
 
-	messageBob := append([]byte("Republic Protocol: open: "), bobOrderID[:]...)
-	signatureDataBob := ethCrypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(messageBob))), messageBob)
+func (client *client) SwapAction(orderID, action string) error {
+	return client.do("POST", "/swaps/"+orderID+"/actions/"+action, nil, nil)
+}
 
-	sigBob, err := ethCrypto.Sign(signatureDataBob[:], ownerECDSA)
-	if err != nil {
-		panic(err)
-	}
+func (client *client) Withdraw(withdrawal http.PostWithdrawal) (http.Withdrawal, error) {
+	sent := http.Withdrawal{}
+	err := client.do("POST", "/withdrawals", withdrawal, &sent)
+	return sent, err
+}
 
-	fmt.Println("Signer:", owner.From.String())
-	fmt.Println("Alice:", hex.EncodeToString(aliceOrderID[:]), "Signature:", hex.EncodeToString(sigAlice))
-	fmt.Println("Bob:", hex.EncodeToString(bobOrderID[:]), "Signature:", hex.EncodeToString(sigBob))
+func (client *client) Withdrawals() ([]http.Withdrawal, error) {
+	withdrawals := []http.Withdrawal{}
+	err := client.do("GET", "/withdrawals", nil, &withdrawals)
+	return withdrawals, err
 }
 
-func deposit(ksA, ksB swap.Keystore, auth *bind.TransactOpts, ethConn ethclient.Conn, btcConn btcclient.Conn) error {
-	Alice, err := ksA.LoadKeys()
-	if err != nil {
-		return err
+// do sends the request with the body as JSON, and decodes the response into
+// out if it is not nil.
+func (client *client) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		inBytes, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(inBytes)
 	}
-	Bob, err := ksB.LoadKeys()
-	if err != nil {
-		return err
-	}
-
-	aEth := Alice[0]
-	aBtc := Alice[1]
-	bEth := Bob[0]
-	bBtc := Bob[1]
 
-	aliceEth, err := aEth.GetAddress()
+	req, err := netHttp.NewRequest(method, client.url+path, body)
 	if err != nil {
 		return err
 	}
-
-	aliceBtc, err := aBtc.GetAddress()
-	if err != nil {
-		return err
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
 	}
-
-	bobEth, err := bEth.GetAddress()
-	if err != nil {
-		return err
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	bobBtc, err := bBtc.GetAddress()
+	resp, err := client.http.Do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	aliceEthAddr := common.BytesToAddress(aliceEth)
-	bobEthAddr := common.BytesToAddress(bobEth)
-	// ethConn.Transfer(aliceAddr, auth, 1000000000000)
-
-	bobBtcAddr, err := btcutil.DecodeAddress(string(bobBtc), btcConn.ChainParams)
+	respBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
-
-	aliceBtcAddr, err := btcutil.DecodeAddress(string(aliceBtc), btcConn.ChainParams)
-	if err != nil {
-		return err
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errResp := http.ErrorResponse{}
+		if err := json.Unmarshal(respBytes, &errResp); err != nil || errResp.Error.Code == "" {
+			return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(respBytes)))
+		}
+		errResp.Error.Status = resp.StatusCode
+		return errResp.Error
 	}
-
-	// bobVal, err := btcutil.NewAmount(0.05)
-	// if err != nil {
-	// 	return err
-	// }
-
-	// _, err = btcConn.Client.SendToAddress(bobAddr, bobVal)
-
-	fmt.Println("Deposit Ether to Alice:", aliceEthAddr.String())
-	fmt.Println("Deposit Ether to Bob:", bobEthAddr.String())
-	fmt.Println("Deposit Bitcoin to Alice:", aliceBtcAddr)
-	fmt.Println("Deposit Bitcoin to Bob:", bobBtcAddr)
-
-	return nil
+	if out == nil || len(respBytes) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBytes, out)
 }
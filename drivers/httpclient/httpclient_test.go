@@ -0,0 +1,122 @@
+package httpclient_test
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	netHttp "net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/republicprotocol/renex-swapper-go/adapters/http"
+	. "github.com/republicprotocol/renex-swapper-go/drivers/httpclient"
+)
+
+var _ = Describe("Client", func() {
+	var mux *netHttp.ServeMux
+	var server *httptest.Server
+	var privKey *ecdsa.PrivateKey
+
+	BeforeEach(func() {
+		var err error
+		privKey, err = ethCrypto.GenerateKey()
+		Expect(err).ShouldNot(HaveOccurred())
+		mux = netHttp.NewServeMux()
+		server = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	writeJSON := func(w netHttp.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	It("should log in with a signed challenge and use the session", func() {
+		challenge := hex.EncodeToString([]byte("challenge"))
+		login := http.Login{}
+		mux.HandleFunc("/v1/login", func(w netHttp.ResponseWriter, r *netHttp.Request) {
+			if r.Method == "GET" {
+				writeJSON(w, netHttp.StatusOK, http.Challenge{Challenge: challenge})
+				return
+			}
+			json.NewDecoder(r.Body).Decode(&login)
+			writeJSON(w, netHttp.StatusCreated, http.Session{Token: "token"})
+		})
+		authorization := ""
+		mux.HandleFunc("/v1/status/00", func(w netHttp.ResponseWriter, r *netHttp.Request) {
+			authorization = r.Header.Get("Authorization")
+			writeJSON(w, netHttp.StatusOK, http.Status{OrderID: "00", Status: "PENDING"})
+		})
+
+		client := NewClient(server.URL+"/", server.Client(), "")
+		session, err := client.Login(privKey)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(session.Token).Should(Equal("token"))
+
+		Expect(login.Challenge).Should(Equal(challenge))
+		msg := []byte("Republic Protocol: login: challenge")
+		hash := ethCrypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(msg))), msg)
+		signature, err := hex.DecodeString(login.Signature)
+		Expect(err).ShouldNot(HaveOccurred())
+		pubKey, err := ethCrypto.SigToPub(hash, signature)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ethCrypto.PubkeyToAddress(*pubKey)).Should(Equal(ethCrypto.PubkeyToAddress(privKey.PublicKey)))
+
+		status, err := client.Status("00")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(status.Status).Should(Equal("PENDING"))
+		Expect(authorization).Should(Equal("Bearer token"))
+	})
+
+	It("should send the cancel signature as a query parameter", func() {
+		var method, signature string
+		mux.HandleFunc("/v1/orders/00", func(w netHttp.ResponseWriter, r *netHttp.Request) {
+			method, signature = r.Method, r.URL.Query().Get("signature")
+			w.WriteHeader(netHttp.StatusNoContent)
+		})
+
+		client := NewClient(server.URL, server.Client(), "token")
+		Expect(client.CancelOrder("00", "a+b")).Should(Succeed())
+		Expect(method).Should(Equal("DELETE"))
+		Expect(signature).Should(Equal("a+b"))
+	})
+
+	It("should return the errors of the swapper as API errors", func() {
+		mux.HandleFunc("/v1/withdrawals", func(w netHttp.ResponseWriter, r *netHttp.Request) {
+			writeJSON(w, netHttp.StatusConflict, http.ErrorResponse{
+				Error: http.APIError{
+					Code:    http.CodeInsufficientFunds,
+					Message: "cannot withdraw",
+				},
+			})
+		})
+
+		client := NewClient(server.URL, server.Client(), "token")
+		_, err := client.Withdraw(http.PostWithdrawal{Currency: "ETH", Amount: "1"})
+		Expect(err).Should(Equal(http.APIError{
+			Status:  netHttp.StatusConflict,
+			Code:    http.CodeInsufficientFunds,
+			Message: "cannot withdraw",
+		}))
+	})
+
+	It("should return other error responses with their status", func() {
+		mux.HandleFunc("/v1/balances", func(w netHttp.ResponseWriter, r *netHttp.Request) {
+			netHttp.Error(w, "bad gateway", netHttp.StatusBadGateway)
+		})
+
+		client := NewClient(server.URL, server.Client(), "token")
+		_, err := client.Balances()
+		Expect(err).Should(HaveOccurred())
+		Expect(err).ShouldNot(BeAssignableToTypeOf(http.APIError{}))
+		Expect(err.Error()).Should(ContainSubstring("502 Bad Gateway: bad gateway"))
+	})
+})
@@ -0,0 +1,282 @@
+package admin
+
+import (
+	"errors"
+	"time"
+
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/swap"
+)
+
+// Actions that an operator can take on a swap
+const (
+	ActionRetry    = "retry"
+	ActionRefund   = "refund"
+	ActionResolve  = "resolve"
+	ActionResubmit = "resubmit"
+	ActionAbandon  = "abandon"
+)
+
+var ErrUnknownAction = errors.New("unknown action")
+var ErrSwapFinished = errors.New("swap has already finished")
+var ErrNotFailed = errors.New("swap has not failed, it is still in progress")
+var ErrNotInitiated = errors.New("swap has not been initiated by the swapper")
+var ErrNotExpired = errors.New("swap has not expired yet")
+var ErrNotComplained = errors.New("swap has not been complained about")
+var ErrFunded = errors.New("swap has been funded by the swapper, it can only be refunded")
+
+// Swapper runs the steps of swaps.
+type Swapper interface {
+	Retry([32]byte) error
+	ResubmitDetails([32]byte) error
+
+	// Hold runs the function while the swap is not running, taking the swap
+	// off the queue if it is waiting to run. It returns
+	// scheduler.ErrInFlight if the swap is running.
+	Hold([32]byte, func() error) error
+}
+
+// Refunder refunds swaps.
+type Refunder interface {
+	Refund([32]byte) error
+}
+
+// Admin lets operators unstick swaps. Every action is recorded in the swap's
+// history along with the actor that took it, and actions that could lose
+// funds are refused.
+type Admin interface {
+	// Do takes the named action on the swap.
+	Do(orderID [32]byte, action, actor string) error
+
+	// Retry runs a swap that stopped because of an error again.
+	Retry(orderID [32]byte, actor string) error
+
+	// Refund refunds a swap that the swapper initiated once it has expired.
+	Refund(orderID [32]byte, actor string) error
+
+	// Resolve marks the complaint about a swap as resolved and resumes the
+	// swap from the step that it complained at.
+	Resolve(orderID [32]byte, actor string) error
+
+	// Resubmit sends the swap details to the counterparty again.
+	Resubmit(orderID [32]byte, actor string) error
+
+	// Abandon gives up on a swap that the swapper has not funded. It returns
+	// scheduler.ErrInFlight if the swap is running.
+	Abandon(orderID [32]byte, actor string) error
+}
+
+type admin struct {
+	state     store.State
+	swapper   Swapper
+	refunder  Refunder
+	publisher events.Publisher
+}
+
+// NewAdmin returns an Admin that takes actions using the swapper and refunder.
+func NewAdmin(state store.State, swapper Swapper, refunder Refunder, publisher events.Publisher) Admin {
+	return &admin{
+		state:     state,
+		swapper:   swapper,
+		refunder:  refunder,
+		publisher: publisher,
+	}
+}
+
+func (admin *admin) Do(orderID [32]byte, action, actor string) error {
+	switch action {
+	case ActionRetry:
+		return admin.Retry(orderID, actor)
+	case ActionRefund:
+		return admin.Refund(orderID, actor)
+	case ActionResolve:
+		return admin.Resolve(orderID, actor)
+	case ActionResubmit:
+		return admin.Resubmit(orderID, actor)
+	case ActionAbandon:
+		return admin.Abandon(orderID, actor)
+	}
+	return ErrUnknownAction
+}
+
+func (admin *admin) Retry(orderID [32]byte, actor string) error {
+	if err := admin.pending(orderID); err != nil {
+		return err
+	}
+	if _, err := admin.state.Error(orderID); err == store.ErrKeyNotFound {
+		return ErrNotFailed
+	}
+
+	if err := admin.record(orderID, ActionRetry, actor, ""); err != nil {
+		return err
+	}
+	return admin.swapper.Retry(orderID)
+}
+
+func (admin *admin) Refund(orderID [32]byte, actor string) error {
+	if err := admin.pending(orderID); err != nil {
+		return err
+	}
+	if !admin.state.IsRedeemable(orderID) {
+		return ErrNotInitiated
+	}
+	expiry, _, err := admin.state.InitiateDetails(orderID)
+	if err != nil {
+		return err
+	}
+	if time.Now().Unix() < expiry {
+		return ErrNotExpired
+	}
+
+	if err := admin.refunder.Refund(orderID); err != nil {
+		return err
+	}
+	return admin.record(orderID, ActionRefund, actor, "")
+}
+
+func (admin *admin) Resolve(orderID [32]byte, actor string) error {
+	if err := admin.pending(orderID); err != nil {
+		return err
+	}
+	if admin.state.Status(orderID) != swap.StatusComplained {
+		return ErrNotComplained
+	}
+	complaint, err := admin.state.Complaint(orderID)
+	if err != nil {
+		return err
+	}
+	status, err := admin.statusBeforeComplaint(orderID)
+	if err != nil {
+		return err
+	}
+
+	tx := admin.state.NewTransaction()
+	if err := tx.ResolveComplaint(orderID, complaint, actor); err != nil {
+		return err
+	}
+
+	if err := tx.PutAction(orderID, ActionResolve, actor); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, status); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	admin.publisher.Publish(events.Status(orderID, status))
+	return admin.swapper.Retry(orderID)
+}
+
+func (admin *admin) Resubmit(orderID [32]byte, actor string) error {
+	if err := admin.pending(orderID); err != nil {
+		return err
+	}
+	if !admin.state.IsRedeemable(orderID) {
+		return ErrNotInitiated
+	}
+
+	if err := admin.record(orderID, ActionResubmit, actor, ""); err != nil {
+		return err
+	}
+	return admin.swapper.ResubmitDetails(orderID)
+}
+
+func (admin *admin) Abandon(orderID [32]byte, actor string) error {
+	return admin.swapper.Hold(orderID, func() error {
+		return admin.abandon(orderID, actor)
+	})
+}
+
+// abandon archives the swap as abandoned, it must only be called while the
+// swap is not running so that it cannot be funded at the same time.
+func (admin *admin) abandon(orderID [32]byte, actor string) error {
+	if err := admin.pending(orderID); err != nil {
+		return err
+	}
+	if admin.state.IsRedeemable(orderID) {
+		return ErrFunded
+	}
+	txs, err := admin.state.Transactions(orderID)
+	if err != nil {
+		return err
+	}
+	if txs.Initiate != "" {
+		return ErrFunded
+	}
+
+	tx := admin.state.NewTransaction()
+	if err := tx.PutAction(orderID, ActionAbandon, actor); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, swap.StatusAbandoned); err != nil {
+		return err
+	}
+
+	if err := tx.ArchiveSwap(orderID, swap.StatusAbandoned); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	admin.publisher.Publish(events.Status(orderID, swap.StatusAbandoned))
+	return nil
+}
+
+// pending returns an error if the swap is not pending or has finished.
+func (admin *admin) pending(orderID [32]byte) error {
+	if _, err := admin.state.PendingSwap(orderID); err != nil {
+		return err
+	}
+	if swap.Finished(admin.state.Status(orderID)) {
+		return ErrSwapFinished
+	}
+	return nil
+}
+
+// record writes the action to the swap's history, along with the status that
+// it moves the swap to if there is one.
+func (admin *admin) record(orderID [32]byte, action, actor, status string) error {
+	tx := admin.state.NewTransaction()
+	if err := tx.PutAction(orderID, action, actor); err != nil {
+		return err
+	}
+
+	if status != "" {
+		if err := tx.PutStatus(orderID, status); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if status != "" {
+		admin.publisher.Publish(events.Status(orderID, status))
+	}
+	return nil
+}
+
+// statusBeforeComplaint returns the last status that the swap had before it
+// was complained about.
+func (admin *admin) statusBeforeComplaint(orderID [32]byte) (string, error) {
+	history, err := admin.state.History(orderID)
+	if err != nil {
+		return "", err
+	}
+	status := ""
+	for _, change := range history {
+		if change.Status != "" && change.Status != swap.StatusComplained {
+			status = change.Status
+		}
+	}
+	if status == "" {
+		return "", ErrNotComplained
+	}
+	return status, nil
+}
@@ -0,0 +1,179 @@
+package admin_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	. "github.com/republicprotocol/renex-swapper-go/services/admin"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/scheduler"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/swap"
+)
+
+// mockSwapper records the swaps that it runs, and holds them unless they are
+// running.
+type mockSwapper struct {
+	retried     [][32]byte
+	resubmitted [][32]byte
+	running     bool
+}
+
+func (swapper *mockSwapper) Retry(orderID [32]byte) error {
+	swapper.retried = append(swapper.retried, orderID)
+	return nil
+}
+
+func (swapper *mockSwapper) ResubmitDetails(orderID [32]byte) error {
+	swapper.resubmitted = append(swapper.resubmitted, orderID)
+	return nil
+}
+
+func (swapper *mockSwapper) Hold(orderID [32]byte, f func() error) error {
+	if swapper.running {
+		return scheduler.ErrInFlight
+	}
+	return f()
+}
+
+// mockRefunder records the swaps that it refunds, and fails to refund them
+// while err is set.
+type mockRefunder struct {
+	refunded [][32]byte
+	err      error
+}
+
+func (refunder *mockRefunder) Refund(orderID [32]byte) error {
+	if refunder.err != nil {
+		return refunder.err
+	}
+	refunder.refunded = append(refunder.refunded, orderID)
+	return nil
+}
+
+var _ = Describe("Admin", func() {
+	var state store.State
+	var swapper *mockSwapper
+	var refunder *mockRefunder
+	var admin Admin
+	var orderID [32]byte
+
+	actions := func() []string {
+		history, err := state.History(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		actions := []string{}
+		for _, change := range history {
+			if change.Action != "" {
+				Expect(change.Actor).Should(Equal("0xoperator"))
+				actions = append(actions, change.Action)
+			}
+		}
+		return actions
+	}
+
+	BeforeEach(func() {
+		state = store.NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		swapper = &mockSwapper{}
+		refunder = &mockRefunder{}
+		admin = NewAdmin(state, swapper, refunder, events.NewBroker(0))
+		rand.Read(orderID[:])
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.PutStatus(orderID, swap.StatusMatched)).ShouldNot(HaveOccurred())
+	})
+
+	It("rejects unknown actions", func() {
+		Expect(admin.Do(orderID, "delete", "0xoperator")).Should(Equal(ErrUnknownAction))
+	})
+
+	It("rejects actions on swaps that are not pending", func() {
+		Expect(state.ArchiveSwap(orderID, swap.StatusRedeemed)).ShouldNot(HaveOccurred())
+		Expect(admin.Do(orderID, ActionRetry, "0xoperator")).Should(Equal(store.ErrKeyNotFound))
+	})
+
+	It("only retries swaps that have failed", func() {
+		Expect(admin.Retry(orderID, "0xoperator")).Should(Equal(ErrNotFailed))
+		Expect(swapper.retried).Should(BeEmpty())
+
+		Expect(state.PutError(orderID, "connection refused")).ShouldNot(HaveOccurred())
+		Expect(admin.Retry(orderID, "0xoperator")).ShouldNot(HaveOccurred())
+		Expect(swapper.retried).Should(Equal([][32]byte{orderID}))
+		Expect(actions()).Should(Equal([]string{ActionRetry}))
+	})
+
+	It("only refunds initiated swaps that have expired", func() {
+		Expect(admin.Refund(orderID, "0xoperator")).Should(Equal(ErrNotInitiated))
+
+		Expect(state.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.PutInitiateDetails(orderID, time.Now().Add(time.Hour).Unix(), [32]byte{})).ShouldNot(HaveOccurred())
+		Expect(admin.Refund(orderID, "0xoperator")).Should(Equal(ErrNotExpired))
+		Expect(refunder.refunded).Should(BeEmpty())
+
+		Expect(state.PutInitiateDetails(orderID, time.Now().Add(-time.Hour).Unix(), [32]byte{})).ShouldNot(HaveOccurred())
+		Expect(admin.Refund(orderID, "0xoperator")).ShouldNot(HaveOccurred())
+		Expect(refunder.refunded).Should(Equal([][32]byte{orderID}))
+		Expect(actions()).Should(Equal([]string{ActionRefund}))
+	})
+
+	It("only records refunds that succeeded", func() {
+		Expect(state.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.PutInitiateDetails(orderID, time.Now().Add(-time.Hour).Unix(), [32]byte{})).ShouldNot(HaveOccurred())
+		refunder.err = errors.New("connection refused")
+		Expect(admin.Refund(orderID, "0xoperator")).Should(MatchError("connection refused"))
+		Expect(actions()).Should(BeEmpty())
+	})
+
+	It("resolves complaints and resumes the swap", func() {
+		Expect(admin.Resolve(orderID, "0xoperator")).Should(Equal(ErrNotComplained))
+
+		tx := state.NewTransaction()
+		Expect(tx.PutComplaint(orderID, "invalid contract")).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, swap.StatusComplained)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		Expect(admin.Resolve(orderID, "0xoperator")).ShouldNot(HaveOccurred())
+		Expect(state.Status(orderID)).Should(Equal(swap.StatusMatched))
+		complaint, err := state.Complaint(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(complaint.ResolvedBy).Should(Equal("0xoperator"))
+		Expect(swapper.retried).Should(Equal([][32]byte{orderID}))
+		Expect(actions()).Should(Equal([]string{ActionResolve}))
+	})
+
+	It("only resubmits details of initiated swaps", func() {
+		Expect(admin.Resubmit(orderID, "0xoperator")).Should(Equal(ErrNotInitiated))
+
+		Expect(state.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(admin.Resubmit(orderID, "0xoperator")).ShouldNot(HaveOccurred())
+		Expect(swapper.resubmitted).Should(Equal([][32]byte{orderID}))
+	})
+
+	It("abandons swaps that have not been funded", func() {
+		Expect(admin.Abandon(orderID, "0xoperator")).ShouldNot(HaveOccurred())
+		_, err := state.PendingSwap(orderID)
+		Expect(err).Should(Equal(store.ErrKeyNotFound))
+		summary, err := state.ArchivedSwap(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summary.Outcome).Should(Equal(swap.StatusAbandoned))
+		Expect(actions()).Should(Equal([]string{ActionAbandon}))
+	})
+
+	It("refuses to abandon swaps that are running", func() {
+		swapper.running = true
+		Expect(admin.Abandon(orderID, "0xoperator")).Should(Equal(scheduler.ErrInFlight))
+		Expect(state.Status(orderID)).Should(Equal(swap.StatusMatched))
+		Expect(actions()).Should(BeEmpty())
+	})
+
+	It("refuses to abandon swaps that have been funded", func() {
+		Expect(state.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(admin.Abandon(orderID, "0xoperator")).Should(Equal(ErrFunded))
+		Expect(state.Status(orderID)).Should(Equal(swap.StatusMatched))
+		Expect(actions()).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Permissions that can be given to a session
+const (
+	PermissionRead  = "read"
+	PermissionTrade = "trade"
+	PermissionAdmin = "admin"
+)
+
+// ChallengeTTL is how long a login challenge can be signed for.
+const ChallengeTTL = 5 * time.Minute
+
+// MaxChallenges is how many challenges can be waiting to be signed at the
+// same time. Challenges are issued without a session, so they are capped to
+// bound the memory that unauthenticated clients can use.
+const MaxChallenges = 1024
+
+var ErrUnknownChallenge = errors.New("unknown or expired challenge")
+var ErrTooManyChallenges = errors.New("too many challenges are waiting to be signed")
+var ErrUnauthorized = errors.New("missing or expired session token")
+var ErrForbidden = errors.New("session does not have the permission")
+
+// Challenge is a random value that an authorized address signs to log in.
+type Challenge struct {
+	Challenge [32]byte
+	ExpiresAt int64
+}
+
+// Session is issued to an authorized address that has signed a challenge.
+type Session struct {
+	Token       string
+	Address     string
+	Permissions []string
+	ExpiresAt   int64
+}
+
+// Authenticator issues login challenges and the sessions that are created by
+// signing them. The caller is responsible for verifying the signature.
+type Authenticator interface {
+	// NewChallenge returns a challenge that expires after the ChallengeTTL.
+	// It returns ErrTooManyChallenges if MaxChallenges have not been signed
+	// or expired yet.
+	NewChallenge() (Challenge, error)
+
+	// Login consumes the challenge and returns a session for the address with
+	// the given permissions.
+	Login(challenge [32]byte, address string, permissions []string) (Session, error)
+
+	// Authorize returns the session of the token if it has the permission.
+	Authorize(token, permission string) (Session, error)
+
+	Logout(token string)
+}
+
+type authenticator struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	challenges map[[32]byte]int64
+	sessions   map[string]Session
+}
+
+// NewAuthenticator returns an Authenticator whose sessions last for the ttl.
+// Sessions are kept in memory, so they end when the swapper is restarted.
+func NewAuthenticator(ttl time.Duration) Authenticator {
+	return &authenticator{
+		ttl:        ttl,
+		challenges: map[[32]byte]int64{},
+		sessions:   map[string]Session{},
+	}
+}
+
+func (auth *authenticator) NewChallenge() (Challenge, error) {
+	challenge := Challenge{
+		ExpiresAt: time.Now().Add(ChallengeTTL).Unix(),
+	}
+	if _, err := rand.Read(challenge.Challenge[:]); err != nil {
+		return Challenge{}, err
+	}
+
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	auth.expire()
+	if len(auth.challenges) >= MaxChallenges {
+		return Challenge{}, ErrTooManyChallenges
+	}
+	auth.challenges[challenge.Challenge] = challenge.ExpiresAt
+	return challenge, nil
+}
+
+func (auth *authenticator) Login(challenge [32]byte, address string, permissions []string) (Session, error) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	auth.expire()
+
+	if _, ok := auth.challenges[challenge]; !ok {
+		return Session{}, ErrUnknownChallenge
+	}
+	delete(auth.challenges, challenge)
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return Session{}, err
+	}
+	session := Session{
+		Token:       hex.EncodeToString(token),
+		Address:     address,
+		Permissions: permissions,
+		ExpiresAt:   time.Now().Add(auth.ttl).Unix(),
+	}
+	auth.sessions[session.Token] = session
+	return session, nil
+}
+
+func (auth *authenticator) Authorize(token, permission string) (Session, error) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	auth.expire()
+
+	session, ok := auth.sessions[token]
+	if !ok {
+		return Session{}, ErrUnauthorized
+	}
+	for _, p := range session.Permissions {
+		if p == permission {
+			return session, nil
+		}
+	}
+	return Session{}, ErrForbidden
+}
+
+func (auth *authenticator) Logout(token string) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	delete(auth.sessions, token)
+}
+
+// expire removes the challenges and sessions that have expired. It must be
+// called with the lock held.
+func (auth *authenticator) expire() {
+	now := time.Now().Unix()
+	for challenge, expiresAt := range auth.challenges {
+		if now >= expiresAt {
+			delete(auth.challenges, challenge)
+		}
+	}
+	for token, session := range auth.sessions {
+		if now >= session.ExpiresAt {
+			delete(auth.sessions, token)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package auth_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/services/auth"
+)
+
+var _ = Describe("Authenticator", func() {
+	var auth Authenticator
+	address := "0x0000000000000000000000000000000000000001"
+
+	login := func(permissions ...string) Session {
+		challenge, err := auth.NewChallenge()
+		Expect(err).ShouldNot(HaveOccurred())
+		session, err := auth.Login(challenge.Challenge, address, permissions)
+		Expect(err).ShouldNot(HaveOccurred())
+		return session
+	}
+
+	BeforeEach(func() {
+		auth = NewAuthenticator(time.Minute)
+	})
+
+	It("authorizes sessions with the permission", func() {
+		session := login(PermissionRead)
+		Expect(session.Token).Should(HaveLen(64))
+
+		authorized, err := auth.Authorize(session.Token, PermissionRead)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(authorized.Address).Should(Equal(address))
+
+		_, err = auth.Authorize(session.Token, PermissionTrade)
+		Expect(err).Should(Equal(ErrForbidden))
+	})
+
+	It("only accepts a challenge once", func() {
+		challenge, err := auth.NewChallenge()
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = auth.Login(challenge.Challenge, address, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = auth.Login(challenge.Challenge, address, nil)
+		Expect(err).Should(Equal(ErrUnknownChallenge))
+		_, err = auth.Login([32]byte{1}, address, nil)
+		Expect(err).Should(Equal(ErrUnknownChallenge))
+	})
+
+	It("rejects unknown, expired and logged out tokens", func() {
+		_, err := auth.Authorize("token", PermissionRead)
+		Expect(err).Should(Equal(ErrUnauthorized))
+
+		auth = NewAuthenticator(0)
+		session := login(PermissionRead)
+		_, err = auth.Authorize(session.Token, PermissionRead)
+		Expect(err).Should(Equal(ErrUnauthorized))
+
+		auth = NewAuthenticator(time.Minute)
+		session = login(PermissionRead)
+		auth.Logout(session.Token)
+		_, err = auth.Authorize(session.Token, PermissionRead)
+		Expect(err).Should(Equal(ErrUnauthorized))
+	})
+
+	It("caps the challenges that are waiting to be signed", func() {
+		challenges := []Challenge{}
+		for i := 0; i < MaxChallenges; i++ {
+			challenge, err := auth.NewChallenge()
+			Expect(err).ShouldNot(HaveOccurred())
+			challenges = append(challenges, challenge)
+		}
+		_, err := auth.NewChallenge()
+		Expect(err).Should(Equal(ErrTooManyChallenges))
+
+		_, err = auth.Login(challenges[0].Challenge, address, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = auth.NewChallenge()
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
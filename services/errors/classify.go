@@ -0,0 +1,42 @@
+package errors
+
+import "strings"
+
+// permanentMessages are parts of the messages of errors that will happen
+// again however many times a step is retried. Errors are wrapped by
+// formatting their messages, so they can only be recognised by their
+// messages.
+var permanentMessages = []string{
+	"key not found",
+	"Failed to build the atom",
+	"same priority code",
+	"Trying to refund a non refundable order",
+	"Persistent Storage Error",
+	"Transaction reverted",
+	"execution reverted",
+	"invalid address",
+	"failed to decode",
+	"expired before it could be redeemed",
+	"atom is unknown",
+}
+
+// IsTransient returns true if retrying the step that failed with the error
+// could succeed, such as when a node could not be reached. Errors that are
+// not known to be permanent are treated as transient, the caller must limit
+// how many times they are retried.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch err {
+	case ErrNonRefundable, ErrNotInitiated, ErrClaimExpired, ErrCounterpartyAtomUnknown:
+		return false
+	}
+	message := err.Error()
+	for _, permanent := range permanentMessages {
+		if strings.Contains(message, permanent) {
+			return false
+		}
+	}
+	return true
+}
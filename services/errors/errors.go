@@ -3,8 +3,21 @@ package errors
 import "fmt"
 
 var (
-	ErrNonRefundable = fmt.Errorf("Trying to refund a non refundable order")
-	ErrNotInitiated  = fmt.Errorf("Trying to refund a swap which is not initiated")
+	ErrNonRefundable  = fmt.Errorf("Trying to refund a non refundable order")
+	ErrNotInitiated   = fmt.Errorf("Trying to refund a swap which is not initiated")
+	ErrMatchNotFound  = fmt.Errorf("Match does not exist")
+	ErrOrderCancelled = fmt.Errorf("Order cancelled")
+	ErrOrderExpired   = fmt.Errorf("Order expired")
+
+	// ErrClaimExpired is returned when the counterparty redeemed the
+	// swapper's atom after the counterparty's atom expired, so the swapper
+	// can no longer redeem it.
+	ErrClaimExpired = fmt.Errorf("The counterparty's atom expired before it could be redeemed")
+
+	// ErrCounterpartyAtomUnknown is returned when the counterparty redeemed
+	// the swapper's atom but the details of the counterparty's atom were
+	// never received.
+	ErrCounterpartyAtomUnknown = fmt.Errorf("The counterparty's atom is unknown")
 )
 
 func ErrAtomBuildFailed(err error) error {
@@ -0,0 +1,219 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TypeStatus      = "status"
+	TypeTransaction = "transaction"
+	TypeError       = "error"
+)
+
+// DefaultHistory is the number of events a broker keeps so that subscribers
+// can resume after reconnecting.
+const DefaultHistory = 1024
+
+// subscriberBuffer is the number of events that can be queued for a
+// subscriber before it is considered too slow and is dropped.
+const subscriberBuffer = 256
+
+// Event is a change in a swap. Events are numbered in the order in which they
+// were published, starting from one.
+type Event struct {
+	Sequence    uint64   `json:"sequence"`
+	Type        string   `json:"type"`
+	OrderID     [32]byte `json:"-"`
+	Time        int64    `json:"time"`
+	Status      string   `json:"status,omitempty"`
+	Transaction string   `json:"transaction,omitempty"`
+	Hash        string   `json:"hash,omitempty"`
+	Message     string   `json:"message,omitempty"`
+}
+
+// Status returns an event for a swap reaching a status.
+func Status(orderID [32]byte, status string) Event {
+	return Event{
+		Type:    TypeStatus,
+		OrderID: orderID,
+		Status:  status,
+	}
+}
+
+// Transaction returns an event for a swap transaction, such as an initiate or
+// a redeem, being confirmed.
+func Transaction(orderID [32]byte, transaction, hash string) Event {
+	return Event{
+		Type:        TypeTransaction,
+		OrderID:     orderID,
+		Transaction: transaction,
+		Hash:        hash,
+	}
+}
+
+// Error returns an event for an error that stopped a swap from progressing.
+func Error(orderID [32]byte, err error) Event {
+	return Event{
+		Type:    TypeError,
+		OrderID: orderID,
+		Message: err.Error(),
+	}
+}
+
+// Publisher is implemented by anything that events can be sent to.
+type Publisher interface {
+	Publish(Event)
+}
+
+// Broker delivers published events to its subscribers, keeping the most
+// recent events so that subscribers can resume from a sequence number.
+type Broker interface {
+	Publisher
+
+	// Subscribe returns a subscription to the events of the order, or of all
+	// orders if the order ID is nil. Events after the sequence number are
+	// replayed first.
+	Subscribe(orderID *[32]byte, after uint64) *Subscription
+
+	// Close closes every subscription, so that the subscribers stop when
+	// the swapper shuts down. Subscriptions made after it are closed once
+	// their events have been replayed.
+	Close()
+}
+
+// Subscription receives events from a broker. The events channel is closed
+// when the subscription is closed, or when the subscriber falls too far
+// behind, in which case it should subscribe again from the last sequence
+// number it received.
+type Subscription struct {
+	Events <-chan Event
+
+	// Missed is true if events after the requested sequence number are no
+	// longer held by the broker and could not be replayed, or if the sequence
+	// number was issued before the broker was restarted.
+	Missed bool
+
+	broker  *broker
+	orderID *[32]byte
+	ch      chan Event
+}
+
+// Close stops the subscription.
+func (sub *Subscription) Close() {
+	sub.broker.unsubscribe(sub)
+}
+
+type broker struct {
+	mu          sync.Mutex
+	sequence    uint64
+	history     []Event
+	next        int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBroker returns a Broker that keeps the given number of recent events.
+func NewBroker(history int) Broker {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &broker{
+		history:     make([]Event, 0, history),
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+func (broker *broker) Publish(event Event) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	broker.sequence++
+	event.Sequence = broker.sequence
+	if event.Time == 0 {
+		event.Time = time.Now().Unix()
+	}
+
+	if len(broker.history) < cap(broker.history) {
+		broker.history = append(broker.history, event)
+	} else {
+		broker.history[broker.next] = event
+		broker.next = (broker.next + 1) % len(broker.history)
+	}
+
+	for sub := range broker.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// Drop subscribers that are not keeping up rather than blocking
+			// the swaps, they can resume from their last event
+			delete(broker.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (broker *broker) Subscribe(orderID *[32]byte, after uint64) *Subscription {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	replay := []Event{}
+	oldest := broker.sequence + 1
+	for i := range broker.history {
+		event := broker.history[(broker.next+i)%len(broker.history)]
+		if event.Sequence < oldest {
+			oldest = event.Sequence
+		}
+		if event.Sequence > after && (orderID == nil || event.OrderID == *orderID) {
+			replay = append(replay, event)
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer+len(replay))
+	for _, event := range replay {
+		ch <- event
+	}
+	sub := &Subscription{
+		Events:  ch,
+		Missed:  after != 0 && (after+1 < oldest || after > broker.sequence),
+		broker:  broker,
+		orderID: orderID,
+		ch:      ch,
+	}
+	if broker.closed {
+		close(ch)
+		return sub
+	}
+	broker.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (broker *broker) Close() {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	broker.closed = true
+	for sub := range broker.subscribers {
+		delete(broker.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+func (broker *broker) unsubscribe(sub *Subscription) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if _, ok := broker.subscribers[sub]; !ok {
+		return
+	}
+	delete(broker.subscribers, sub)
+	close(sub.ch)
+}
+
+func (sub *Subscription) matches(event Event) bool {
+	return sub.orderID == nil || event.OrderID == *sub.orderID
+}
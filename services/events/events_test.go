@@ -0,0 +1,98 @@
+package events_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/services/events"
+)
+
+var _ = Describe("Broker", func() {
+	var broker Broker
+	first, second := [32]byte{1}, [32]byte{2}
+
+	receive := func(sub *Subscription) Event {
+		var event Event
+		Eventually(sub.Events).Should(Receive(&event))
+		return event
+	}
+
+	BeforeEach(func() {
+		broker = NewBroker(4)
+	})
+
+	It("delivers events to subscribers of all orders", func() {
+		sub := broker.Subscribe(nil, 0)
+		defer sub.Close()
+
+		broker.Publish(Status(first, "INITIATED"))
+		broker.Publish(Transaction(second, "initiate", "0x01"))
+
+		event := receive(sub)
+		Expect(event.Sequence).Should(Equal(uint64(1)))
+		Expect(event.Status).Should(Equal("INITIATED"))
+		Expect(event.Time).ShouldNot(BeZero())
+		Expect(receive(sub).Hash).Should(Equal("0x01"))
+	})
+
+	It("only delivers the events of the chosen order", func() {
+		sub := broker.Subscribe(&second, 0)
+		defer sub.Close()
+
+		broker.Publish(Status(first, "INITIATED"))
+		broker.Publish(Status(second, "AUDITED"))
+
+		event := receive(sub)
+		Expect(event.OrderID).Should(Equal(second))
+		Expect(event.Sequence).Should(Equal(uint64(2)))
+		Consistently(sub.Events).ShouldNot(Receive())
+	})
+
+	It("replays the events after the sequence number", func() {
+		for _, status := range []string{"PENDING", "MATCHED", "INFO_SUBMITTED"} {
+			broker.Publish(Status(first, status))
+		}
+
+		sub := broker.Subscribe(nil, 1)
+		defer sub.Close()
+		Expect(sub.Missed).Should(BeFalse())
+		Expect(receive(sub).Status).Should(Equal("MATCHED"))
+		Expect(receive(sub).Status).Should(Equal("INFO_SUBMITTED"))
+	})
+
+	It("reports events that can no longer be replayed", func() {
+		for i := 0; i < 6; i++ {
+			broker.Publish(Status(first, "PENDING"))
+		}
+
+		sub := broker.Subscribe(nil, 1)
+		defer sub.Close()
+		Expect(sub.Missed).Should(BeTrue())
+		Expect(receive(sub).Sequence).Should(Equal(uint64(3)))
+
+		restarted := broker.Subscribe(nil, 100)
+		defer restarted.Close()
+		Expect(restarted.Missed).Should(BeTrue())
+	})
+
+	It("closes the events channel when the subscription is closed", func() {
+		sub := broker.Subscribe(nil, 0)
+		sub.Close()
+		Eventually(sub.Events).Should(BeClosed())
+		sub.Close()
+	})
+
+	It("closes every subscription when it is closed", func() {
+		broker.Publish(Status(first, "PENDING"))
+		sub := broker.Subscribe(nil, 0)
+		broker.Close()
+		Expect(receive(sub).Status).Should(Equal("PENDING"))
+		Eventually(sub.Events).Should(BeClosed())
+		sub.Close()
+
+		late := broker.Subscribe(nil, 0)
+		Expect(receive(late).Status).Should(Equal("PENDING"))
+		Eventually(late.Events).Should(BeClosed())
+		late.Close()
+	})
+})
@@ -3,93 +3,290 @@ package guardian
 import (
 	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/republicprotocol/renex-swapper-go/adapters/atoms"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/domains/order"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
 	"github.com/republicprotocol/renex-swapper-go/services/errors"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
+	"github.com/republicprotocol/renex-swapper-go/services/scheduler"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
 	"github.com/republicprotocol/renex-swapper-go/services/store"
 	"github.com/republicprotocol/renex-swapper-go/services/swap"
 )
 
 var ErrSwapRedeemed = fmt.Errorf("Swap Redeemed")
 
+// resyncInterval is how often the refundable swaps are checked, so that
+// refunds are retried without waiting for the guardian to be notified.
+const resyncInterval = time.Minute
+
+// claimRetryInterval is the longest the guardian waits before retrying to
+// redeem the counterparty's atom, which it has to do before the atom expires.
+const claimRetryInterval = time.Minute
+
+// claimError is an error redeeming the counterparty's atom after the
+// counterparty redeemed the swapper's atom, which expires at expiry.
+type claimError struct {
+	err    error
+	expiry int64
+}
+
+func (err claimError) Error() string {
+	return fmt.Sprintf("failed to redeem the counterparty's atom: %v", err.err)
+}
+
+// refundTiming is how long the guardian waits after an atom expires before
+// refunding it, and how often it checks an expired atom that cannot be
+// refunded yet.
+type refundTiming struct {
+	Margin  time.Duration
+	Recheck time.Duration
+}
+
+// refundTimings are the refund timings of each currency. Bitcoin only accepts
+// a refund once the median time of the last 11 blocks has passed the lock
+// time, which lags the wall clock by about an hour.
+var refundTimings = map[uint32]refundTiming{
+	cc.BITCOINCC:  {Margin: time.Hour, Recheck: 10 * time.Minute},
+	cc.ETHEREUMCC: {Margin: time.Minute, Recheck: time.Minute},
+}
+
+// defaultRefundTiming is used for currencies without refund timings.
+var defaultRefundTiming = refundTiming{Margin: time.Hour, Recheck: 10 * time.Minute}
+
+func timingOf(code uint32) refundTiming {
+	if timing, ok := refundTimings[code]; ok {
+		return timing
+	}
+	return defaultRefundTiming
+}
+
 type Guardian interface {
 	Start() <-chan error
 	Notify()
 	Stop()
+
+	// Drain stops the guardian and waits for the refunds that are running to
+	// finish. It returns false if they did not finish before the timeout.
+	Drain(timeout time.Duration) bool
+
+	// Refund refunds the swap without waiting for it to expire or checking
+	// that it can be refunded, the caller must check that it has expired. If
+	// the counterparty has redeemed the swapper's atom, the counterparty's
+	// atom is redeemed instead. A refund that is waiting for the atom to
+	// expire, or to be retried, is run straight away. It returns
+	// scheduler.ErrInFlight if the guardian is already refunding the swap.
+	Refund([32]byte) error
+
+	// Alive returns true if the guardian has been started and has not
+	// stopped.
+	Alive() bool
 }
 
 type guardian struct {
-	builder  atoms.AtomBuilder
-	state    store.State
-	notifyCh chan struct{}
-	doneCh   chan struct{}
+	builder     atoms.AtomBuilder
+	state       store.State
+	publisher   events.Publisher
+	broadcaster shutdown.Broadcaster
+	scheduler   scheduler.Scheduler
+	backoff     scheduler.Backoff
+	notifyCh    chan struct{}
+	doneCh      chan struct{}
+
+	// running is 1 while the loop started by Start is running.
+	running int32
 }
 
-func NewGuardian(builder atoms.AtomBuilder, state store.State) Guardian {
-	return &guardian{
-		builder:  builder,
-		state:    state,
-		notifyCh: make(chan struct{}, 1),
-		doneCh:   make(chan struct{}, 1),
+// NewGuardian returns a Guardian that refunds at most concurrency swaps at
+// the same time, or scheduler.DefaultConcurrency if it is not positive.
+// Refunds are broadcast through the broadcaster, so that they are not
+// interrupted when the swapper shuts down.
+func NewGuardian(builder atoms.AtomBuilder, state store.State, publisher events.Publisher, broadcaster shutdown.Broadcaster, concurrency int) Guardian {
+	g := &guardian{
+		builder:     builder,
+		state:       state,
+		publisher:   publisher,
+		broadcaster: broadcaster,
+		backoff:     scheduler.DefaultBackoff,
+		notifyCh:    make(chan struct{}, 1),
+		doneCh:      make(chan struct{}, 1),
 	}
+	g.scheduler = scheduler.NewScheduler(metrics.QueueGuardian, concurrency, g.run)
+	return g
 }
 
+// Start schedules the refunds of the swaps that the swapper initiated each
+// time the guardian is notified, and once a minute.
 func (g *guardian) Start() <-chan error {
 	errs := make(chan error)
 	log.Println("Starting the guardian......")
+	atomic.StoreInt32(&g.running, 1)
+	g.scheduler.Start(errs)
 	go func() {
 		defer log.Println("Ending the guardian......")
+		defer atomic.StoreInt32(&g.running, 0)
+		defer g.scheduler.Stop()
+		ticker := time.NewTicker(resyncInterval)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-g.doneCh:
 				return
 			case <-g.notifyCh:
-				swaps, err := g.state.RefundableSwaps()
-				if err != nil {
-					if err == ErrSwapRedeemed {
-						continue
-					}
-					errs <- err
-					return
-				}
-				if len(swaps) < 1000 {
-					for i := range swaps {
-						go func(i int) {
-							if err := g.refund(swaps[i]); err != nil {
-								if err == errors.ErrNotInitiated {
-									return
-								}
-								errs <- err
-								return
-							}
-							if g.state.Status(swaps[i]) == swap.StatusRefunded {
-								g.state.DeleteSwap(swaps[i])
-							}
-						}(i)
-					}
-					continue
-				}
-				for i := range swaps[:1000] {
-					go func(i int) {
-						if err := g.refund(swaps[i]); err != nil {
-							if err == errors.ErrNotInitiated {
-								return
-							}
-							errs <- err
-							return
-						}
-						if g.state.Status(swaps[i]) == swap.StatusRefunded {
-							g.state.DeleteSwap(swaps[i])
-						}
-					}(i)
-				}
+			case <-ticker.C:
+			}
+			if err := g.sync(); err != nil {
+				errs <- err
 			}
 		}
 	}()
 	return errs
 }
 
+// sync schedules a refund for every swap that the swapper initiated and has
+// not redeemed, for when its atom expires, and for every complained swap.
+// Refunds that failed with a permanent error are left for an operator to
+// retry, and refunds that are waiting to be retried are scheduled for when
+// they are due.
+func (g *guardian) sync() error {
+	timers, err := g.state.RefundTimers()
+	if err != nil {
+		return err
+	}
+	for _, timer := range timers {
+		if !g.state.IsRedeemable(timer.OrderID) {
+			if err := g.deleteRefundTimer(timer.OrderID); err != nil {
+				return err
+			}
+			continue
+		}
+		g.schedule(timer.OrderID, timer.Expiry)
+	}
+
+	swaps, err := g.state.RefundableSwaps()
+	if err != nil {
+		return err
+	}
+	for _, orderID := range swaps {
+		g.schedule(orderID, g.deadline(orderID))
+	}
+	return nil
+}
+
+// deleteRefundTimer deletes the refund timer of a swap that no longer needs
+// to be refunded.
+func (g *guardian) deleteRefundTimer(orderID [32]byte) error {
+	tx := g.state.NewTransaction()
+	if err := tx.DeleteRefundTimer(orderID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// schedule schedules the refund of the swap for when its atom expires.
+func (g *guardian) schedule(orderID [32]byte, expiry int64) {
+	if g.scheduler.InFlight(orderID) {
+		return
+	}
+	delay := time.Until(time.Unix(expiry, 0))
+	if swapErr, err := g.state.Error(orderID); err == nil && swapErr.Refund {
+		if swapErr.Permanent {
+			return
+		}
+		if retryIn := time.Until(time.Unix(swapErr.RetryAt, 0)); retryIn > delay {
+			delay = retryIn
+		}
+	}
+	g.scheduler.ScheduleAfter(orderID, expiry, delay)
+}
+
+// run settles the swap once it can be settled, which archives it. A refund
+// that fails with a transient error is retried with backoff for as long as
+// it takes, and a claim is retried every minute until the counterparty's atom
+// expires.
+func (g *guardian) run(orderID [32]byte) error {
+	if err := g.settle(orderID); err != nil {
+		switch err.(type) {
+		case scheduler.RetryAfter:
+			return err
+		}
+		switch err {
+		case errors.ErrNotInitiated:
+			return nil
+		case ErrSwapRedeemed:
+			return err
+		}
+		if g.broadcaster.ShuttingDown() {
+			return err
+		}
+		if claimErr, ok := err.(claimError); ok {
+			return g.retry(orderID, claimErr, claimErr.expiry, claimRetryInterval)
+		}
+		return g.retry(orderID, err, g.deadline(orderID), 0)
+	}
+	return nil
+}
+
+// retry records the failed attempt and returns a scheduler.RetryAfter for
+// when it should be tried again, before the deadline and no later than
+// maxDelay if it is positive, unless it failed with a permanent error.
+func (g *guardian) retry(orderID [32]byte, err error, deadline int64, maxDelay time.Duration) error {
+	attempts := 1
+	if swapErr, readErr := g.state.Error(orderID); readErr == nil && swapErr.Refund {
+		attempts = swapErr.Attempts + 1
+	}
+	message := fmt.Sprintf("failed to refund the swap: %v", err)
+	if _, ok := err.(claimError); ok {
+		message = err.Error()
+	}
+	swapErr := store.SwapError{
+		Message:   message,
+		Attempts:  attempts,
+		Permanent: !errors.IsTransient(err),
+		Refund:    true,
+	}
+	delay := g.backoff.Delay(attempts)
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	if !swapErr.Permanent {
+		swapErr.RetryAt = time.Now().Add(delay).Unix()
+	}
+
+	tx := g.state.NewTransaction()
+	if putErr := tx.PutFailedAttempt(orderID, swapErr); putErr != nil {
+		return putErr
+	}
+	if putErr := tx.Commit(); putErr != nil {
+		return putErr
+	}
+	g.publisher.Publish(events.Error(orderID, err))
+
+	if swapErr.Permanent {
+		return err
+	}
+	return scheduler.RetryAfter{
+		Delay:    delay,
+		Deadline: deadline,
+		Err:      err,
+	}
+}
+
+// deadline returns the expiry of the swapper's atom, or zero if it is not
+// known.
+func (g *guardian) deadline(orderID [32]byte) int64 {
+	expiry, _, err := g.state.InitiateDetails(orderID)
+	if err != nil {
+		return 0
+	}
+	return expiry
+}
+
 func (g *guardian) Notify() {
 	g.notifyCh <- struct{}{}
 }
@@ -98,48 +295,253 @@ func (g *guardian) Stop() {
 	g.doneCh <- struct{}{}
 }
 
-func (g *guardian) refund(orderID [32]byte) error {
+func (g *guardian) Drain(timeout time.Duration) bool {
+	g.Stop()
+	return g.scheduler.Drain(timeout)
+}
+
+func (g *guardian) Alive() bool {
+	return atomic.LoadInt32(&g.running) == 1
+}
+
+// settle redeems the counterparty's atom if the counterparty has redeemed the
+// swapper's atom, and otherwise refunds the swapper's atom.
+func (g *guardian) settle(orderID [32]byte) error {
 	if !g.state.Complained(orderID) && !g.state.IsRedeemable(orderID) {
 		return errors.ErrNotInitiated
 	}
 
-	atom, err := g.buildAtom(orderID)
+	personalAtom, foreignAtom, err := g.buildAtoms(orderID)
 	if err != nil {
 		return errors.ErrAtomBuildFailed(err)
 	}
 
-	if err = g.waitForExpiry(orderID); err != nil {
+	claimed, err := g.claim(orderID, personalAtom, foreignAtom)
+	if err != nil || claimed {
 		return err
 	}
+	return g.refund(orderID, personalAtom)
+}
 
-	if err := atom.Refund(); err != nil {
-		return errors.ErrRefundAfterRedeem(err)
+// claim redeems the counterparty's atom with the secret that the counterparty
+// revealed by redeeming the swapper's atom. It returns false if the swapper's
+// atom has not been redeemed, and a claimError if the counterparty's atom
+// could not be redeemed.
+func (g *guardian) claim(orderID [32]byte, personalAtom, foreignAtom swap.Atom) (bool, error) {
+	secret, redeemed, err := personalAtom.RedeemedSecret()
+	if err != nil || !redeemed {
+		return false, err
 	}
-	return nil
-}
 
-func (g *guardian) buildAtom(orderID [32]byte) (swap.Atom, error) {
 	m, err := g.state.Match(orderID)
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	if !g.state.AtomExists(m.ForeignOrderID()) {
+		g.alert(orderID, errors.ErrCounterpartyAtomUnknown)
+		return false, claimError{err: errors.ErrCounterpartyAtomUnknown}
+	}
+
+	expiry, err := foreignAtom.Expiry()
+	if err != nil {
+		return false, claimError{err: err}
 	}
-	atom, _, err := g.builder.BuildAtoms(g.state, m)
-	return atom, err
+	if time.Now().Unix() >= expiry {
+		// The counterparty's atom can still be redeemed until the
+		// counterparty refunds it, so it is worth trying.
+		g.alert(orderID, errors.ErrClaimExpired)
+		if err := g.redeemAtom(orderID, foreignAtom, secret); err != nil {
+			metrics.Claims.WithLabelValues("expired").Inc()
+			return false, claimError{err: fmt.Errorf("%v: %v", errors.ErrClaimExpired, err), expiry: expiry}
+		}
+		return true, nil
+	}
+
+	if err := g.redeemAtom(orderID, foreignAtom, secret); err != nil {
+		return false, claimError{err: err, expiry: expiry}
+	}
+	return true, nil
 }
 
-func (g *guardian) waitForExpiry(orderID [32]byte) error {
+// alert logs that the swapper could lose both sides of the swap.
+func (g *guardian) alert(orderID [32]byte, err error) {
+	log.Println(fmt.Sprintf("[CRITICAL] (%s) the counterparty redeemed the swapper's atom, and the swapper may not be able to redeem theirs: %v", order.Fmt(orderID), err))
+	g.publisher.Publish(events.Error(orderID, err))
+}
+
+// refund refunds the swapper's atom once it has expired and has not been
+// redeemed. An atom that was spent without revealing the secret was refunded
+// by the swapper, and the refund is recorded if it was not. It returns a
+// scheduler.RetryAfter if the atom cannot be refunded yet.
+func (g *guardian) refund(orderID [32]byte, atom swap.Atom) error {
 	expiry, _, err := g.state.InitiateDetails(orderID)
 	if err != nil {
 		return err
 	}
+	timing := timingOf(atom.PriorityCode())
+	if wait := time.Until(time.Unix(expiry, 0).Add(timing.Margin)); wait > 0 {
+		return scheduler.RetryAfter{
+			Delay:    wait,
+			Deadline: expiry,
+		}
+	}
 
-	for {
-		if time.Now().Unix() >= expiry {
-			if g.state.Status(orderID) == swap.StatusRedeemed {
-				return ErrSwapRedeemed
-			}
-			return nil
+	refundable, err := atom.Refundable()
+	if err != nil {
+		return err
+	}
+	if !refundable {
+		if g.state.Status(orderID) == swap.StatusRedeemed {
+			return ErrSwapRedeemed
+		}
+		spent, err := atom.Spent()
+		if err != nil {
+			return err
+		}
+		if spent {
+			return g.refunded(orderID, atom)
+		}
+		return scheduler.RetryAfter{
+			Delay:    timing.Recheck,
+			Deadline: expiry,
 		}
-		time.Sleep(time.Duration(time.Now().Unix()-expiry) * time.Minute)
 	}
+	return g.refundAtom(orderID, atom)
+}
+
+func (g *guardian) Refund(orderID [32]byte) error {
+	return g.scheduler.RunNow(orderID, func() error {
+		return g.refundNow(orderID)
+	})
+}
+
+func (g *guardian) refundNow(orderID [32]byte) error {
+	personalAtom, foreignAtom, err := g.buildAtoms(orderID)
+	if err != nil {
+		return errors.ErrAtomBuildFailed(err)
+	}
+
+	claimed, err := g.claim(orderID, personalAtom, foreignAtom)
+	if err != nil || claimed {
+		return err
+	}
+	return g.refundAtom(orderID, personalAtom)
+}
+
+// redeemAtom redeems the counterparty's atom and persists the redemption,
+// without being interrupted by the swapper shutting down.
+func (g *guardian) redeemAtom(orderID [32]byte, atom swap.Atom, secret [32]byte) error {
+	return g.broadcaster.Broadcast(func() error {
+		return g.sendRedeem(orderID, atom, secret)
+	})
+}
+
+func (g *guardian) sendRedeem(orderID [32]byte, atom swap.Atom, secret [32]byte) error {
+	if err := atom.Redeem(secret); err != nil {
+		metrics.Claims.WithLabelValues("error").Inc()
+		return err
+	}
+	metrics.Claims.WithLabelValues("ok").Inc()
+
+	txs, err := g.state.Transactions(orderID)
+	if err != nil {
+		return err
+	}
+
+	tx := g.state.NewTransaction()
+	if err := tx.PutRedeemDetails(orderID, secret); err != nil {
+		return err
+	}
+
+	if err := tx.Redeemed(orderID); err != nil {
+		return err
+	}
+
+	txs = swapDomain.Transactions{Redeem: atom.Transactions().Redeem}.Merge(txs)
+	if err := tx.PutTransactions(orderID, txs); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, swap.StatusRedeemed); err != nil {
+		return err
+	}
+
+	if err := g.settled(tx, orderID, swap.StatusRedeemed); err != nil {
+		return err
+	}
+	g.publisher.Publish(events.Status(orderID, swap.StatusRedeemed))
+	g.publisher.Publish(events.Transaction(orderID, "redeem", txs.Redeem))
+	return nil
+}
+
+// refundAtom refunds the atom and persists the refund, without being
+// interrupted by the swapper shutting down.
+func (g *guardian) refundAtom(orderID [32]byte, atom swap.Atom) error {
+	return g.broadcaster.Broadcast(func() error {
+		return g.sendRefund(orderID, atom)
+	})
+}
+
+func (g *guardian) sendRefund(orderID [32]byte, atom swap.Atom) error {
+	if err := atom.Refund(); err != nil {
+		metrics.Refunds.WithLabelValues("error").Inc()
+		return errors.ErrRefundAfterRedeem(err)
+	}
+	metrics.Refunds.WithLabelValues("ok").Inc()
+	return g.refunded(orderID, atom)
+}
+
+// refunded persists the refund of the atom.
+func (g *guardian) refunded(orderID [32]byte, atom swap.Atom) error {
+	txs, err := g.state.Transactions(orderID)
+	if err != nil {
+		return err
+	}
+
+	tx := g.state.NewTransaction()
+	if err := tx.Redeemed(orderID); err != nil {
+		return err
+	}
+
+	txs = atom.Transactions().Merge(txs)
+	if err := tx.PutTransactions(orderID, txs); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, swap.StatusRefunded); err != nil {
+		return err
+	}
+
+	if err := g.settled(tx, orderID, swap.StatusRefunded); err != nil {
+		return err
+	}
+	g.publisher.Publish(events.Status(orderID, swap.StatusRefunded))
+	g.publisher.Publish(events.Transaction(orderID, "refund", txs.Refund))
+	return nil
+}
+
+// settled clears the error of the settled swap and archives it along with
+// the other updates in the transaction, and commits it.
+func (g *guardian) settled(tx store.Transaction, orderID [32]byte, status string) error {
+	if err := tx.ClearError(orderID); err != nil {
+		return err
+	}
+
+	if err := tx.ArchiveSwap(orderID, status); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	metrics.SwapsFinished.WithLabelValues(status).Inc()
+	return nil
+}
+
+func (g *guardian) buildAtoms(orderID [32]byte) (swap.Atom, swap.Atom, error) {
+	m, err := g.state.Match(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.builder.BuildAtoms(g.state, m)
 }
@@ -0,0 +1,13 @@
+package guardian_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestGuardian(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Guardian Suite")
+}
@@ -0,0 +1,251 @@
+package guardian_test
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	. "github.com/republicprotocol/renex-swapper-go/services/guardian"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/swap"
+)
+
+// mockAtom is an atom that was redeemed with the secret if it is set, and
+// records whether it was redeemed or refunded by the swapper.
+type mockAtom struct {
+	mu       *sync.Mutex
+	code     uint32
+	expiry   int64
+	secret   *[32]byte
+	redeemed *[32]byte
+	refunded bool
+}
+
+func (atom *mockAtom) Initiate(to []byte, hash [32]byte, value *big.Int, expiry int64) error {
+	return nil
+}
+
+func (atom *mockAtom) Refund() error {
+	atom.mu.Lock()
+	defer atom.mu.Unlock()
+	atom.refunded = true
+	return nil
+}
+
+func (atom *mockAtom) Refunded() bool {
+	atom.mu.Lock()
+	defer atom.mu.Unlock()
+	return atom.refunded
+}
+
+func (atom *mockAtom) Refundable() (bool, error) {
+	return !atom.Refunded(), nil
+}
+
+func (atom *mockAtom) RedeemedSecret() ([32]byte, bool, error) {
+	if atom.secret == nil {
+		return [32]byte{}, false, nil
+	}
+	return *atom.secret, true, nil
+}
+
+func (atom *mockAtom) Spent() (bool, error) {
+	return atom.Refunded(), nil
+}
+
+func (atom *mockAtom) Expiry() (int64, error) {
+	return atom.expiry, nil
+}
+
+func (atom *mockAtom) AuditSecret() ([32]byte, error) {
+	return [32]byte{}, nil
+}
+
+func (atom *mockAtom) Redeem(secret [32]byte) error {
+	atom.mu.Lock()
+	defer atom.mu.Unlock()
+	atom.redeemed = &secret
+	return nil
+}
+
+func (atom *mockAtom) Redeemed() *[32]byte {
+	atom.mu.Lock()
+	defer atom.mu.Unlock()
+	return atom.redeemed
+}
+
+func (atom *mockAtom) Audit() ([32]byte, []byte, *big.Int, int64, error) {
+	return [32]byte{}, nil, nil, 0, nil
+}
+
+func (atom *mockAtom) WaitForCounterRedemption() error {
+	return nil
+}
+
+func (atom *mockAtom) Serialize() ([]byte, error) {
+	return nil, nil
+}
+
+func (atom *mockAtom) Deserialize([]byte) error {
+	return nil
+}
+
+func (atom *mockAtom) GetFromAddress() ([]byte, error) {
+	return nil, nil
+}
+
+func (atom *mockAtom) PriorityCode() uint32 {
+	return atom.code
+}
+
+func (atom *mockAtom) RedeemedAt() (int64, error) {
+	return 0, nil
+}
+
+func (atom *mockAtom) Transactions() swapDomain.Transactions {
+	if atom.Refunded() {
+		return swapDomain.Transactions{Refund: "0x01"}
+	}
+	return swapDomain.Transactions{}
+}
+
+type mockBuilder struct {
+	personalAtom *mockAtom
+	foreignAtom  *mockAtom
+}
+
+func (builder *mockBuilder) BuildAtoms(state store.State, m match.Match) (swap.Atom, swap.Atom, error) {
+	return builder.personalAtom, builder.foreignAtom, nil
+}
+
+var _ = Describe("Guardian", func() {
+	var state store.State
+	var builder *mockBuilder
+	var guardian Guardian
+
+	orderID := [32]byte{1}
+	staleOrderID := [32]byte{2}
+
+	BeforeEach(func() {
+		state = store.NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		builder = &mockBuilder{
+			personalAtom: &mockAtom{mu: new(sync.Mutex), code: cc.ETHEREUMCC},
+			foreignAtom:  &mockAtom{mu: new(sync.Mutex), code: cc.BITCOINCC},
+		}
+		guardian = NewGuardian(builder, state, events.NewBroker(0), shutdown.NewShutdown(), 0)
+
+		expiry := time.Now().Add(time.Hour).Unix()
+		m := match.NewMatch(orderID, [32]byte{3}, big.NewInt(1), big.NewInt(1), cc.ETHEREUMCC, cc.BITCOINCC)
+		tx := state.NewTransaction()
+		Expect(tx.PutMatch(orderID, m)).ShouldNot(HaveOccurred())
+		Expect(tx.PutInitiateDetails(orderID, expiry, [32]byte{4})).ShouldNot(HaveOccurred())
+		Expect(tx.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, swap.StatusInitiated)).ShouldNot(HaveOccurred())
+		Expect(tx.PutRefundTimer(orderID, expiry)).ShouldNot(HaveOccurred())
+		// The guardian deletes the timer of a swap that is not redeemable,
+		// after scheduling the refunds of the timers that expire before it.
+		Expect(tx.PutRefundTimer(staleOrderID, expiry+1)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(guardian.Drain(time.Second)).Should(BeTrue())
+	})
+
+	It("refunds a swap that is waiting for its atom to expire straight away", func() {
+		go func() {
+			for range guardian.Start() {
+			}
+		}()
+		guardian.Notify()
+		Eventually(state.RefundTimers).Should(HaveLen(1))
+		Expect(builder.personalAtom.Refunded()).Should(BeFalse())
+
+		Expect(guardian.Refund(orderID)).Should(Succeed())
+		Expect(builder.personalAtom.Refunded()).Should(BeTrue())
+		summary, err := state.ArchivedSwap(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summary.Outcome).Should(Equal(swap.StatusRefunded))
+	})
+
+	It("redeems the counterparty's atom instead of refunding a redeemed atom", func() {
+		secret := [32]byte{5}
+		builder.personalAtom.secret = &secret
+		builder.foreignAtom.expiry = time.Now().Add(time.Hour).Unix()
+		Expect(state.PutAtomDetails([32]byte{3}, []byte("details"))).ShouldNot(HaveOccurred())
+
+		Expect(guardian.Refund(orderID)).Should(Succeed())
+		Expect(builder.personalAtom.Refunded()).Should(BeFalse())
+		Expect(builder.foreignAtom.Redeemed()).Should(Equal(&secret))
+		summary, err := state.ArchivedSwap(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summary.Outcome).Should(Equal(swap.StatusRedeemed))
+	})
+
+	It("redeems the counterparty's atom once the swapper's atom expires", func() {
+		secret := [32]byte{5}
+		builder.personalAtom.secret = &secret
+		builder.foreignAtom.expiry = time.Now().Add(time.Hour).Unix()
+		Expect(state.PutAtomDetails([32]byte{3}, []byte("details"))).ShouldNot(HaveOccurred())
+		expiry := time.Now().Add(-time.Hour).Unix()
+		tx := state.NewTransaction()
+		Expect(tx.PutInitiateDetails(orderID, expiry, [32]byte{4})).ShouldNot(HaveOccurred())
+		Expect(tx.PutRefundTimer(orderID, expiry)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		go func() {
+			for range guardian.Start() {
+			}
+		}()
+		guardian.Notify()
+		Eventually(builder.foreignAtom.Redeemed).Should(Equal(&secret))
+		Eventually(func() error {
+			_, err := state.ArchivedSwap(orderID)
+			return err
+		}).ShouldNot(HaveOccurred())
+		Expect(builder.personalAtom.Refunded()).Should(BeFalse())
+	})
+
+	It("does not refund a redeemed atom when the counterparty's atom is unknown", func() {
+		secret := [32]byte{5}
+		builder.personalAtom.secret = &secret
+
+		Expect(guardian.Refund(orderID)).ShouldNot(Succeed())
+		Expect(builder.personalAtom.Refunded()).Should(BeFalse())
+		Expect(builder.foreignAtom.Redeemed()).Should(BeNil())
+		_, err := state.ArchivedSwap(orderID)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("records a refund that was broadcast but not recorded", func() {
+		expiry := time.Now().Add(-time.Hour).Unix()
+		tx := state.NewTransaction()
+		Expect(tx.PutInitiateDetails(orderID, expiry, [32]byte{4})).ShouldNot(HaveOccurred())
+		Expect(tx.PutRefundTimer(orderID, expiry)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+		Expect(builder.personalAtom.Refund()).Should(Succeed())
+
+		go func() {
+			for range guardian.Start() {
+			}
+		}()
+		guardian.Notify()
+		Eventually(func() error {
+			_, err := state.ArchivedSwap(orderID)
+			return err
+		}).ShouldNot(HaveOccurred())
+		summary, err := state.ArchivedSwap(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summary.Outcome).Should(Equal(swap.StatusRefunded))
+	})
+})
@@ -0,0 +1,289 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+// Names of the checks.
+const (
+	CheckBitcoin  = "bitcoin"
+	CheckEthereum = "ethereum"
+	CheckWatchdog = "watchdog"
+	CheckStore    = "store"
+	CheckKeystore = "keystore"
+	CheckWatcher  = "watcher"
+	CheckGuardian = "guardian"
+)
+
+// DefaultMaxBlockAge is how old the latest Ethereum block can be before the
+// node is considered to be behind.
+const DefaultMaxBlockAge = 5 * time.Minute
+
+// cacheTTL is how long a report is reused for, so that posting orders does
+// not call every dependency.
+const cacheTTL = 10 * time.Second
+
+// checkTimeout is how long a check can take before it fails.
+const checkTimeout = 10 * time.Second
+
+var ErrTimeout = errors.New("check timed out")
+var ErrNotRunning = errors.New("not running")
+
+// healthKey is written to and deleted from the store to check that it is
+// writable.
+var healthKey = []byte("health:check")
+
+// BitcoinInfo is the state of the Bitcoin node.
+type BitcoinInfo struct {
+	Blocks               int64
+	Headers              int64
+	InitialBlockDownload bool
+}
+
+// EthereumInfo is the state of the Ethereum node. LatestBlockTime is the Unix
+// time of the latest block that the node has.
+type EthereumInfo struct {
+	Syncing         bool
+	CurrentBlock    uint64
+	HighestBlock    uint64
+	LatestBlock     uint64
+	LatestBlockTime int64
+}
+
+// Adapter reaches the dependencies of the swapper that are outside of it.
+type Adapter interface {
+	BitcoinInfo() (BitcoinInfo, error)
+	EthereumInfo() (EthereumInfo, error)
+
+	// PingWatchdog checks that the watchdog can be reached.
+	PingWatchdog() error
+
+	// UnlockKeys checks that the keys used to sign transactions can be
+	// read.
+	UnlockKeys() error
+}
+
+// Runner is a loop of the swapper that runs in the background.
+type Runner interface {
+	Alive() bool
+}
+
+// Check is the result of checking one dependency.
+type Check struct {
+	Name    string                 `json:"name"`
+	Healthy bool                   `json:"healthy"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the result of checking every dependency. The swapper is live if
+// the parts of it that restarting could fix are healthy, and ready to take
+// orders if every dependency is healthy.
+type Report struct {
+	Live   bool    `json:"live"`
+	Ready  bool    `json:"ready"`
+	Checks []Check `json:"checks"`
+	Time   int64   `json:"time"`
+}
+
+// Checker checks the health of the swapper.
+type Checker interface {
+	// Check returns a report that is at most a few seconds old.
+	Check() Report
+}
+
+type checker struct {
+	adapter     Adapter
+	store       store.Store
+	watcher     Runner
+	guardian    Runner
+	maxBlockAge time.Duration
+
+	mu     *sync.Mutex
+	report Report
+}
+
+// NewChecker returns a Checker of the dependencies of the swapper. The
+// Ethereum node is behind if its latest block is older than maxBlockAge, or
+// DefaultMaxBlockAge if it is zero.
+func NewChecker(adapter Adapter, db store.Store, watcher, guardian Runner, maxBlockAge time.Duration) Checker {
+	if maxBlockAge == 0 {
+		maxBlockAge = DefaultMaxBlockAge
+	}
+	return &checker{
+		adapter:     adapter,
+		store:       db,
+		watcher:     watcher,
+		guardian:    guardian,
+		maxBlockAge: maxBlockAge,
+		mu:          new(sync.Mutex),
+	}
+}
+
+func (checker *checker) Check() Report {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(time.Unix(checker.report.Time, 0)) < cacheTTL {
+		return checker.report
+	}
+
+	names := []string{CheckBitcoin, CheckEthereum, CheckWatchdog, CheckStore, CheckKeystore, CheckWatcher, CheckGuardian}
+	checks := map[string]func() Check{
+		CheckBitcoin:  checker.checkBitcoin,
+		CheckEthereum: checker.checkEthereum,
+		CheckWatchdog: checker.checkWatchdog,
+		CheckStore:    checker.checkStore,
+		CheckKeystore: checker.checkKeystore,
+		CheckWatcher:  func() Check { return checkRunner(CheckWatcher, checker.watcher) },
+		CheckGuardian: func() Check { return checkRunner(CheckGuardian, checker.guardian) },
+	}
+
+	report := Report{
+		Live:   true,
+		Ready:  true,
+		Checks: make([]Check, len(names)),
+		Time:   now.Unix(),
+	}
+	wg := new(sync.WaitGroup)
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			report.Checks[i] = withTimeout(name, checks[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Healthy {
+			continue
+		}
+		report.Ready = false
+		if internal(check.Name) {
+			report.Live = false
+		}
+	}
+	checker.report = report
+	return report
+}
+
+func (checker *checker) checkBitcoin() Check {
+	info, err := checker.adapter.BitcoinInfo()
+	if err != nil {
+		return failed(CheckBitcoin, err)
+	}
+	check := Check{
+		Name:    CheckBitcoin,
+		Healthy: true,
+		Details: map[string]interface{}{
+			"blocks":               info.Blocks,
+			"headers":              info.Headers,
+			"initialBlockDownload": info.InitialBlockDownload,
+		},
+	}
+	if info.InitialBlockDownload {
+		check.Healthy = false
+		check.Error = "node is in its initial block download"
+	}
+	return check
+}
+
+func (checker *checker) checkEthereum() Check {
+	info, err := checker.adapter.EthereumInfo()
+	if err != nil {
+		return failed(CheckEthereum, err)
+	}
+	age := time.Since(time.Unix(info.LatestBlockTime, 0))
+	check := Check{
+		Name:    CheckEthereum,
+		Healthy: true,
+		Details: map[string]interface{}{
+			"syncing":            info.Syncing,
+			"latestBlock":        info.LatestBlock,
+			"latestBlockAgeSecs": int64(age.Seconds()),
+		},
+	}
+	if info.Syncing {
+		check.Details["currentBlock"] = info.CurrentBlock
+		check.Details["highestBlock"] = info.HighestBlock
+	}
+	switch {
+	case info.Syncing:
+		check.Healthy = false
+		check.Error = "node is syncing"
+	case age > checker.maxBlockAge:
+		check.Healthy = false
+		check.Error = fmt.Sprintf("latest block is %d seconds old", int64(age.Seconds()))
+	}
+	return check
+}
+
+func (checker *checker) checkWatchdog() Check {
+	if err := checker.adapter.PingWatchdog(); err != nil {
+		return failed(CheckWatchdog, err)
+	}
+	return Check{Name: CheckWatchdog, Healthy: true}
+}
+
+func (checker *checker) checkStore() Check {
+	if err := checker.store.Write(healthKey, []byte(fmt.Sprintf("%d", time.Now().Unix()))); err != nil {
+		return failed(CheckStore, err)
+	}
+	if err := checker.store.Delete(healthKey); err != nil {
+		return failed(CheckStore, err)
+	}
+	return Check{Name: CheckStore, Healthy: true}
+}
+
+func (checker *checker) checkKeystore() Check {
+	if err := checker.adapter.UnlockKeys(); err != nil {
+		return failed(CheckKeystore, err)
+	}
+	return Check{Name: CheckKeystore, Healthy: true}
+}
+
+func checkRunner(name string, runner Runner) Check {
+	if !runner.Alive() {
+		return failed(name, ErrNotRunning)
+	}
+	return Check{Name: name, Healthy: true}
+}
+
+// withTimeout runs the check, failing it if it does not return in time. The
+// check is left to finish in the background.
+func withTimeout(name string, check func() Check) Check {
+	done := make(chan Check, 1)
+	go func() {
+		done <- check()
+	}()
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(checkTimeout):
+		return failed(name, ErrTimeout)
+	}
+}
+
+// internal returns true if the check is of a part of the swapper, rather than
+// a node or service that it depends on.
+func internal(name string) bool {
+	switch name {
+	case CheckStore, CheckKeystore, CheckWatcher, CheckGuardian:
+		return true
+	}
+	return false
+}
+
+func failed(name string, err error) Check {
+	return Check{
+		Name:  name,
+		Error: err.Error(),
+	}
+}
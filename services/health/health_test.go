@@ -0,0 +1,129 @@
+package health_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	. "github.com/republicprotocol/renex-swapper-go/services/health"
+)
+
+type mockAdapter struct {
+	bitcoin     BitcoinInfo
+	ethereum    EthereumInfo
+	bitcoinErr  error
+	watchdogErr error
+	keysErr     error
+	calls       int
+}
+
+func (adapter *mockAdapter) BitcoinInfo() (BitcoinInfo, error) {
+	adapter.calls++
+	return adapter.bitcoin, adapter.bitcoinErr
+}
+
+func (adapter *mockAdapter) EthereumInfo() (EthereumInfo, error) {
+	return adapter.ethereum, nil
+}
+
+func (adapter *mockAdapter) PingWatchdog() error {
+	return adapter.watchdogErr
+}
+
+func (adapter *mockAdapter) UnlockKeys() error {
+	return adapter.keysErr
+}
+
+type mockRunner bool
+
+func (runner mockRunner) Alive() bool {
+	return bool(runner)
+}
+
+var _ = Describe("Health", func() {
+	var adapter *mockAdapter
+
+	check := func(report Report, name string) Check {
+		for _, check := range report.Checks {
+			if check.Name == name {
+				return check
+			}
+		}
+		Fail("missing check " + name)
+		return Check{}
+	}
+
+	BeforeEach(func() {
+		adapter = &mockAdapter{
+			bitcoin: BitcoinInfo{
+				Blocks:  100,
+				Headers: 100,
+			},
+			ethereum: EthereumInfo{
+				LatestBlock:     200,
+				LatestBlockTime: time.Now().Unix(),
+			},
+		}
+	})
+
+	It("is ready when every dependency is healthy", func() {
+		report := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(true), DefaultMaxBlockAge).Check()
+		Expect(report.Ready).Should(BeTrue())
+		Expect(report.Live).Should(BeTrue())
+		Expect(report.Checks).Should(HaveLen(7))
+		Expect(check(report, CheckBitcoin).Details["blocks"]).Should(Equal(int64(100)))
+	})
+
+	It("is not ready while the bitcoin node is in its initial block download", func() {
+		adapter.bitcoin.InitialBlockDownload = true
+		report := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(true), DefaultMaxBlockAge).Check()
+		Expect(report.Ready).Should(BeFalse())
+		Expect(report.Live).Should(BeTrue())
+		Expect(check(report, CheckBitcoin).Healthy).Should(BeFalse())
+	})
+
+	It("is not ready when the bitcoin node cannot be reached", func() {
+		adapter.bitcoinErr = errors.New("connection refused")
+		report := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(true), DefaultMaxBlockAge).Check()
+		Expect(report.Ready).Should(BeFalse())
+		Expect(check(report, CheckBitcoin).Error).Should(Equal("connection refused"))
+	})
+
+	It("is not ready when the ethereum node is behind", func() {
+		adapter.ethereum.LatestBlockTime = time.Now().Add(-time.Hour).Unix()
+		report := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(true), DefaultMaxBlockAge).Check()
+		Expect(report.Ready).Should(BeFalse())
+		Expect(check(report, CheckEthereum).Healthy).Should(BeFalse())
+		Expect(check(report, CheckBitcoin).Healthy).Should(BeTrue())
+	})
+
+	It("is not ready when the watchdog cannot be reached", func() {
+		adapter.watchdogErr = errors.New("timeout")
+		report := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(true), DefaultMaxBlockAge).Check()
+		Expect(report.Ready).Should(BeFalse())
+		Expect(report.Live).Should(BeTrue())
+	})
+
+	It("is not live when the guardian has stopped", func() {
+		report := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(false), DefaultMaxBlockAge).Check()
+		Expect(report.Ready).Should(BeFalse())
+		Expect(report.Live).Should(BeFalse())
+		Expect(check(report, CheckGuardian).Error).Should(Equal(ErrNotRunning.Error()))
+	})
+
+	It("is not live when the keys cannot be read", func() {
+		adapter.keysErr = errors.New("locked")
+		report := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(true), DefaultMaxBlockAge).Check()
+		Expect(report.Live).Should(BeFalse())
+	})
+
+	It("reuses recent reports", func() {
+		checker := NewChecker(adapter, memory.NewMemoryStore(), mockRunner(true), mockRunner(true), DefaultMaxBlockAge)
+		checker.Check()
+		checker.Check()
+		Expect(adapter.calls).Should(Equal(1))
+	})
+})
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"encoding/hex"
+	"log"
+	"math/big"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/wallet"
+)
+
+// Nonces reports the transactions that the swapper has sent to Ethereum that
+// have not been mined yet.
+type Nonces interface {
+	PendingNonces() (uint64, error)
+}
+
+var (
+	swapsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "swaps"),
+		"Pending swaps, by status and role.",
+		[]string{"status", "role"}, nil,
+	)
+	expiryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "swap_expiry_timestamp_seconds"),
+		"Unix time that the swapper's side of a pending swap expires at, alert when it gets close.",
+		[]string{"order_id", "status"}, nil,
+	)
+	balanceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "wallet_balance"),
+		"Funds held by the swapper in the base unit of the currency, by kind of balance.",
+		[]string{"currency", "kind"}, nil,
+	)
+	noncesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "ethereum_pending_nonces"),
+		"Transactions sent by the swapper to Ethereum that have not been mined.",
+		nil, nil,
+	)
+)
+
+type collector struct {
+	state  store.State
+	wallet wallet.Wallet
+	nonces Nonces
+}
+
+// NewCollector returns a prometheus.Collector that reads the pending swaps,
+// balances and nonces of the swapper when it is scraped.
+func NewCollector(state store.State, wallet wallet.Wallet, nonces Nonces) prometheus.Collector {
+	return &collector{
+		state:  state,
+		wallet: wallet,
+		nonces: nonces,
+	}
+}
+
+func (collector *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- swapsDesc
+	ch <- expiryDesc
+	ch <- balanceDesc
+	ch <- noncesDesc
+}
+
+func (collector *collector) Collect(ch chan<- prometheus.Metric) {
+	collector.collectSwaps(ch)
+	collector.collectBalances(ch)
+
+	pending, err := collector.nonces.PendingNonces()
+	if err != nil {
+		log.Println("Failed to get the pending ethereum nonces:", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(noncesDesc, prometheus.GaugeValue, float64(pending))
+}
+
+func (collector *collector) collectSwaps(ch chan<- prometheus.Metric) {
+	swaps, err := collector.state.PendingSwaps()
+	if err != nil {
+		log.Println("Failed to get the pending swaps:", err)
+		return
+	}
+
+	type key struct{ status, role string }
+	counts := map[key]int{}
+	for _, swap := range swaps {
+		counts[key{swap.Status, swap.Role}]++
+
+		// Swaps only have an expiry once their details have been generated
+		// or audited
+		expiry, _, err := collector.state.InitiateDetails(swap.OrderID)
+		if err != nil || expiry == 0 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(expiryDesc, prometheus.GaugeValue, float64(expiry), hex.EncodeToString(swap.OrderID[:]), swap.Status)
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(swapsDesc, prometheus.GaugeValue, float64(count), k.status, k.role)
+	}
+}
+
+func (collector *collector) collectBalances(ch chan<- prometheus.Metric) {
+	for _, currency := range []uint32{cc.BITCOINCC, cc.ETHEREUMCC} {
+		balance, err := collector.wallet.Balance(currency)
+		if err != nil {
+			log.Println("Failed to get the balance of", cc.Name(currency), err)
+			continue
+		}
+		name := cc.Name(currency)
+		for kind, amount := range map[string]float64{
+			"confirmed":   toFloat(balance.Confirmed),
+			"unconfirmed": toFloat(balance.Unconfirmed),
+			"locked":      toFloat(balance.Locked),
+			"available":   toFloat(balance.Available),
+		} {
+			ch <- prometheus.MustNewConstMetric(balanceDesc, prometheus.GaugeValue, amount, name, kind)
+		}
+	}
+}
+
+func toFloat(amount *big.Int) float64 {
+	if amount == nil {
+		return 0
+	}
+	f, _ := new(big.Float).SetInt(amount).Float64()
+	return f
+}
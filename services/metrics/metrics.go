@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "swapper"
+
+// Queues whose depth is reported.
+const (
+	QueueWatch    = "watch"
+	QueueGuardian = "guardian"
+	QueueWatchdog = "watchdog"
+)
+
+// Chains that RPC calls are made to.
+const (
+	ChainBitcoin  = "bitcoin"
+	ChainEthereum = "ethereum"
+)
+
+var (
+	// StepDuration is how long each step of a swap took. Steps that wait for
+	// the counterparty can take hours.
+	StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "swap_step_duration_seconds",
+		Help:      "Time taken by each step of a swap.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"step", "result"})
+
+	// SwapsFinished counts the swaps that have been archived, by their final
+	// status.
+	SwapsFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "swaps_finished_total",
+		Help:      "Swaps that have finished, by final status.",
+	}, []string{"status"})
+
+	// Complaints counts the complaints filed with the watchdog.
+	Complaints = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "complaints_total",
+		Help:      "Complaints filed with the watchdog.",
+	})
+
+	// ComplaintDeliveries counts the attempts to deliver complaints to the
+	// watchdog.
+	ComplaintDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "complaint_deliveries_total",
+		Help:      "Attempts to deliver complaints to the watchdog.",
+	}, []string{"result"})
+
+	// Refunds counts the refunds that the swapper has attempted.
+	Refunds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunds_total",
+		Help:      "Refunds attempted by the swapper.",
+	}, []string{"result"})
+
+	// Claims counts the counterparty's atoms that the guardian has tried to
+	// redeem after the counterparty redeemed the swapper's atom.
+	Claims = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "claims_total",
+		Help:      "Counterparty atoms the guardian tried to redeem instead of refunding.",
+	}, []string{"result"})
+
+	// RPCDuration is the latency of calls to the blockchain nodes.
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Latency of calls to the blockchain nodes.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"chain", "method"})
+
+	// RPCErrors counts the calls to the blockchain nodes that failed.
+	RPCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Calls to the blockchain nodes that failed.",
+	}, []string{"chain", "method"})
+
+	// QueueDepth is the number of swaps that the watcher and guardian, and
+	// complaints that the watchdog outbox, have queued or are working on.
+	QueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_depth",
+		Help:      "Swaps queued or being worked on by the watcher and guardian, and complaints waiting for the watchdog.",
+	}, []string{"queue"})
+)
+
+func init() {
+	prometheus.MustRegister(StepDuration, SwapsFinished, Complaints, ComplaintDeliveries, Refunds, Claims, RPCDuration, RPCErrors, QueueDepth)
+}
+
+// ObserveStep records how long a step of a swap took since it started, and
+// whether it failed.
+func ObserveStep(step string, start time.Time, err error) {
+	StepDuration.WithLabelValues(step, result(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveRPC records how long a call to a blockchain node took since it
+// started, and whether it failed.
+func ObserveRPC(chain, method string, start time.Time, err error) {
+	RPCDuration.WithLabelValues(chain, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		RPCErrors.WithLabelValues(chain, method).Inc()
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,108 @@
+package metrics_test
+
+import (
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	. "github.com/republicprotocol/renex-swapper-go/services/metrics"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/wallet"
+)
+
+// mockWallet holds the same balance of every currency.
+type mockWallet struct {
+	wallet.Wallet
+}
+
+func (mockWallet) Balance(currency uint32) (wallet.Balance, error) {
+	return wallet.Balance{
+		Currency:    currency,
+		Confirmed:   big.NewInt(5),
+		Unconfirmed: big.NewInt(1),
+		Locked:      big.NewInt(3),
+		Available:   big.NewInt(2),
+	}, nil
+}
+
+type mockNonces struct{}
+
+func (mockNonces) PendingNonces() (uint64, error) {
+	return 4, nil
+}
+
+var _ = Describe("Metrics", func() {
+	var state store.State
+
+	orderID := [32]byte{1}
+
+	// scrape returns the metrics that /metrics exposes, including the
+	// collector of the swapper's state.
+	scrape := func() string {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(NewCollector(state, mockWallet{}, mockNonces{}))
+		handler := promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, registry}, promhttp.HandlerOpts{})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		return w.Body.String()
+	}
+
+	BeforeEach(func() {
+		state = store.NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		tx := state.NewTransaction()
+		Expect(tx.PutMatch(orderID, match.NewMatch(orderID, [32]byte{2}, big.NewInt(1), big.NewInt(1), cc.BITCOINCC, cc.ETHEREUMCC))).ShouldNot(HaveOccurred())
+		Expect(tx.PutInitiateDetails(orderID, 1234, [32]byte{3})).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, swap.StatusInitiated)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+	})
+
+	It("exposes the pending swaps by status and role", func() {
+		metrics := scrape()
+		Expect(metrics).Should(ContainSubstring(`swapper_swaps{role="REQUESTOR",status="INITIATED"} 1`))
+		Expect(metrics).Should(ContainSubstring(`swapper_swap_expiry_timestamp_seconds{order_id="0100000000000000000000000000000000000000000000000000000000000000",status="INITIATED"} 1234`))
+	})
+
+	It("exposes the balances and pending nonces", func() {
+		metrics := scrape()
+		for _, currency := range []string{"BTC", "ETH"} {
+			Expect(metrics).Should(ContainSubstring(`swapper_wallet_balance{currency="` + currency + `",kind="confirmed"} 5`))
+			Expect(metrics).Should(ContainSubstring(`swapper_wallet_balance{currency="` + currency + `",kind="unconfirmed"} 1`))
+			Expect(metrics).Should(ContainSubstring(`swapper_wallet_balance{currency="` + currency + `",kind="locked"} 3`))
+			Expect(metrics).Should(ContainSubstring(`swapper_wallet_balance{currency="` + currency + `",kind="available"} 2`))
+		}
+		Expect(metrics).Should(ContainSubstring(`swapper_ethereum_pending_nonces 4`))
+	})
+
+	It("exposes the steps, finished swaps and RPC calls by chain", func() {
+		ObserveStep("get_match", time.Now(), nil)
+		ObserveStep("submit_address", time.Now(), errors.New("connection refused"))
+		SwapsFinished.WithLabelValues(swap.StatusRedeemed).Inc()
+		ObserveRPC(ChainBitcoin, "getblockcount", time.Now(), nil)
+		ObserveRPC(ChainEthereum, "eth_call", time.Now(), errors.New("connection refused"))
+		QueueDepth.WithLabelValues(QueueWatch).Set(3)
+
+		metrics := scrape()
+		Expect(metrics).Should(ContainSubstring(`swapper_swap_step_duration_seconds_count{result="ok",step="get_match"} 1`))
+		Expect(metrics).Should(ContainSubstring(`swapper_swap_step_duration_seconds_count{result="error",step="submit_address"} 1`))
+		Expect(metrics).Should(ContainSubstring(`swapper_swaps_finished_total{status="REDEEMED"} 1`))
+		Expect(metrics).Should(ContainSubstring(`swapper_rpc_duration_seconds_count{chain="bitcoin",method="getblockcount"} 1`))
+		Expect(metrics).Should(ContainSubstring(`swapper_rpc_duration_seconds_count{chain="ethereum",method="eth_call"} 1`))
+		Expect(metrics).Should(ContainSubstring(`swapper_rpc_errors_total{chain="ethereum",method="eth_call"} 1`))
+		Expect(metrics).ShouldNot(ContainSubstring(`swapper_rpc_errors_total{chain="bitcoin"`))
+		Expect(metrics).Should(ContainSubstring(`swapper_queue_depth{queue="watch"} 3`))
+		Expect(metrics).Should(ContainSubstring(`# TYPE swapper_complaints_total counter`))
+	})
+})
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff is how long to wait before retrying an order that failed. The
+// delay doubles with every attempt, from Initial up to Max, and is randomised
+// by up to Jitter of itself so that orders that failed together are not
+// retried together. An order that has failed MaxAttempts times in a row is
+// given up on, if it is safe to do so.
+type Backoff struct {
+	Initial     time.Duration
+	Max         time.Duration
+	Jitter      float64
+	MaxAttempts int
+}
+
+// DefaultBackoff retries after about 30 seconds at first, and at least every
+// 30 minutes.
+var DefaultBackoff = Backoff{
+	Initial:     30 * time.Second,
+	Max:         30 * time.Minute,
+	Jitter:      0.2,
+	MaxAttempts: 20,
+}
+
+// Delay returns how long to wait before the attempt, the first attempt after
+// a failure being 1.
+func (backoff Backoff) Delay(attempt int) time.Duration {
+	delay := backoff.Max
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt < 32 {
+		if d := backoff.Initial << uint(attempt-1); d > 0 && d < backoff.Max {
+			delay = d
+		}
+	}
+	jitter := (rand.Float64()*2 - 1) * backoff.Jitter * float64(delay)
+	return delay + time.Duration(jitter)
+}
@@ -0,0 +1,433 @@
+package scheduler
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
+)
+
+// DefaultConcurrency is the number of orders that are run at the same time
+// when no concurrency is configured.
+const DefaultConcurrency = 100
+
+// drainPollInterval is how often Drain checks whether the running orders have
+// finished.
+const drainPollInterval = 100 * time.Millisecond
+
+// ErrInFlight is returned when running an order that is already queued or
+// running.
+var ErrInFlight = errors.New("swap is already queued or running")
+
+// Job runs an order.
+type Job func(orderID [32]byte) error
+
+// RetryAfter is returned by a job to run the order again after the delay,
+// before the deadline. Err is the error that the job failed with, if it
+// failed, and is sent on the error channel instead of the RetryAfter.
+type RetryAfter struct {
+	Delay    time.Duration
+	Deadline int64
+	Err      error
+}
+
+func (err RetryAfter) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("retrying in %v", err.Delay)
+	}
+	return fmt.Sprintf("%v, retrying in %v", err.Err, err.Delay)
+}
+
+// Scheduler runs jobs for orders in the background, with at most a fixed
+// number of them running at the same time. An order is only ever queued or
+// run once at a time, and the orders that are waiting to run are queued in
+// order of their deadlines, the most urgent first.
+type Scheduler interface {
+	// Start runs the queued orders until Stop is called, sending the errors
+	// returned by the job on the channel.
+	Start(errs chan<- error)
+	Stop()
+
+	// Drain stops running queued orders and waits for the orders that are
+	// running to finish. It returns false if they did not finish before the
+	// timeout.
+	Drain(timeout time.Duration) bool
+
+	// Schedule queues the order to be run before the deadline, a Unix time
+	// or zero if it has none. It returns false if the order is already
+	// queued, running or waiting to be retried, the deadline of a queued
+	// order is brought forward if it is earlier.
+	Schedule(orderID [32]byte, deadline int64) bool
+
+	// ScheduleAfter queues the order once the delay has passed.
+	ScheduleAfter(orderID [32]byte, deadline int64, delay time.Duration) bool
+
+	// Wake queues an order that is waiting to be retried straight away. It
+	// returns false if the order is not waiting.
+	Wake(orderID [32]byte) bool
+
+	// Run runs the function for the order straight away, without waiting for
+	// a free worker, and returns ErrInFlight if the order is already queued
+	// or running.
+	Run(orderID [32]byte, f func() error) error
+
+	// RunNow runs the function for the order straight away like Run, taking
+	// the order off the queue if it is queued or waiting to be retried. It
+	// only returns ErrInFlight if the order is running.
+	RunNow(orderID [32]byte, f func() error) error
+
+	// Block runs the function for a running order, which waits for something
+	// outside of the swapper such as the counterparty, without holding the
+	// order's worker so that other orders can run while it waits. The order
+	// takes its worker back once the function returns, even if that briefly
+	// runs more orders than the concurrency.
+	Block(orderID [32]byte, f func() error) error
+
+	// InFlight returns true if the order is queued, running or waiting to be
+	// retried.
+	InFlight(orderID [32]byte) bool
+
+	// Queued returns the number of orders that are waiting to run.
+	Queued() int
+
+	// Running returns the number of orders that are running.
+	Running() int
+}
+
+type item struct {
+	orderID  [32]byte
+	deadline int64
+	seq      uint64
+	index    int
+}
+
+// queue is a heap of items ordered by deadline, with the items that have no
+// deadline last and ties broken by the order they were queued in.
+type queue []*item
+
+func (q queue) Len() int { return len(q) }
+
+func (q queue) Less(i, j int) bool {
+	if q[i].deadline != q[j].deadline {
+		if q[i].deadline == 0 || q[j].deadline == 0 {
+			return q[j].deadline == 0
+		}
+		return q[i].deadline < q[j].deadline
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q queue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *queue) Push(x interface{}) {
+	item := x.(*item)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *queue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	item.index = -1
+	return item
+}
+
+// waiting is an order that is queued when its timer fires.
+type waiting struct {
+	timer    *time.Timer
+	deadline int64
+}
+
+type scheduler struct {
+	name        string
+	concurrency int
+	job         Job
+
+	mu      *sync.Mutex
+	queue   queue
+	queued  map[[32]byte]*item
+	running map[[32]byte]bool
+	blocked map[[32]byte]bool
+	waiting map[[32]byte]*waiting
+	seq     uint64
+
+	wakeCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce *sync.Once
+}
+
+// NewScheduler returns a Scheduler that runs the job for at most concurrency
+// orders at the same time, or DefaultConcurrency if it is not positive. The
+// depth of its queue is reported in the metrics under the name.
+func NewScheduler(name string, concurrency int, job Job) Scheduler {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &scheduler{
+		name:        name,
+		concurrency: concurrency,
+		job:         job,
+		mu:          new(sync.Mutex),
+		queue:       queue{},
+		queued:      map[[32]byte]*item{},
+		running:     map[[32]byte]bool{},
+		blocked:     map[[32]byte]bool{},
+		waiting:     map[[32]byte]*waiting{},
+		wakeCh:      make(chan struct{}, 1),
+		doneCh:      make(chan struct{}),
+		stopOnce:    new(sync.Once),
+	}
+}
+
+func (s *scheduler) Start(errs chan<- error) {
+	go func() {
+		for {
+			select {
+			case <-s.doneCh:
+				return
+			case <-s.wakeCh:
+			}
+			for {
+				orderID, ok := s.next()
+				if !ok {
+					break
+				}
+				go s.run(orderID, errs)
+			}
+		}
+	}()
+	s.wake()
+}
+
+func (s *scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.doneCh)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for orderID, waiting := range s.waiting {
+		waiting.timer.Stop()
+		delete(s.waiting, orderID)
+	}
+}
+
+func (s *scheduler) Drain(timeout time.Duration) bool {
+	s.Stop()
+	deadline := time.Now().Add(timeout)
+	for s.Running() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+	return true
+}
+
+func (s *scheduler) Schedule(orderID [32]byte, deadline int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running[orderID] || s.waiting[orderID] != nil {
+		return false
+	}
+	if queued, ok := s.queued[orderID]; ok {
+		if deadline != 0 && (queued.deadline == 0 || deadline < queued.deadline) {
+			queued.deadline = deadline
+			heap.Fix(&s.queue, queued.index)
+		}
+		return false
+	}
+
+	s.seq++
+	item := &item{
+		orderID:  orderID,
+		deadline: deadline,
+		seq:      s.seq,
+	}
+	heap.Push(&s.queue, item)
+	s.queued[orderID] = item
+	s.report()
+	s.wake()
+	return true
+}
+
+func (s *scheduler) ScheduleAfter(orderID [32]byte, deadline int64, delay time.Duration) bool {
+	if delay <= 0 {
+		return s.Schedule(orderID, deadline)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[orderID] || s.queued[orderID] != nil || s.waiting[orderID] != nil {
+		return false
+	}
+	s.wait(orderID, deadline, delay)
+	return true
+}
+
+func (s *scheduler) Wake(orderID [32]byte) bool {
+	s.mu.Lock()
+	waiting, ok := s.waiting[orderID]
+	if ok {
+		waiting.timer.Stop()
+		delete(s.waiting, orderID)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	return s.Schedule(orderID, waiting.deadline)
+}
+
+// wait queues the order once the delay has passed, it must be called with
+// the mutex held.
+func (s *scheduler) wait(orderID [32]byte, deadline int64, delay time.Duration) {
+	s.waiting[orderID] = &waiting{
+		timer:    time.AfterFunc(delay, func() { s.Wake(orderID) }),
+		deadline: deadline,
+	}
+	s.report()
+}
+
+func (s *scheduler) Run(orderID [32]byte, f func() error) error {
+	s.mu.Lock()
+	if s.running[orderID] || s.queued[orderID] != nil || s.waiting[orderID] != nil {
+		s.mu.Unlock()
+		return ErrInFlight
+	}
+	s.running[orderID] = true
+	s.report()
+	s.mu.Unlock()
+
+	defer s.done(orderID)
+	return f()
+}
+
+func (s *scheduler) RunNow(orderID [32]byte, f func() error) error {
+	s.mu.Lock()
+	if s.running[orderID] {
+		s.mu.Unlock()
+		return ErrInFlight
+	}
+	if waiting, ok := s.waiting[orderID]; ok {
+		waiting.timer.Stop()
+		delete(s.waiting, orderID)
+	}
+	if queued, ok := s.queued[orderID]; ok {
+		heap.Remove(&s.queue, queued.index)
+		delete(s.queued, orderID)
+	}
+	s.running[orderID] = true
+	s.report()
+	s.mu.Unlock()
+
+	defer s.done(orderID)
+	return f()
+}
+
+func (s *scheduler) Block(orderID [32]byte, f func() error) error {
+	s.mu.Lock()
+	block := s.running[orderID] && !s.blocked[orderID]
+	if block {
+		s.blocked[orderID] = true
+	}
+	s.mu.Unlock()
+
+	if block {
+		s.wake()
+		defer func() {
+			s.mu.Lock()
+			delete(s.blocked, orderID)
+			s.mu.Unlock()
+		}()
+	}
+	return f()
+}
+
+func (s *scheduler) InFlight(orderID [32]byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[orderID] || s.queued[orderID] != nil || s.waiting[orderID] != nil
+}
+
+func (s *scheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+func (s *scheduler) Running() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.running)
+}
+
+// next takes the most urgent order off the queue and marks it as running,
+// if there is a free worker for it. Orders that are blocked do not hold a
+// worker.
+func (s *scheduler) next() ([32]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 || len(s.running)-len(s.blocked) >= s.concurrency {
+		return [32]byte{}, false
+	}
+	item := heap.Pop(&s.queue).(*item)
+	delete(s.queued, item.orderID)
+	s.running[item.orderID] = true
+	return item.orderID, true
+}
+
+func (s *scheduler) run(orderID [32]byte, errs chan<- error) {
+	err := s.job(orderID)
+	if retry, ok := err.(RetryAfter); ok {
+		s.mu.Lock()
+		delete(s.running, orderID)
+		select {
+		case <-s.doneCh:
+		default:
+			s.wait(orderID, retry.Deadline, retry.Delay)
+		}
+		s.mu.Unlock()
+		s.wake()
+		err = retry.Err
+	} else {
+		s.done(orderID)
+	}
+	if err != nil {
+		errs <- err
+	}
+}
+
+// done marks the order as no longer running, freeing its worker for the
+// next order in the queue.
+func (s *scheduler) done(orderID [32]byte) {
+	s.mu.Lock()
+	delete(s.running, orderID)
+	s.report()
+	s.mu.Unlock()
+	s.wake()
+}
+
+func (s *scheduler) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// report updates the queue depth metric, it must be called with the mutex
+// held.
+func (s *scheduler) report() {
+	metrics.QueueDepth.WithLabelValues(s.name).Set(float64(len(s.queue) + len(s.running) + len(s.waiting)))
+}
@@ -0,0 +1,252 @@
+package scheduler_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/services/scheduler"
+)
+
+// blockingJob records the orders that it runs, and blocks until it is
+// released.
+type blockingJob struct {
+	mu      *sync.Mutex
+	started [][32]byte
+	release chan struct{}
+}
+
+func newBlockingJob() *blockingJob {
+	return &blockingJob{
+		mu:      new(sync.Mutex),
+		release: make(chan struct{}),
+	}
+}
+
+func (job *blockingJob) run(orderID [32]byte) error {
+	job.mu.Lock()
+	job.started = append(job.started, orderID)
+	job.mu.Unlock()
+	<-job.release
+	return nil
+}
+
+func (job *blockingJob) Started() [][32]byte {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return append([][32]byte{}, job.started...)
+}
+
+func order(i byte) [32]byte {
+	return [32]byte{i}
+}
+
+var _ = Describe("Scheduler", func() {
+	var job *blockingJob
+	var errs chan error
+
+	BeforeEach(func() {
+		job = newBlockingJob()
+		errs = make(chan error, 10)
+	})
+
+	It("runs at most the configured number of orders and queues the rest", func() {
+		scheduler := NewScheduler("test", 2, job.run)
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		for i := byte(1); i <= 5; i++ {
+			Expect(scheduler.Schedule(order(i), 0)).Should(BeTrue())
+		}
+		Eventually(job.Started).Should(HaveLen(2))
+		Consistently(job.Started).Should(HaveLen(2))
+		Expect(scheduler.Running()).Should(Equal(2))
+		Expect(scheduler.Queued()).Should(Equal(3))
+
+		close(job.release)
+		Eventually(job.Started).Should(HaveLen(5))
+		Eventually(scheduler.Running).Should(Equal(0))
+	})
+
+	It("does not schedule an order that is queued or running", func() {
+		scheduler := NewScheduler("test", 1, job.run)
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		Expect(scheduler.Schedule(order(1), 0)).Should(BeTrue())
+		Eventually(job.Started).Should(HaveLen(1))
+		Expect(scheduler.Schedule(order(1), 0)).Should(BeFalse())
+
+		Expect(scheduler.Schedule(order(2), 0)).Should(BeTrue())
+		Expect(scheduler.Schedule(order(2), 0)).Should(BeFalse())
+		Expect(scheduler.InFlight(order(2))).Should(BeTrue())
+
+		close(job.release)
+		Eventually(job.Started).Should(HaveLen(2))
+		Consistently(job.Started).Should(HaveLen(2))
+	})
+
+	It("runs the orders with the earliest deadlines first", func() {
+		scheduler := NewScheduler("test", 1, job.run)
+
+		scheduler.Schedule(order(1), 0)
+		scheduler.Schedule(order(2), 300)
+		scheduler.Schedule(order(3), 100)
+		scheduler.Schedule(order(4), 200)
+		scheduler.Schedule(order(1), 50)
+
+		close(job.release)
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		Eventually(job.Started).Should(Equal([][32]byte{order(1), order(3), order(4), order(2)}))
+	})
+
+	It("refuses to run an order that is running", func() {
+		scheduler := NewScheduler("test", 1, job.run)
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		scheduler.Schedule(order(1), 0)
+		Eventually(job.Started).Should(HaveLen(1))
+		Expect(scheduler.Run(order(1), func() error { return nil })).Should(Equal(ErrInFlight))
+
+		ran := false
+		Expect(scheduler.Run(order(2), func() error {
+			ran = true
+			Expect(scheduler.InFlight(order(2))).Should(BeTrue())
+			return nil
+		})).Should(Succeed())
+		Expect(ran).Should(BeTrue())
+		Expect(scheduler.InFlight(order(2))).Should(BeFalse())
+		close(job.release)
+	})
+
+	It("runs other orders while more orders than the concurrency are blocked", func() {
+		var scheduler Scheduler
+		blocked := int32(0)
+		scheduler = NewScheduler("test", 2, func(orderID [32]byte) error {
+			if orderID == order(6) {
+				return job.run(orderID)
+			}
+			return scheduler.Block(orderID, func() error {
+				atomic.AddInt32(&blocked, 1)
+				<-job.release
+				return nil
+			})
+		})
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		for i := byte(1); i <= 5; i++ {
+			scheduler.Schedule(order(i), 0)
+		}
+		Eventually(func() int32 { return atomic.LoadInt32(&blocked) }).Should(Equal(int32(5)))
+		Expect(scheduler.Running()).Should(Equal(5))
+
+		scheduler.Schedule(order(6), 0)
+		Eventually(job.Started).Should(Equal([][32]byte{order(6)}))
+		close(job.release)
+		Eventually(scheduler.Running).Should(Equal(0))
+	})
+
+	It("runs an order that is waiting to be retried straight away", func() {
+		scheduler := NewScheduler("test", 1, job.run)
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		Expect(scheduler.ScheduleAfter(order(1), 0, time.Hour)).Should(BeTrue())
+		Expect(scheduler.Run(order(1), func() error { return nil })).Should(Equal(ErrInFlight))
+
+		ran := false
+		Expect(scheduler.RunNow(order(1), func() error {
+			ran = true
+			Expect(scheduler.Wake(order(1))).Should(BeFalse())
+			return nil
+		})).Should(Succeed())
+		Expect(ran).Should(BeTrue())
+		Expect(scheduler.InFlight(order(1))).Should(BeFalse())
+		Consistently(job.Started).Should(BeEmpty())
+		close(job.release)
+	})
+
+	It("sends the errors of the job", func() {
+		scheduler := NewScheduler("test", 1, func([32]byte) error {
+			return errors.New("failed")
+		})
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		scheduler.Schedule(order(1), 0)
+		Eventually(errs).Should(Receive(MatchError("failed")))
+		Eventually(func() bool { return scheduler.InFlight(order(1)) }).Should(BeFalse())
+	})
+
+	It("runs an order again after the delay that its job asks for", func() {
+		attempts := int32(0)
+		scheduler := NewScheduler("test", 1, func([32]byte) error {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				return RetryAfter{Delay: 50 * time.Millisecond, Err: errors.New("failed")}
+			}
+			return nil
+		})
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		scheduler.Schedule(order(1), 0)
+		Eventually(errs).Should(Receive(MatchError("failed")))
+		Expect(scheduler.InFlight(order(1))).Should(BeTrue())
+		Expect(scheduler.Schedule(order(1), 0)).Should(BeFalse())
+		Eventually(func() int32 { return atomic.LoadInt32(&attempts) }).Should(Equal(int32(2)))
+		Eventually(func() bool { return scheduler.InFlight(order(1)) }).Should(BeFalse())
+	})
+
+	It("wakes an order that is waiting to be retried", func() {
+		scheduler := NewScheduler("test", 1, job.run)
+		scheduler.Start(errs)
+		defer scheduler.Stop()
+
+		Expect(scheduler.ScheduleAfter(order(1), 0, time.Hour)).Should(BeTrue())
+		Consistently(job.Started).Should(BeEmpty())
+		Expect(scheduler.Wake(order(1))).Should(BeTrue())
+		Eventually(job.Started).Should(HaveLen(1))
+		Expect(scheduler.Wake(order(1))).Should(BeFalse())
+		close(job.release)
+	})
+
+	It("drains the running orders without starting the queued ones", func() {
+		scheduler := NewScheduler("test", 1, job.run)
+		scheduler.Start(errs)
+
+		scheduler.Schedule(order(1), 0)
+		scheduler.Schedule(order(2), 0)
+		Eventually(job.Started).Should(HaveLen(1))
+		Expect(scheduler.Drain(50 * time.Millisecond)).Should(BeFalse())
+
+		close(job.release)
+		Expect(scheduler.Drain(time.Second)).Should(BeTrue())
+		Consistently(job.Started).Should(HaveLen(1))
+	})
+})
+
+var _ = Describe("Backoff", func() {
+	It("doubles the delay up to the maximum", func() {
+		backoff := Backoff{Initial: time.Second, Max: 10 * time.Second}
+		Expect(backoff.Delay(1)).Should(Equal(time.Second))
+		Expect(backoff.Delay(2)).Should(Equal(2 * time.Second))
+		Expect(backoff.Delay(4)).Should(Equal(8 * time.Second))
+		Expect(backoff.Delay(5)).Should(Equal(10 * time.Second))
+		Expect(backoff.Delay(100)).Should(Equal(10 * time.Second))
+	})
+
+	It("randomises the delay by at most the jitter", func() {
+		backoff := Backoff{Initial: time.Second, Max: time.Minute, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			Expect(backoff.Delay(1)).Should(BeNumerically("~", time.Second, 500*time.Millisecond))
+		}
+	})
+})
@@ -0,0 +1,77 @@
+package shutdown
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned when broadcasting a transaction while the
+// swapper is shutting down.
+var ErrShuttingDown = errors.New("swapper is shutting down")
+
+// Broadcaster guards the broadcasting of transactions, which must not be
+// interrupted before the transaction has been persisted. Otherwise a swap that
+// is resumed could broadcast it a second time.
+type Broadcaster interface {
+	// Broadcast runs the function, which broadcasts a transaction and
+	// persists it, unless the swapper is shutting down.
+	Broadcast(f func() error) error
+
+	// ShuttingDown returns true once the swapper has started shutting down.
+	ShuttingDown() bool
+}
+
+// Shutdown stops the swapper from broadcasting transactions, waiting for the
+// broadcasts that are in progress.
+type Shutdown interface {
+	Broadcaster
+
+	// Begin refuses new broadcasts with ErrShuttingDown.
+	Begin()
+
+	// Wait waits for the broadcasts that are in progress to finish.
+	Wait()
+}
+
+type shutdown struct {
+	mu           *sync.Mutex
+	shuttingDown bool
+	broadcasts   *sync.WaitGroup
+}
+
+// NewShutdown returns a Shutdown that allows broadcasts until Begin is called.
+func NewShutdown() Shutdown {
+	return &shutdown{
+		mu:         new(sync.Mutex),
+		broadcasts: new(sync.WaitGroup),
+	}
+}
+
+func (s *shutdown) Broadcast(f func() error) error {
+	s.mu.Lock()
+	if s.shuttingDown {
+		s.mu.Unlock()
+		return ErrShuttingDown
+	}
+	s.broadcasts.Add(1)
+	s.mu.Unlock()
+
+	defer s.broadcasts.Done()
+	return f()
+}
+
+func (s *shutdown) ShuttingDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shuttingDown
+}
+
+func (s *shutdown) Begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shuttingDown = true
+}
+
+func (s *shutdown) Wait() {
+	s.broadcasts.Wait()
+}
@@ -0,0 +1,49 @@
+package shutdown_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/republicprotocol/renex-swapper-go/services/shutdown"
+)
+
+var _ = Describe("Shutdown", func() {
+	It("refuses broadcasts once shutdown has begun", func() {
+		shutdown := NewShutdown()
+		Expect(shutdown.Broadcast(func() error { return nil })).Should(Succeed())
+		Expect(shutdown.ShuttingDown()).Should(BeFalse())
+
+		shutdown.Begin()
+		ran := false
+		Expect(shutdown.Broadcast(func() error {
+			ran = true
+			return nil
+		})).Should(Equal(ErrShuttingDown))
+		Expect(ran).Should(BeFalse())
+		Expect(shutdown.ShuttingDown()).Should(BeTrue())
+	})
+
+	It("waits for the broadcasts in progress", func() {
+		shutdown := NewShutdown()
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go shutdown.Broadcast(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+		<-started
+		shutdown.Begin()
+
+		waited := make(chan struct{})
+		go func() {
+			shutdown.Wait()
+			close(waited)
+		}()
+		Consistently(waited, 100*time.Millisecond).ShouldNot(BeClosed())
+		close(release)
+		Eventually(waited).Should(BeClosed())
+	})
+})
@@ -0,0 +1,258 @@
+package store
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// ArchiveFormat is the version of the archive layout written by
+// ExportArchive.
+const ArchiveFormat = 1
+
+var ErrConflictingSwap = errors.New("archive contains a swap that conflicts with a swap in the store")
+var ErrUnknownArchiveFormat = errors.New("archive was written by a newer version of the swapper")
+var ErrStoreNotMigrated = errors.New("swap store must be migrated before importing an archive")
+
+// localKeys are the records that belong to a particular Store and are never
+// exported or imported.
+var localKeys = map[string]bool{
+	"Cipher Salt:":    true,
+	"Cipher Check:":   true,
+	"Schema Version:": true,
+}
+
+// ArchiveInfo describes the contents of an archive.
+type ArchiveInfo struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	CreatedAt     int64           `json:"createdAt"`
+	Keystore      json.RawMessage `json:"keystore"`
+	Records       int             `json:"records"`
+}
+
+type archive struct {
+	Format int    `json:"format"`
+	Salt   []byte `json:"salt"`
+	Data   []byte `json:"data"`
+}
+
+type archiveData struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	CreatedAt     int64           `json:"createdAt"`
+	Keystore      json.RawMessage `json:"keystore"`
+	Records       []Record        `json:"records"`
+}
+
+func (data archiveData) info() ArchiveInfo {
+	return ArchiveInfo{
+		SchemaVersion: data.SchemaVersion,
+		CreatedAt:     data.CreatedAt,
+		Keystore:      data.Keystore,
+		Records:       len(data.Records),
+	}
+}
+
+// ExportArchive writes every swap record in the Store to the Writer, along
+// with the keystore metadata. Sensitive records are decrypted using the
+// Store's Cipher and the whole archive is encrypted using the passphrase, so
+// the archive can be imported into a Store with a different passphrase.
+func ExportArchive(store Store, storeCipher Cipher, w io.Writer, passphrase string, keystore json.RawMessage) (ArchiveInfo, error) {
+	store, err := migratedCopy(store)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+
+	data := archiveData{
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now().Unix(),
+		Keystore:      keystore,
+		Records:       []Record{},
+	}
+	if err := store.Iterate(nil, func(key, value []byte) error {
+		if localKeys[string(key)] {
+			return nil
+		}
+		value, err := decryptRecord(storeCipher, key, value)
+		if err != nil {
+			return fmt.Errorf("cannot decrypt %q: %v", key, err)
+		}
+		data.Records = append(data.Records, Record{
+			Key:   append([]byte{}, key...),
+			Value: value,
+		})
+		return nil
+	}); err != nil {
+		return ArchiveInfo{}, err
+	}
+
+	plain, err := json.Marshal(data)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return ArchiveInfo{}, err
+	}
+	c, err := NewCipher(passphrase, salt)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	sealed, err := c.Encrypt(plain)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	if err := json.NewEncoder(w).Encode(archive{
+		Format: ArchiveFormat,
+		Salt:   salt,
+		Data:   sealed,
+	}); err != nil {
+		return ArchiveInfo{}, err
+	}
+	return data.info(), nil
+}
+
+// ImportArchive writes the records of an archive to the Store, encrypting
+// sensitive records using the Store's Cipher, and returns the number of
+// records written. Records that are already in the Store with the same value
+// are skipped. If any record conflicts with one in
+// the Store, nothing is written and ErrConflictingSwap is returned.
+func ImportArchive(store Store, storeCipher Cipher, r io.Reader, passphrase string) (ArchiveInfo, error) {
+	version, err := LoadSchemaVersion(store)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	if version != SchemaVersion {
+		return ArchiveInfo{}, ErrStoreNotMigrated
+	}
+
+	data, err := readArchive(r, passphrase)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	info := data.info()
+	if data.SchemaVersion > SchemaVersion {
+		return info, ErrUnknownSchemaVersion
+	}
+
+	// Archives written by older versions of the swapper are migrated before
+	// they are imported.
+	records := newSnapshotStore()
+	batch := records.NewBatch()
+	for _, record := range data.Records {
+		batch.Write(record.Key, record.Value)
+	}
+	versionBytes, err := json.Marshal(schemaVersion{data.SchemaVersion})
+	if err != nil {
+		return info, err
+	}
+	batch.Write(schemaVersionKey, versionBytes)
+	if err := batch.Commit(); err != nil {
+		return info, err
+	}
+	if _, err := Migrate(records, MigrateOptions{}); err != nil {
+		return info, err
+	}
+
+	batch = store.NewBatch()
+	n := 0
+	if err := records.Iterate(nil, func(key, value []byte) error {
+		if localKeys[string(key)] {
+			return nil
+		}
+		existing, err := store.Read(key)
+		if err == nil {
+			existing, err = decryptRecord(storeCipher, key, existing)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(existing, value) {
+				return ErrConflictingSwap
+			}
+			return nil
+		}
+		if err != ErrKeyNotFound {
+			return err
+		}
+		if storeCipher != nil && isSensitive(key) {
+			if value, err = storeCipher.Encrypt(value); err != nil {
+				return err
+			}
+		}
+		batch.Write(key, value)
+		n++
+		return nil
+	}); err != nil {
+		return info, err
+	}
+	info.Records = n
+	if n == 0 {
+		return info, nil
+	}
+	versionBytes, err = json.Marshal(schemaVersion{SchemaVersion})
+	if err != nil {
+		return info, err
+	}
+	batch.Write(schemaVersionKey, versionBytes)
+	return info, batch.Commit()
+}
+
+// ReadArchiveInfo decrypts an archive and returns its description without
+// importing it.
+func ReadArchiveInfo(r io.Reader, passphrase string) (ArchiveInfo, error) {
+	data, err := readArchive(r, passphrase)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	return data.info(), nil
+}
+
+func readArchive(r io.Reader, passphrase string) (archiveData, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return archiveData{}, err
+	}
+	a := archive{}
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return archiveData{}, err
+	}
+	if a.Format > ArchiveFormat {
+		return archiveData{}, ErrUnknownArchiveFormat
+	}
+	c, err := NewCipher(passphrase, a.Salt)
+	if err != nil {
+		return archiveData{}, err
+	}
+	plain, err := c.Decrypt(a.Data)
+	if err != nil {
+		return archiveData{}, ErrWrongPassphrase
+	}
+	data := archiveData{}
+	if err := json.Unmarshal(plain, &data); err != nil {
+		return archiveData{}, err
+	}
+	return data, nil
+}
+
+func decryptRecord(c Cipher, key, value []byte) ([]byte, error) {
+	if !isSensitive(key) || !IsEncrypted(value) {
+		return append([]byte{}, value...), nil
+	}
+	if c == nil {
+		return nil, ErrStoreLocked
+	}
+	return c.Decrypt(value)
+}
+
+func isSensitive(key []byte) bool {
+	for _, prefix := range sensitivePrefixes {
+		if bytes.HasPrefix(key, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
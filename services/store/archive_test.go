@@ -0,0 +1,108 @@
+package store_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Archives", func() {
+	var from, to Store
+	var fromCipher, toCipher Cipher
+	var orderID [32]byte
+
+	keystore := json.RawMessage(`[{"priorityCode":0,"network":"testnet","address":"mxqP4AoVDtrWGKzVAp8jiVBzjkt2nNgLEN"}]`)
+
+	BeforeEach(func() {
+		var err error
+		rand.Read(orderID[:])
+
+		from = memory.NewMemoryStore()
+		fromCipher, err = LoadCipher(from, "from passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		to = memory.NewMemoryStore()
+		_, err = Migrate(to, MigrateOptions{})
+		Expect(err).ShouldNot(HaveOccurred())
+		toCipher, err = LoadCipher(to, "to passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		state := NewState(from, fromCipher, loggerAdapter.NewStdOutLogger())
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.PutStatus(orderID, "INITIATED")).ShouldNot(HaveOccurred())
+		Expect(state.PutRedeemDetails(orderID, [32]byte{42})).ShouldNot(HaveOccurred())
+		Expect(state.PutAtomDetails(orderID, []byte(`{"contract":"details"}`))).ShouldNot(HaveOccurred())
+	})
+
+	export := func() *bytes.Buffer {
+		archive := new(bytes.Buffer)
+		info, err := ExportArchive(from, fromCipher, archive, "archive passphrase", keystore)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Records).Should(Equal(5))
+		Expect(archive.String()).ShouldNot(ContainSubstring("testnet"))
+		return archive
+	}
+
+	It("moves swaps to a store with a different passphrase", func() {
+		archive := export()
+
+		info, err := ImportArchive(to, toCipher, archive, "archive passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Records).Should(Equal(5))
+		Expect(info.SchemaVersion).Should(Equal(SchemaVersion))
+		Expect(info.Keystore).Should(MatchJSON(keystore))
+
+		raw, err := to.Read(append([]byte("Redeem Details:"), orderID[:]...))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(IsEncrypted(raw)).Should(BeTrue())
+
+		state := NewState(to, toCipher, loggerAdapter.NewStdOutLogger())
+		Expect(state.Status(orderID)).Should(Equal("INITIATED"))
+		secret, err := state.RedeemDetails(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(secret).Should(Equal([32]byte{42}))
+		swaps, err := state.ExecutableSwaps(true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(swaps).Should(Equal([][32]byte{orderID}))
+	})
+
+	It("refuses to import into a store that has not been migrated", func() {
+		Expect(to.Delete([]byte("Schema Version:"))).ShouldNot(HaveOccurred())
+		Expect(NewState(to, toCipher, loggerAdapter.NewStdOutLogger()).AddSwap([32]byte{1})).ShouldNot(HaveOccurred())
+		_, err := ImportArchive(to, toCipher, export(), "archive passphrase")
+		Expect(err).Should(Equal(ErrStoreNotMigrated))
+	})
+
+	It("rejects the wrong passphrase", func() {
+		_, err := ImportArchive(to, toCipher, export(), "wrong passphrase")
+		Expect(err).Should(Equal(ErrWrongPassphrase))
+	})
+
+	It("skips swaps that have already been imported", func() {
+		archive := export().Bytes()
+		_, err := ImportArchive(to, toCipher, bytes.NewReader(archive), "archive passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		info, err := ImportArchive(to, toCipher, bytes.NewReader(archive), "archive passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(info.Records).Should(Equal(0))
+	})
+
+	It("refuses to overwrite conflicting swaps", func() {
+		state := NewState(to, toCipher, loggerAdapter.NewStdOutLogger())
+		Expect(state.PutRedeemDetails(orderID, [32]byte{43})).ShouldNot(HaveOccurred())
+
+		_, err := ImportArchive(to, toCipher, export(), "archive passphrase")
+		Expect(err).Should(Equal(ErrConflictingSwap))
+		Expect(state.Status(orderID)).Should(Equal("UNKNOWN"))
+		secret, err := state.RedeemDetails(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(secret).Should(Equal([32]byte{43}))
+	})
+})
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// cipherVersion prefixes every encrypted value so that it can be told apart
+// from the plain JSON written by earlier versions of the swapper.
+const cipherVersion byte = 0x01
+
+var cipherCheck = []byte("RenEx Atomic Swapper")
+
+// sensitivePrefixes are the prefixes of the records that are encrypted. Atom
+// details contain the refund transactions of the swapper's own atoms.
+var sensitivePrefixes = []string{"Redeem Details:", "Atom Details:"}
+
+var ErrWrongPassphrase = errors.New("wrong passphrase for the swap store")
+var ErrEmptyPassphrase = errors.New("passphrase must not be empty")
+var ErrNotEncrypted = errors.New("value is not encrypted")
+var ErrStoreLocked = errors.New("swap store is encrypted and no passphrase was given")
+
+// Cipher encrypts sensitive values before they are written to the Store and
+// decrypts them after they are read.
+type Cipher interface {
+	Encrypt([]byte) ([]byte, error)
+	Decrypt([]byte) ([]byte, error)
+}
+
+type aesCipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher derives an AES-256-GCM key from the passphrase and salt using
+// scrypt. It returns ErrEmptyPassphrase if the passphrase is empty.
+func NewCipher(passphrase string, salt []byte) (Cipher, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesCipher{
+		aead: aead,
+	}, nil
+}
+
+// LoadCipher returns the Cipher for the Store, creating a new salt the first
+// time it is called. It returns ErrWrongPassphrase if the Store was encrypted
+// using a different passphrase.
+func LoadCipher(store Store, passphrase string) (Cipher, error) {
+	salt, err := store.Read([]byte("Cipher Salt:"))
+	if err != nil {
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := NewCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := store.Read([]byte("Cipher Check:"))
+	if err == nil {
+		plain, err := c.Decrypt(check)
+		if err != nil || !bytes.Equal(plain, cipherCheck) {
+			return nil, ErrWrongPassphrase
+		}
+		return c, nil
+	}
+
+	check, err = c.Encrypt(cipherCheck)
+	if err != nil {
+		return nil, err
+	}
+	batch := store.NewBatch()
+	batch.Write([]byte("Cipher Salt:"), salt)
+	batch.Write([]byte("Cipher Check:"), check)
+	if err := batch.Commit(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *aesCipher) Encrypt(plain []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := append([]byte{cipherVersion}, nonce...)
+	return c.aead.Seal(sealed, nonce, plain, nil), nil
+}
+
+func (c *aesCipher) Decrypt(sealed []byte) ([]byte, error) {
+	if !IsEncrypted(sealed) {
+		return nil, ErrNotEncrypted
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < 1+nonceSize {
+		return nil, fmt.Errorf("encrypted value is too short: %d bytes", len(sealed))
+	}
+	return c.aead.Open(nil, sealed[1:1+nonceSize], sealed[1+nonceSize:], nil)
+}
+
+// IsEncrypted returns true if the value was written by a Cipher.
+func IsEncrypted(value []byte) bool {
+	return len(value) > 0 && value[0] == cipherVersion
+}
+
+// EncryptRecords encrypts the sensitive records that were written in plain
+// text by earlier versions of the swapper. It returns the number of records
+// that were encrypted.
+func EncryptRecords(store Store, c Cipher) (int, error) {
+	batch := store.NewBatch()
+	n := 0
+	for _, prefix := range sensitivePrefixes {
+		if err := store.Iterate([]byte(prefix), func(key, value []byte) error {
+			if IsEncrypted(value) {
+				return nil
+			}
+			sealed, err := c.Encrypt(value)
+			if err != nil {
+				return err
+			}
+			batch.Write(append([]byte{}, key...), sealed)
+			n++
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, batch.Commit()
+}
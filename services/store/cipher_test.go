@@ -0,0 +1,80 @@
+package store_test
+
+import (
+	"crypto/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Cipher", func() {
+	var db Store
+	var orderID [32]byte
+
+	BeforeEach(func() {
+		db = memory.NewMemoryStore()
+		rand.Read(orderID[:])
+	})
+
+	It("encrypts secrets and atom details", func() {
+		cipher, err := LoadCipher(db, "passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		state := NewState(db, cipher, loggerAdapter.NewStdOutLogger())
+
+		Expect(state.PutRedeemDetails(orderID, [32]byte{42})).ShouldNot(HaveOccurred())
+		Expect(state.PutAtomDetails(orderID, []byte(`{"contract":"details"}`))).ShouldNot(HaveOccurred())
+
+		raw, err := db.Read(append([]byte("Redeem Details:"), orderID[:]...))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(IsEncrypted(raw)).Should(BeTrue())
+		raw, err = db.Read(append([]byte("Atom Details:"), orderID[:]...))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(IsEncrypted(raw)).Should(BeTrue())
+
+		secret, err := state.RedeemDetails(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(secret).Should(Equal([32]byte{42}))
+		details, err := state.AtomDetails(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(details).Should(Equal([]byte(`{"contract":"details"}`)))
+	})
+
+	It("rejects the wrong passphrase", func() {
+		_, err := LoadCipher(db, "passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = LoadCipher(db, "wrong passphrase")
+		Expect(err).Should(Equal(ErrWrongPassphrase))
+	})
+
+	It("rejects an empty passphrase", func() {
+		_, err := LoadCipher(db, "")
+		Expect(err).Should(Equal(ErrEmptyPassphrase))
+	})
+
+	It("encrypts records that were stored in plain text", func() {
+		plain := NewState(db, nil, loggerAdapter.NewStdOutLogger())
+		Expect(plain.PutRedeemDetails(orderID, [32]byte{42})).ShouldNot(HaveOccurred())
+
+		cipher, err := LoadCipher(db, "passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		n, err := EncryptRecords(db, cipher)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n).Should(Equal(1))
+
+		raw, err := db.Read(append([]byte("Redeem Details:"), orderID[:]...))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(IsEncrypted(raw)).Should(BeTrue())
+
+		state := NewState(db, cipher, loggerAdapter.NewStdOutLogger())
+		secret, err := state.RedeemDetails(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(secret).Should(Equal([32]byte{42}))
+
+		_, err = plain.RedeemDetails(orderID)
+		Expect(err).Should(Equal(ErrStoreLocked))
+	})
+})
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+)
+
+// Complaint records why the swapper complained to the watchdog about a swap,
+// and who resolved it.
+type Complaint struct {
+	Reason     string `json:"reason"`
+	Time       int64  `json:"time"`
+	ResolvedBy string `json:"resolvedBy,omitempty"`
+	ResolvedAt int64  `json:"resolvedAt,omitempty"`
+}
+
+// SwapError records the last error that stopped a swap from progressing.
+// Attempts is the number of times in a row that the swap has failed. A swap
+// that failed with a transient error is retried at RetryAt, one that failed
+// with a permanent error is not retried until an operator asks for it. Refund
+// is set if the error stopped the guardian from refunding the swap.
+type SwapError struct {
+	Message   string `json:"message"`
+	Time      int64  `json:"time"`
+	Attempts  int    `json:"attempts,omitempty"`
+	Permanent bool   `json:"permanent,omitempty"`
+	RetryAt   int64  `json:"retryAt,omitempty"`
+	Refund    bool   `json:"refund,omitempty"`
+}
+
+func (tx *transaction) PutComplaint(orderID [32]byte, reason string) error {
+	complaintBytes, err := json.Marshal(Complaint{
+		Reason: reason,
+		Time:   time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Complaint:"), orderID[:]...), complaintBytes)
+	return nil
+}
+
+func (tx *transaction) ResolveComplaint(orderID [32]byte, complaint Complaint, actor string) error {
+	complaint.ResolvedBy = actor
+	complaint.ResolvedAt = time.Now().Unix()
+	complaintBytes, err := json.Marshal(complaint)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Complaint:"), orderID[:]...), complaintBytes)
+	return nil
+}
+
+func (tx *transaction) PutCounterpartyTransactions(orderID [32]byte, txs swapDomain.Transactions) error {
+	txsBytes, err := json.Marshal(txs)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Counterparty Transactions:"), orderID[:]...), txsBytes)
+	return nil
+}
+
+func (state *state) Complaint(orderID [32]byte) (Complaint, error) {
+	complaint := Complaint{}
+	complaintBytes, err := state.Read(append([]byte("Complaint:"), orderID[:]...))
+	if err != nil {
+		return complaint, err
+	}
+	if err := json.Unmarshal(complaintBytes, &complaint); err != nil {
+		return complaint, err
+	}
+	return complaint, nil
+}
+
+func (state *state) CounterpartyTransactions(orderID [32]byte) (swapDomain.Transactions, error) {
+	txs := swapDomain.Transactions{}
+	txsBytes, err := state.Read(append([]byte("Counterparty Transactions:"), orderID[:]...))
+	if err == ErrKeyNotFound {
+		return txs, nil
+	}
+	if err != nil {
+		return txs, err
+	}
+	if err := json.Unmarshal(txsBytes, &txs); err != nil {
+		return txs, err
+	}
+	return txs, nil
+}
+
+func (state *state) PutError(orderID [32]byte, message string) error {
+	errBytes, err := json.Marshal(SwapError{
+		Message: message,
+		Time:    time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	return state.Write(append([]byte("Swap Error:"), orderID[:]...), errBytes)
+}
+
+func (state *state) ClearError(orderID [32]byte) error {
+	return state.update(func(tx Transaction) error {
+		return tx.ClearError(orderID)
+	})
+}
+
+func (tx *transaction) ClearError(orderID [32]byte) error {
+	tx.batch.Delete(append([]byte("Swap Error:"), orderID[:]...))
+	return nil
+}
+
+func (state *state) Error(orderID [32]byte) (SwapError, error) {
+	swapErr := SwapError{}
+	errBytes, err := state.Read(append([]byte("Swap Error:"), orderID[:]...))
+	if err != nil {
+		return swapErr, err
+	}
+	if err := json.Unmarshal(errBytes, &swapErr); err != nil {
+		return swapErr, err
+	}
+	return swapErr, nil
+}
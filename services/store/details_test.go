@@ -0,0 +1,84 @@
+package store_test
+
+import (
+	"crypto/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Swap details", func() {
+	var state State
+	var orderID [32]byte
+
+	BeforeEach(func() {
+		state = NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		rand.Read(orderID[:])
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+	})
+
+	It("records the complaint with the status", func() {
+		tx := state.NewTransaction()
+		Expect(tx.PutComplaint(orderID, "invalid contract")).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, "COMPLAINED")).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		complaint, err := state.Complaint(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(complaint.Reason).Should(Equal("invalid contract"))
+		Expect(state.Status(orderID)).Should(Equal("COMPLAINED"))
+	})
+
+	It("records the counterparty's transactions", func() {
+		txs, err := state.CounterpartyTransactions(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(txs).Should(Equal(swapDomain.Transactions{}))
+
+		tx := state.NewTransaction()
+		Expect(tx.PutCounterpartyTransactions(orderID, swapDomain.Transactions{Contract: "0x01", Initiate: "0x02"})).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+		txs, err = state.CounterpartyTransactions(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(txs.Initiate).Should(Equal("0x02"))
+	})
+
+	It("keeps the last error until it is cleared", func() {
+		_, err := state.Error(orderID)
+		Expect(err).Should(Equal(ErrKeyNotFound))
+
+		Expect(state.PutError(orderID, "connection refused")).ShouldNot(HaveOccurred())
+		swapErr, err := state.Error(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(swapErr.Message).Should(Equal("connection refused"))
+
+		Expect(state.ClearError(orderID)).ShouldNot(HaveOccurred())
+		_, err = state.Error(orderID)
+		Expect(err).Should(Equal(ErrKeyNotFound))
+	})
+
+	It("records failed attempts in the history", func() {
+		tx := state.NewTransaction()
+		Expect(tx.PutFailedAttempt(orderID, SwapError{
+			Message:  "connection refused",
+			Attempts: 2,
+			RetryAt:  100,
+		})).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		swapErr, err := state.Error(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(swapErr.Attempts).Should(Equal(2))
+		Expect(swapErr.RetryAt).Should(Equal(int64(100)))
+		Expect(swapErr.Time).ShouldNot(BeZero())
+
+		history, err := state.History(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(history[len(history)-1].Error).Should(Equal("connection refused"))
+		Expect(history[len(history)-1].Attempt).Should(Equal(2))
+	})
+})
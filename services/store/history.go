@@ -0,0 +1,341 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+)
+
+// Swap roles, the requestor is the trader that sends the currency with the
+// lower priority code and generates the secret.
+const (
+	RoleRequestor = "REQUESTOR"
+	RoleResponder = "RESPONDER"
+)
+
+// StatusChange records the time at which a swap reached a status, at which
+// an operator took an action on the swap, or at which an attempt to progress
+// the swap failed.
+type StatusChange struct {
+	Status  string `json:"status,omitempty"`
+	Action  string `json:"action,omitempty"`
+	Actor   string `json:"actor,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Attempt int    `json:"attempt,omitempty"`
+	Time    int64  `json:"time"`
+}
+
+// SwapSummary is the record that is kept for a swap once it has finished.
+type SwapSummary struct {
+	OrderID         [32]byte                `json:"orderID"`
+	ForeignOrderID  [32]byte                `json:"foreignOrderID"`
+	SendCurrency    uint32                  `json:"sendCurrency"`
+	ReceiveCurrency uint32                  `json:"receiveCurrency"`
+	SendValue       *big.Int                `json:"sendValue"`
+	ReceiveValue    *big.Int                `json:"receiveValue"`
+	Role            string                  `json:"role"`
+	Transactions    swapDomain.Transactions `json:"transactions"`
+	StartedAt       int64                   `json:"startedAt"`
+	FinishedAt      int64                   `json:"finishedAt"`
+	Outcome         string                  `json:"outcome"`
+	Pruned          bool                    `json:"pruned"`
+
+	// Status is the current status of a pending swap, or the outcome of an
+	// archived swap. It is not stored.
+	Status   string `json:"-"`
+	Archived bool   `json:"-"`
+}
+
+// ArchiveQuery selects archived swaps. Swaps are selected if they finished in
+// the range [From, To) and, if Outcome is not empty, have that outcome. A To
+// of zero selects all swaps that finished after From.
+type ArchiveQuery struct {
+	From    int64
+	To      int64
+	Outcome string
+}
+
+// settledOutcomes are the outcomes of swaps whose funds can no longer be
+// claimed by either trader, so their secrets are no longer needed.
+var settledOutcomes = map[string]bool{
+	"REDEEMED":  true,
+	"REFUNDED":  true,
+	"CANCELLED": true,
+	"EXPIRED":   true,
+	"ABANDONED": true,
+}
+
+func historyKey(orderID [32]byte, t time.Time) []byte {
+	key := append([]byte("History:"), orderID[:]...)
+	timestamp := make([]byte, 8)
+	binary.BigEndian.PutUint64(timestamp, uint64(t.UnixNano()))
+	return append(key, timestamp...)
+}
+
+func archiveKey(orderID [32]byte) []byte {
+	return append([]byte("Archive:"), orderID[:]...)
+}
+
+// archiveTimeKey indexes archived swaps by the time at which they finished.
+func archiveTimeKey(finishedAt int64, orderID [32]byte) []byte {
+	key := []byte("Archive Time:")
+	timestamp := make([]byte, 8)
+	binary.BigEndian.PutUint64(timestamp, uint64(finishedAt))
+	return append(append(key, timestamp...), orderID[:]...)
+}
+
+func readHistory(store Store, orderID [32]byte) ([]StatusChange, error) {
+	history := []StatusChange{}
+	if err := store.Iterate(append([]byte("History:"), orderID[:]...), func(key, value []byte) error {
+		change := StatusChange{}
+		if err := json.Unmarshal(value, &change); err != nil {
+			return err
+		}
+		history = append(history, change)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func readTransactions(store Store, orderID [32]byte) (swapDomain.Transactions, error) {
+	txs := swapDomain.Transactions{}
+	txsBytes, err := store.Read(append([]byte("Transactions:"), orderID[:]...))
+	if err == ErrKeyNotFound {
+		return txs, nil
+	}
+	if err != nil {
+		return txs, err
+	}
+	if err := json.Unmarshal(txsBytes, &txs); err != nil {
+		return txs, err
+	}
+	return txs, nil
+}
+
+// buildSummary summarises a swap from its records.
+func buildSummary(store Store, orderID [32]byte) (SwapSummary, error) {
+	summary := SwapSummary{
+		OrderID: orderID,
+	}
+
+	matchBytes, err := store.Read(append([]byte("Match:"), orderID[:]...))
+	if err != nil && err != ErrKeyNotFound {
+		return summary, err
+	}
+	if err == nil {
+		swapMatch := SwapMatch{}
+		if err := json.Unmarshal(matchBytes, &swapMatch); err != nil {
+			return summary, err
+		}
+		summary.ForeignOrderID = swapMatch.ForeignOrderID
+		summary.SendCurrency = swapMatch.SendCurrency
+		summary.ReceiveCurrency = swapMatch.ReceiveCurrency
+		summary.SendValue = swapMatch.SendValue
+		summary.ReceiveValue = swapMatch.ReceiveValue
+		summary.Role = RoleResponder
+		if swapMatch.SendCurrency < swapMatch.ReceiveCurrency {
+			summary.Role = RoleRequestor
+		}
+	}
+
+	history, err := readHistory(store, orderID)
+	if err != nil {
+		return summary, err
+	}
+	if len(history) > 0 {
+		summary.StartedAt = history[0].Time
+	}
+
+	if summary.Transactions, err = readTransactions(store, orderID); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// archiveSwap adds the writes that move a swap from the pending swaps into the
+// archive to the batch. The swap's records are kept until they are pruned.
+func archiveSwap(store Store, batch Batch, orderID [32]byte, outcome string, finishedAt int64) error {
+	summary, err := buildSummary(store, orderID)
+	if err != nil {
+		return err
+	}
+	summary.FinishedAt = finishedAt
+	summary.Outcome = outcome
+
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	batch.Write(archiveKey(orderID), summaryBytes)
+	batch.Write(archiveTimeKey(finishedAt, orderID), orderID[:])
+	batch.Delete(pendingSwapKey(orderID))
+	batch.Delete(reservationKey(orderID))
+	return nil
+}
+
+func (state *state) History(orderID [32]byte) ([]StatusChange, error) {
+	return readHistory(state.Store, orderID)
+}
+
+func (state *state) Transactions(orderID [32]byte) (swapDomain.Transactions, error) {
+	return readTransactions(state.Store, orderID)
+}
+
+func (state *state) PutTransactions(orderID [32]byte, txs swapDomain.Transactions) error {
+	return state.update(func(tx Transaction) error {
+		return tx.PutTransactions(orderID, txs)
+	})
+}
+
+// ArchiveSwap removes a finished swap from the pending swaps and records its
+// summary in the archive.
+func (state *state) ArchiveSwap(orderID [32]byte, outcome string) error {
+	state.swapMu.Lock()
+	defer state.swapMu.Unlock()
+	defer state.LogInfo(orderID, "archived the swap")
+
+	batch := state.NewBatch()
+	if err := archiveSwap(state.Store, batch, orderID, outcome, time.Now().Unix()); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// ArchiveSwap archives the swap along with the other updates in the
+// transaction. Its summary includes the updates made by the transaction
+// before it is archived.
+func (tx *transaction) ArchiveSwap(orderID [32]byte, outcome string) error {
+	return archiveSwap(pendingStore{tx.store, tx.batch}, tx.batch, orderID, outcome, time.Now().Unix())
+}
+
+func (state *state) ArchivedSwap(orderID [32]byte) (SwapSummary, error) {
+	summaryBytes, err := state.Read(archiveKey(orderID))
+	if err != nil {
+		return SwapSummary{}, err
+	}
+	summary := SwapSummary{}
+	if err := json.Unmarshal(summaryBytes, &summary); err != nil {
+		return SwapSummary{}, err
+	}
+	summary.Status = summary.Outcome
+	summary.Archived = true
+	return summary, nil
+}
+
+func (state *state) ArchivedSwaps(query ArchiveQuery) ([]SwapSummary, error) {
+	orderIDs := [][32]byte{}
+	if err := state.iterateArchive(query.From, query.To, func(orderID [32]byte) error {
+		orderIDs = append(orderIDs, orderID)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	summaries := []SwapSummary{}
+	for _, orderID := range orderIDs {
+		summary, err := state.ArchivedSwap(orderID)
+		if err != nil {
+			return nil, err
+		}
+		if query.Outcome != "" && summary.Outcome != query.Outcome {
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// PruneArchive deletes the details of the archived swaps that finished before
+// the given time, keeping their summaries. The secrets and atom details of a
+// swap are only deleted once it has been settled.
+func (state *state) PruneArchive(before int64) (int, error) {
+	state.swapMu.Lock()
+	defer state.swapMu.Unlock()
+
+	orderIDs := [][32]byte{}
+	if err := state.iterateArchive(0, before, func(orderID [32]byte) error {
+		orderIDs = append(orderIDs, orderID)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	batch := state.NewBatch()
+	n := 0
+	for _, orderID := range orderIDs {
+		summary, err := state.ArchivedSwap(orderID)
+		if err != nil {
+			return 0, err
+		}
+		if summary.Pruned || !state.isSettled(orderID, summary) {
+			continue
+		}
+		if err := state.pruneSwap(batch, summary); err != nil {
+			return 0, err
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, batch.Commit()
+}
+
+func (state *state) isSettled(orderID [32]byte, summary SwapSummary) bool {
+	if !settledOutcomes[summary.Outcome] {
+		return false
+	}
+	if _, err := state.Read(pendingSwapKey(orderID)); err == nil {
+		return false
+	}
+	return !state.IsRedeemable(orderID)
+}
+
+func (state *state) pruneSwap(batch Batch, summary SwapSummary) error {
+	orderID := summary.OrderID
+	for _, prefix := range []string{"Initiate Details:", "Redeem Details:", "Match:", "Atom Details:", "Transactions:", "Counterparty Transactions:", "Complaint:", "Swap Error:", "Reservation:"} {
+		batch.Delete(append([]byte(prefix), orderID[:]...))
+	}
+	if summary.ForeignOrderID != [32]byte{} {
+		batch.Delete(append([]byte("Atom Details:"), summary.ForeignOrderID[:]...))
+	}
+	if err := state.Iterate(append([]byte("History:"), orderID[:]...), func(key, value []byte) error {
+		batch.Delete(append([]byte{}, key...))
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	summary.Pruned = true
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	batch.Write(archiveKey(orderID), summaryBytes)
+	return nil
+}
+
+// iterateArchive calls the function for every archived swap that finished in
+// the range [from, to). A to of zero has no upper bound.
+func (state *state) iterateArchive(from, to int64, f func(orderID [32]byte) error) error {
+	prefix := []byte("Archive Time:")
+	lower := archiveTimeKey(from, [32]byte{})
+	return state.Iterate(prefix, func(key, value []byte) error {
+		if bytes.Compare(key, lower) < 0 {
+			return nil
+		}
+		finishedAt := int64(binary.BigEndian.Uint64(key[len(prefix) : len(prefix)+8]))
+		if to != 0 && finishedAt >= to {
+			return nil
+		}
+		var orderID [32]byte
+		copy(orderID[:], value)
+		return f(orderID)
+	})
+}
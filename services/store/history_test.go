@@ -0,0 +1,142 @@
+package store_test
+
+import (
+	"crypto/rand"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("History", func() {
+	var state State
+
+	newSwap := func(status string) [32]byte {
+		var orderID, foreignOrderID [32]byte
+		rand.Read(orderID[:])
+		rand.Read(foreignOrderID[:])
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.PutMatch(orderID, match.NewMatch(orderID, foreignOrderID, big.NewInt(10), big.NewInt(20), 1, 0))).ShouldNot(HaveOccurred())
+		Expect(state.PutRedeemDetails(orderID, [32]byte{42})).ShouldNot(HaveOccurred())
+		Expect(state.PutStatus(orderID, "INITIATED")).ShouldNot(HaveOccurred())
+		Expect(state.PutStatus(orderID, status)).ShouldNot(HaveOccurred())
+		return orderID
+	}
+
+	BeforeEach(func() {
+		state = NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+	})
+
+	It("records every status of a swap", func() {
+		orderID := newSwap("REDEEMED")
+		history, err := state.History(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(history).Should(HaveLen(2))
+		Expect(history[0].Status).Should(Equal("INITIATED"))
+		Expect(history[1].Status).Should(Equal("REDEEMED"))
+	})
+
+	It("archives a swap with the updates of the same transaction", func() {
+		var orderID [32]byte
+		rand.Read(orderID[:])
+
+		tx := state.NewTransaction()
+		Expect(tx.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		Expect(tx.PutReservation(orderID, Reservation{Currency: 1, Amount: big.NewInt(10)})).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, "CANCELLED")).ShouldNot(HaveOccurred())
+		Expect(tx.PutTransactions(orderID, swapDomain.Transactions{Refund: "0x03"})).ShouldNot(HaveOccurred())
+		Expect(tx.ArchiveSwap(orderID, "CANCELLED")).ShouldNot(HaveOccurred())
+
+		_, err := state.ArchivedSwap(orderID)
+		Expect(err).Should(Equal(ErrKeyNotFound))
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		_, err = state.PendingSwap(orderID)
+		Expect(err).Should(Equal(ErrKeyNotFound))
+		_, err = state.Reservation(orderID)
+		Expect(err).Should(Equal(ErrKeyNotFound))
+		summary, err := state.ArchivedSwap(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summary.Outcome).Should(Equal("CANCELLED"))
+		Expect(summary.StartedAt).ShouldNot(BeZero())
+		Expect(summary.Transactions.Refund).Should(Equal("0x03"))
+	})
+
+	It("archives a finished swap with its summary", func() {
+		orderID := newSwap("REDEEMED")
+		Expect(state.PutTransactions(orderID, swapDomain.Transactions{Initiate: "0x01", Redeem: "0x02"})).ShouldNot(HaveOccurred())
+		Expect(state.ArchiveSwap(orderID, "REDEEMED")).ShouldNot(HaveOccurred())
+
+		swaps, err := state.ExecutableSwaps(true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(swaps).Should(BeEmpty())
+
+		summary, err := state.ArchivedSwap(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summary.Outcome).Should(Equal("REDEEMED"))
+		Expect(summary.Role).Should(Equal(RoleResponder))
+		Expect(summary.SendValue.Int64()).Should(Equal(int64(10)))
+		Expect(summary.Transactions.Redeem).Should(Equal("0x02"))
+		Expect(summary.StartedAt).ShouldNot(BeZero())
+		Expect(summary.FinishedAt).Should(BeNumerically(">=", summary.StartedAt))
+	})
+
+	It("queries archived swaps by time and outcome", func() {
+		redeemed := newSwap("REDEEMED")
+		refunded := newSwap("REFUNDED")
+		Expect(state.ArchiveSwap(redeemed, "REDEEMED")).ShouldNot(HaveOccurred())
+		Expect(state.ArchiveSwap(refunded, "REFUNDED")).ShouldNot(HaveOccurred())
+
+		now := time.Now().Unix()
+		summaries, err := state.ArchivedSwaps(ArchiveQuery{From: now - 60, To: now + 60})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summaries).Should(HaveLen(2))
+
+		summaries, err = state.ArchivedSwaps(ArchiveQuery{Outcome: "REFUNDED"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summaries).Should(HaveLen(1))
+		Expect(summaries[0].OrderID).Should(Equal(refunded))
+
+		summaries, err = state.ArchivedSwaps(ArchiveQuery{From: now + 60})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summaries).Should(BeEmpty())
+	})
+
+	It("prunes the details of settled swaps and keeps their summaries", func() {
+		settled := newSwap("REDEEMED")
+		unsettled := newSwap("COMPLAINED")
+		Expect(state.ArchiveSwap(settled, "REDEEMED")).ShouldNot(HaveOccurred())
+		Expect(state.ArchiveSwap(unsettled, "COMPLAINED")).ShouldNot(HaveOccurred())
+
+		n, err := state.PruneArchive(time.Now().Unix() + 60)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n).Should(Equal(1))
+
+		_, err = state.RedeemDetails(settled)
+		Expect(err).Should(HaveOccurred())
+		summary, err := state.ArchivedSwap(settled)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(summary.Pruned).Should(BeTrue())
+
+		secret, err := state.RedeemDetails(unsettled)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(secret).Should(Equal([32]byte{42}))
+	})
+
+	It("does not prune swaps that can still be refunded", func() {
+		orderID := newSwap("REDEEMED")
+		Expect(state.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.ArchiveSwap(orderID, "REDEEMED")).ShouldNot(HaveOccurred())
+
+		n, err := state.PruneArchive(time.Now().Unix() + 60)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n).Should(Equal(0))
+	})
+})
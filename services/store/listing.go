@@ -0,0 +1,179 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// Limits on the number of swaps returned by ListSwaps.
+const (
+	DefaultSwapLimit = 50
+	MaxSwapLimit     = 500
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SwapQuery filters and orders a listing of pending and archived swaps. Empty
+// fields do not filter. Currencies selects the swaps that trade between the
+// two currencies, in either direction. From and To select the swaps that
+// started in the range [From, To), a To of zero has no upper bound. Swaps are
+// ordered by the time at which they started, and Cursor is the NextCursor of
+// the previous page.
+type SwapQuery struct {
+	Status     string
+	Currencies []uint32
+	Role       string
+	From       int64
+	To         int64
+	Descending bool
+	Cursor     string
+	Limit      int
+}
+
+// SwapPage is a page of swaps. NextCursor is empty if it is the last page.
+type SwapPage struct {
+	Swaps      []SwapSummary
+	NextCursor string
+}
+
+// PendingSwap summarises a swap that has not finished. It returns
+// ErrKeyNotFound if the swap is not pending.
+func (state *state) PendingSwap(orderID [32]byte) (SwapSummary, error) {
+	if _, err := state.Read(pendingSwapKey(orderID)); err != nil {
+		return SwapSummary{}, err
+	}
+	summary, err := buildSummary(state.Store, orderID)
+	if err != nil {
+		return SwapSummary{}, err
+	}
+	summary.Status = state.Status(orderID)
+	return summary, nil
+}
+
+// PendingSwaps summarises the swaps that have not finished.
+func (state *state) PendingSwaps() ([]SwapSummary, error) {
+	state.swapMu.RLock()
+	pendingSwaps, err := state.pendingSwaps()
+	state.swapMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	summaries := []SwapSummary{}
+	for _, orderID := range pendingSwaps {
+		summary, err := state.PendingSwap(orderID)
+		if err == ErrKeyNotFound {
+			// The swap was archived after the pending swaps were read.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (state *state) ListSwaps(query SwapQuery) (SwapPage, error) {
+	after, err := decodeCursor(query.Cursor)
+	if err != nil {
+		return SwapPage{}, err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultSwapLimit
+	}
+	if limit > MaxSwapLimit {
+		limit = MaxSwapLimit
+	}
+
+	summaries, err := state.PendingSwaps()
+	if err != nil {
+		return SwapPage{}, err
+	}
+	archived, err := state.ArchivedSwaps(ArchiveQuery{})
+	if err != nil {
+		return SwapPage{}, err
+	}
+	summaries = append(summaries, archived...)
+
+	less := func(a, b SwapSummary) bool {
+		if a.StartedAt != b.StartedAt {
+			return a.StartedAt < b.StartedAt
+		}
+		return string(a.OrderID[:]) < string(b.OrderID[:])
+	}
+	if query.Descending {
+		ascending := less
+		less = func(a, b SwapSummary) bool {
+			return ascending(b, a)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return less(summaries[i], summaries[j])
+	})
+
+	page := SwapPage{
+		Swaps: []SwapSummary{},
+	}
+	for _, summary := range summaries {
+		if after != nil && !less(*after, summary) {
+			continue
+		}
+		if !query.matches(summary) {
+			continue
+		}
+		if len(page.Swaps) == limit {
+			page.NextCursor = encodeCursor(page.Swaps[limit-1])
+			break
+		}
+		page.Swaps = append(page.Swaps, summary)
+	}
+	return page, nil
+}
+
+func (query SwapQuery) matches(summary SwapSummary) bool {
+	if query.Status != "" && summary.Status != query.Status {
+		return false
+	}
+	if query.Role != "" && summary.Role != query.Role {
+		return false
+	}
+	if len(query.Currencies) == 2 {
+		a, b := query.Currencies[0], query.Currencies[1]
+		if !(summary.SendCurrency == a && summary.ReceiveCurrency == b) && !(summary.SendCurrency == b && summary.ReceiveCurrency == a) {
+			return false
+		}
+	}
+	if summary.StartedAt < query.From {
+		return false
+	}
+	if query.To != 0 && summary.StartedAt >= query.To {
+		return false
+	}
+	return true
+}
+
+// encodeCursor encodes the position of a swap in a listing.
+func encodeCursor(summary SwapSummary) string {
+	cursor := make([]byte, 8, 40)
+	binary.BigEndian.PutUint64(cursor, uint64(summary.StartedAt))
+	cursor = append(cursor, summary.OrderID[:]...)
+	return base64.RawURLEncoding.EncodeToString(cursor)
+}
+
+func decodeCursor(cursor string) (*SwapSummary, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(cursorBytes) != 40 {
+		return nil, ErrInvalidCursor
+	}
+	summary := SwapSummary{
+		StartedAt: int64(binary.BigEndian.Uint64(cursorBytes[:8])),
+	}
+	copy(summary.OrderID[:], cursorBytes[8:])
+	return &summary, nil
+}
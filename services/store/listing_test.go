@@ -0,0 +1,96 @@
+package store_test
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Listing swaps", func() {
+	var state State
+	var requestor, responder, redeemed [32]byte
+
+	newSwap := func(sendCurrency, receiveCurrency uint32, status string) [32]byte {
+		var orderID, foreignOrderID [32]byte
+		rand.Read(orderID[:])
+		rand.Read(foreignOrderID[:])
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.PutMatch(orderID, match.NewMatch(orderID, foreignOrderID, big.NewInt(10), big.NewInt(20), sendCurrency, receiveCurrency))).ShouldNot(HaveOccurred())
+		Expect(state.PutStatus(orderID, status)).ShouldNot(HaveOccurred())
+		return orderID
+	}
+
+	orderIDs := func(page SwapPage) [][32]byte {
+		ids := [][32]byte{}
+		for _, summary := range page.Swaps {
+			ids = append(ids, summary.OrderID)
+		}
+		return ids
+	}
+
+	BeforeEach(func() {
+		state = NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		requestor = newSwap(0, 1, "INITIATED")
+		responder = newSwap(1, 0, "AUDITED")
+		redeemed = newSwap(0, 1, "REDEEMED")
+		Expect(state.ArchiveSwap(redeemed, "REDEEMED")).ShouldNot(HaveOccurred())
+	})
+
+	It("lists pending and archived swaps", func() {
+		page, err := state.ListSwaps(SwapQuery{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(orderIDs(page)).Should(ConsistOf(requestor, responder, redeemed))
+		Expect(page.NextCursor).Should(BeEmpty())
+	})
+
+	It("filters swaps by status and role", func() {
+		page, err := state.ListSwaps(SwapQuery{Status: "REDEEMED"})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(orderIDs(page)).Should(Equal([][32]byte{redeemed}))
+		Expect(page.Swaps[0].Archived).Should(BeTrue())
+
+		page, err = state.ListSwaps(SwapQuery{Role: RoleResponder})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(orderIDs(page)).Should(Equal([][32]byte{responder}))
+	})
+
+	It("filters swaps by currency pair in either direction", func() {
+		page, err := state.ListSwaps(SwapQuery{Currencies: []uint32{1, 0}})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(page.Swaps).Should(HaveLen(3))
+
+		page, err = state.ListSwaps(SwapQuery{Currencies: []uint32{0, 2}})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(page.Swaps).Should(BeEmpty())
+	})
+
+	It("pages through the swaps in both orders", func() {
+		for _, descending := range []bool{false, true} {
+			seen := [][32]byte{}
+			query := SwapQuery{Limit: 2, Descending: descending}
+			for {
+				page, err := state.ListSwaps(query)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(len(page.Swaps)).Should(BeNumerically("<=", 2))
+				seen = append(seen, orderIDs(page)...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+			Expect(seen).Should(ConsistOf(requestor, responder, redeemed))
+		}
+	})
+
+	It("rejects an invalid cursor", func() {
+		_, err := state.ListSwaps(SwapQuery{Cursor: "not a cursor"})
+		Expect(err).Should(Equal(ErrInvalidCursor))
+	})
+})
@@ -0,0 +1,483 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SchemaVersion is the version of the records written by this version of the
+// swapper.
+const SchemaVersion = 4
+
+var schemaVersionKey = []byte("Schema Version:")
+
+var ErrUnknownSchemaVersion = errors.New("swap store was written by a newer version of the swapper")
+
+var errStoreNotEmpty = errors.New("store is not empty")
+
+// Migration upgrades the records of a Store from the previous schema version
+// to Version. It reads from the Store and writes its changes to the Batch, so
+// that the changes and the new version are committed together.
+type Migration struct {
+	Version     int
+	Description string
+	Migrate     func(Store, Batch) error
+}
+
+// Migrations are applied in order to bring a Store up to the SchemaVersion.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "separate the redeemable prefix from the order id",
+		Migrate:     migrateRedeemable,
+	},
+	{
+		Version:     2,
+		Description: "store each pending swap under its own key",
+		Migrate:     migratePendingSwaps,
+	},
+	{
+		Version:     3,
+		Description: "archive the swaps that finished before the archive was introduced",
+		Migrate:     migrateFinishedSwaps,
+	},
+	{
+		Version:     4,
+		Description: "set refund timers for the atoms that the swapper initiated",
+		Migrate:     migrateRefundTimers,
+	},
+}
+
+// MigrateOptions configure Migrate. If DryRun is set the migrations are run
+// against a copy of the records and the Store is left untouched. If Backup is
+// not nil, all records are written to it before any migration is applied,
+// with the sensitive records encrypted using the Cipher.
+type MigrateOptions struct {
+	DryRun bool
+	Backup io.Writer
+	Cipher Cipher
+}
+
+// MigrationReport describes the migrations that were, or in a dry run would
+// have been, applied to a Store.
+type MigrationReport struct {
+	From    int
+	To      int
+	Applied []string
+	Records int
+}
+
+type schemaVersion struct {
+	Version int `json:"version"`
+}
+
+// LoadSchemaVersion returns the schema version of the Store. A Store that has
+// records but no version was written before versioning was introduced, and is
+// at version 0. An empty Store, or one that only has its cipher records, is at
+// the current SchemaVersion.
+func LoadSchemaVersion(store Store) (int, error) {
+	versionBytes, err := store.Read(schemaVersionKey)
+	if err == nil {
+		version := schemaVersion{}
+		if err := json.Unmarshal(versionBytes, &version); err != nil {
+			return 0, err
+		}
+		return version.Version, nil
+	}
+	if err != ErrKeyNotFound {
+		return 0, err
+	}
+
+	if err := store.Iterate(nil, func(key, value []byte) error {
+		if localKeys[string(key)] {
+			return nil
+		}
+		return errStoreNotEmpty
+	}); err != nil {
+		if err == errStoreNotEmpty {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return SchemaVersion, nil
+}
+
+// Migrate brings the Store up to the current SchemaVersion. Each migration is
+// committed atomically along with the version it upgrades to, so a swapper
+// that is stopped part way through resumes from the last completed migration.
+func Migrate(store Store, options MigrateOptions) (MigrationReport, error) {
+	version, err := LoadSchemaVersion(store)
+	if err != nil {
+		return MigrationReport{}, err
+	}
+	report := MigrationReport{
+		From: version,
+		To:   version,
+	}
+	if version > SchemaVersion {
+		return report, ErrUnknownSchemaVersion
+	}
+	if version == SchemaVersion {
+		// Stores created by this version of the swapper are marked so that
+		// they are not mistaken for old stores once they have records.
+		if options.DryRun {
+			return report, nil
+		}
+		return report, writeSchemaVersion(store, version)
+	}
+
+	if options.Backup != nil {
+		if err := Backup(store, options.Cipher, options.Backup); err != nil {
+			return report, fmt.Errorf("cannot back up the store: %v", err)
+		}
+	}
+
+	if options.DryRun {
+		snapshot := newSnapshotStore()
+		if err := copyRecords(store, snapshot); err != nil {
+			return report, err
+		}
+		store = snapshot
+	}
+
+	for _, migration := range Migrations {
+		if migration.Version <= version {
+			continue
+		}
+		batch := &countingBatch{Batch: store.NewBatch()}
+		if err := migration.Migrate(store, batch); err != nil {
+			return report, fmt.Errorf("cannot migrate the store to version %d: %v", migration.Version, err)
+		}
+		versionBytes, err := json.Marshal(schemaVersion{migration.Version})
+		if err != nil {
+			return report, err
+		}
+		batch.Batch.Write(schemaVersionKey, versionBytes)
+		if err := batch.Commit(); err != nil {
+			return report, err
+		}
+		report.To = migration.Version
+		report.Applied = append(report.Applied, migration.Description)
+		report.Records += batch.n
+	}
+	return report, nil
+}
+
+// migratedCopy returns the Store if it is at the current SchemaVersion, and a
+// migrated copy of it otherwise.
+func migratedCopy(store Store) (Store, error) {
+	version, err := LoadSchemaVersion(store)
+	if err != nil {
+		return nil, err
+	}
+	if version == SchemaVersion {
+		return store, nil
+	}
+	snapshot := newSnapshotStore()
+	if err := copyRecords(store, snapshot); err != nil {
+		return nil, err
+	}
+	if _, err := Migrate(snapshot, MigrateOptions{}); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func writeSchemaVersion(store Store, version int) error {
+	versionBytes, err := json.Marshal(schemaVersion{version})
+	if err != nil {
+		return err
+	}
+	return store.Write(schemaVersionKey, versionBytes)
+}
+
+// Record is a key-value pair in a backup.
+type Record struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type backup struct {
+	Version int      `json:"version"`
+	Records []Record `json:"records"`
+}
+
+// Backup writes every record in the Store to the Writer as JSON, along with
+// the schema version of the records. Sensitive records that were written in
+// plain text are encrypted using the Cipher, or left out of the backup if the
+// Cipher is nil, so that secrets are never written to the backup in plain
+// text.
+func Backup(store Store, c Cipher, w io.Writer) error {
+	version, err := LoadSchemaVersion(store)
+	if err != nil {
+		return err
+	}
+	b := backup{
+		Version: version,
+		Records: []Record{},
+	}
+	if err := store.Iterate(nil, func(key, value []byte) error {
+		value = append([]byte{}, value...)
+		if isSensitive(key) && !IsEncrypted(value) {
+			if c == nil {
+				return nil
+			}
+			sealed, err := c.Encrypt(value)
+			if err != nil {
+				return err
+			}
+			value = sealed
+		}
+		b.Records = append(b.Records, Record{
+			Key:   append([]byte{}, key...),
+			Value: value,
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(b)
+}
+
+// Restore writes the records of a backup to the Store and returns the schema
+// version they were written with. Records in the Store that are not in the
+// backup are left untouched.
+func Restore(store Store, r io.Reader) (int, error) {
+	b := backup{}
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return 0, err
+	}
+	batch := store.NewBatch()
+	for _, record := range b.Records {
+		batch.Write(record.Key, record.Value)
+	}
+	if b.Version > 0 {
+		versionBytes, err := json.Marshal(schemaVersion{b.Version})
+		if err != nil {
+			return 0, err
+		}
+		batch.Write(schemaVersionKey, versionBytes)
+	}
+	return b.Version, batch.Commit()
+}
+
+// migrateRedeemable moves the "Redeemable" records, which were written without
+// a separator, under the "Redeemable:" prefix.
+func migrateRedeemable(store Store, batch Batch) error {
+	prefix := []byte("Redeemable")
+	return store.Iterate(prefix, func(key, value []byte) error {
+		if len(key) != len(prefix)+32 {
+			return nil
+		}
+		orderID := key[len(prefix):]
+		batch.Write(append([]byte("Redeemable:"), orderID...), append([]byte{}, value...))
+		batch.Delete(append([]byte{}, key...))
+		return nil
+	})
+}
+
+// migratePendingSwaps replaces the "Pending Swaps:" list with a "Pending
+// Swap:" record for each swap, so that adding and removing a swap no longer
+// rewrites the whole list.
+func migratePendingSwaps(store Store, batch Batch) error {
+	pendingSwapsBytes, err := store.Read([]byte("Pending Swaps:"))
+	if err == ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pendingSwaps := PendingSwaps{}
+	if err := json.Unmarshal(pendingSwapsBytes, &pendingSwaps); err != nil {
+		return err
+	}
+	for _, orderID := range pendingSwaps.Swaps {
+		batch.Write(pendingSwapKey(orderID), orderID[:])
+	}
+	batch.Delete([]byte("Pending Swaps:"))
+	return nil
+}
+
+// migrateFinishedSwaps archives the swaps that were deleted from the pending
+// swaps when they finished. The time at which they finished is not known.
+func migrateFinishedSwaps(store Store, batch Batch) error {
+	prefix := []byte("Status:")
+	finished := [][32]byte{}
+	outcomes := map[[32]byte]string{}
+	if err := store.Iterate(prefix, func(key, value []byte) error {
+		if len(key) != len(prefix)+32 {
+			return nil
+		}
+		swapStatus := SwapStatus{}
+		if err := json.Unmarshal(value, &swapStatus); err != nil {
+			return nil
+		}
+		if !settledOutcomes[swapStatus.Status] {
+			return nil
+		}
+		var orderID [32]byte
+		copy(orderID[:], key[len(prefix):])
+		finished = append(finished, orderID)
+		outcomes[orderID] = swapStatus.Status
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, orderID := range finished {
+		if _, err := store.Read(pendingSwapKey(orderID)); err == nil {
+			continue
+		}
+		if _, err := store.Read(archiveKey(orderID)); err == nil {
+			continue
+		}
+		if err := archiveSwap(store, batch, orderID, outcomes[orderID], 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateRefundTimers sets a refund timer for every swap whose atom was
+// initiated by the swapper and has not been redeemed or refunded, so that the
+// guardian refunds it even if it was never complained about.
+func migrateRefundTimers(store Store, batch Batch) error {
+	prefix := []byte("Redeemable:")
+	initiated := [][32]byte{}
+	if err := store.Iterate(prefix, func(key, value []byte) error {
+		if len(key) != len(prefix)+32 {
+			return nil
+		}
+		var orderID [32]byte
+		copy(orderID[:], key[len(prefix):])
+		initiated = append(initiated, orderID)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, orderID := range initiated {
+		detailsBytes, err := store.Read(append([]byte("Initiate Details:"), orderID[:]...))
+		if err != nil {
+			continue
+		}
+		details := SwapInitiateDetails{}
+		if err := json.Unmarshal(detailsBytes, &details); err != nil {
+			continue
+		}
+		timerBytes, err := json.Marshal(RefundTimer{
+			OrderID: orderID,
+			Expiry:  details.Expiry,
+		})
+		if err != nil {
+			return err
+		}
+		batch.Write(refundTimerKey(orderID), timerBytes)
+	}
+	return nil
+}
+
+func copyRecords(from, to Store) error {
+	batch := to.NewBatch()
+	if err := from.Iterate(nil, func(key, value []byte) error {
+		batch.Write(append([]byte{}, key...), append([]byte{}, value...))
+		return nil
+	}); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+type countingBatch struct {
+	Batch
+	n int
+}
+
+func (batch *countingBatch) Write(key, value []byte) {
+	batch.n++
+	batch.Batch.Write(key, value)
+}
+
+func (batch *countingBatch) Delete(key []byte) {
+	batch.n++
+	batch.Batch.Delete(key)
+}
+
+// snapshotStore is an in-memory copy of a Store that dry runs are applied to.
+type snapshotStore struct {
+	records map[string][]byte
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{
+		records: map[string][]byte{},
+	}
+}
+
+func (store *snapshotStore) Read(key []byte) ([]byte, error) {
+	value, ok := store.records[string(key)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (store *snapshotStore) Write(key, value []byte) error {
+	store.records[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (store *snapshotStore) Delete(key []byte) error {
+	delete(store.records, string(key))
+	return nil
+}
+
+func (store *snapshotStore) Iterate(prefix []byte, f func(key, value []byte) error) error {
+	keys := []string{}
+	for key := range store.records {
+		if len(key) >= len(prefix) && key[:len(prefix)] == string(prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := f([]byte(key), store.records[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *snapshotStore) NewBatch() Batch {
+	return &snapshotBatch{store: store}
+}
+
+func (store *snapshotStore) Close() error {
+	return nil
+}
+
+type snapshotBatch struct {
+	store *snapshotStore
+	ops   []func()
+}
+
+func (batch *snapshotBatch) Write(key, value []byte) {
+	key, value = append([]byte{}, key...), append([]byte{}, value...)
+	batch.ops = append(batch.ops, func() { batch.store.Write(key, value) })
+}
+
+func (batch *snapshotBatch) Delete(key []byte) {
+	key = append([]byte{}, key...)
+	batch.ops = append(batch.ops, func() { batch.store.Delete(key) })
+}
+
+func (batch *snapshotBatch) Commit() error {
+	for _, op := range batch.ops {
+		op()
+	}
+	batch.ops = nil
+	return nil
+}
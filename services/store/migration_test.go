@@ -0,0 +1,153 @@
+package store_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Migrations", func() {
+	var db Store
+
+	orderID1 := [32]byte{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	orderID2 := [32]byte{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2}
+	finishedOrderID := [32]byte{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3}
+
+	loadFixture := func(version int) {
+		f, err := os.Open(fmt.Sprintf("testdata/v%d.json", version))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer f.Close()
+		restored, err := Restore(db, f)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(restored).Should(Equal(version))
+	}
+
+	BeforeEach(func() {
+		db = memory.NewMemoryStore()
+	})
+
+	for _, version := range []int{0, 1, 2, 3} {
+		version := version
+
+		It(fmt.Sprintf("upgrades a store from version %d", version), func() {
+			loadFixture(version)
+			Expect(LoadSchemaVersion(db)).Should(Equal(version))
+
+			report, err := Migrate(db, MigrateOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(report.From).Should(Equal(version))
+			Expect(report.To).Should(Equal(SchemaVersion))
+			Expect(report.Applied).Should(HaveLen(SchemaVersion - version))
+			Expect(LoadSchemaVersion(db)).Should(Equal(SchemaVersion))
+
+			state := NewState(db, nil, loggerAdapter.NewStdOutLogger())
+			Expect(state.IsRedeemable(orderID1)).Should(BeTrue())
+			Expect(state.IsRedeemable(orderID2)).Should(BeFalse())
+			Expect(state.Status(orderID1)).Should(Equal("INITIATED"))
+			expiry, _, err := state.InitiateDetails(orderID1)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(expiry).Should(Equal(int64(1530000000)))
+			Expect(state.RefundTimers()).Should(Equal([]RefundTimer{{OrderID: orderID1, Expiry: 1530000000}}))
+
+			swaps, err := state.ExecutableSwaps(true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(swaps).Should(ConsistOf(orderID1, orderID2))
+
+			summary, err := state.ArchivedSwap(finishedOrderID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(summary.Outcome).Should(Equal("REDEEMED"))
+			Expect(summary.Role).Should(Equal(RoleRequestor))
+			Expect(summary.SendValue.Int64()).Should(Equal(int64(100000)))
+
+			Expect(state.ArchiveSwap(orderID1, "REDEEMED")).ShouldNot(HaveOccurred())
+			swaps, err = state.ExecutableSwaps(true)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(swaps).Should(Equal([][32]byte{orderID2}))
+		})
+
+		It(fmt.Sprintf("does not modify a version %d store in a dry run", version), func() {
+			loadFixture(version)
+			before := new(bytes.Buffer)
+			Expect(Backup(db, nil, before)).ShouldNot(HaveOccurred())
+
+			backup := new(bytes.Buffer)
+			report, err := Migrate(db, MigrateOptions{DryRun: true, Backup: backup})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(report.To).Should(Equal(SchemaVersion))
+			Expect(report.Records).Should(BeNumerically(">", 0))
+			Expect(LoadSchemaVersion(db)).Should(Equal(version))
+
+			after := new(bytes.Buffer)
+			Expect(Backup(db, nil, after)).ShouldNot(HaveOccurred())
+			Expect(after.String()).Should(Equal(before.String()))
+			Expect(backup.String()).Should(Equal(before.String()))
+		})
+	}
+
+	It("never writes secrets to a backup in plain text", func() {
+		secret := [32]byte{9}
+		Expect(NewState(db, nil, loggerAdapter.NewStdOutLogger()).PutRedeemDetails(orderID1, secret)).ShouldNot(HaveOccurred())
+		redeemDetails := func(backup *bytes.Buffer) []Record {
+			b := struct {
+				Records []Record `json:"records"`
+			}{}
+			Expect(json.Unmarshal(backup.Bytes(), &b)).ShouldNot(HaveOccurred())
+			records := []Record{}
+			for _, record := range b.Records {
+				if bytes.HasPrefix(record.Key, []byte("Redeem Details:")) {
+					records = append(records, record)
+				}
+			}
+			return records
+		}
+
+		plain := new(bytes.Buffer)
+		Expect(Backup(db, nil, plain)).ShouldNot(HaveOccurred())
+		Expect(redeemDetails(plain)).Should(BeEmpty())
+
+		cipher, err := LoadCipher(db, "passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		encrypted := new(bytes.Buffer)
+		Expect(Backup(db, cipher, encrypted)).ShouldNot(HaveOccurred())
+		records := redeemDetails(encrypted)
+		Expect(records).Should(HaveLen(1))
+		Expect(IsEncrypted(records[0].Value)).Should(BeTrue())
+
+		restored := memory.NewMemoryStore()
+		_, err = Restore(restored, encrypted)
+		Expect(err).ShouldNot(HaveOccurred())
+		cipher, err = LoadCipher(restored, "passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(NewState(restored, cipher, loggerAdapter.NewStdOutLogger()).RedeemDetails(orderID1)).Should(Equal(secret))
+	})
+
+	It("does not mistake a store that only has its cipher records for an old store", func() {
+		_, err := LoadCipher(db, "passphrase")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(LoadSchemaVersion(db)).Should(Equal(SchemaVersion))
+	})
+
+	It("marks an empty store with the current version", func() {
+		report, err := Migrate(db, MigrateOptions{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(report.Applied).Should(BeEmpty())
+
+		state := NewState(db, nil, loggerAdapter.NewStdOutLogger())
+		Expect(state.AddSwap(orderID1)).ShouldNot(HaveOccurred())
+		Expect(LoadSchemaVersion(db)).Should(Equal(SchemaVersion))
+	})
+
+	It("refuses to downgrade a store", func() {
+		Expect(db.Write([]byte("Schema Version:"), []byte(fmt.Sprintf(`{"version":%d}`, SchemaVersion+1)))).ShouldNot(HaveOccurred())
+		_, err := Migrate(db, MigrateOptions{})
+		Expect(err).Should(Equal(ErrUnknownSchemaVersion))
+	})
+})
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// OutboxMessage is a message that is waiting to be delivered, such as a
+// complaint to the watchdog, and the attempts that have been made to deliver
+// it. Messages are deleted once they have been delivered.
+type OutboxMessage struct {
+	ID        [32]byte        `json:"id"`
+	Body      json.RawMessage `json:"body"`
+	QueuedAt  int64           `json:"queuedAt"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"lastError,omitempty"`
+	RetryAt   int64           `json:"retryAt,omitempty"`
+}
+
+func outboxKey(id [32]byte) []byte {
+	return append([]byte("Outbox:"), id[:]...)
+}
+
+func (tx *transaction) PutOutboxMessage(message OutboxMessage) error {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(outboxKey(message.ID), messageBytes)
+	return nil
+}
+
+func (state *state) PutOutboxMessage(message OutboxMessage) error {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return state.Write(outboxKey(message.ID), messageBytes)
+}
+
+func (state *state) DeleteOutboxMessage(id [32]byte) error {
+	return state.Delete(outboxKey(id))
+}
+
+func (state *state) OutboxMessage(id [32]byte) (OutboxMessage, error) {
+	message := OutboxMessage{}
+	messageBytes, err := state.Read(outboxKey(id))
+	if err != nil {
+		return message, err
+	}
+	if err := json.Unmarshal(messageBytes, &message); err != nil {
+		return message, err
+	}
+	return message, nil
+}
+
+// OutboxMessages returns the messages that are waiting to be delivered, the
+// oldest first.
+func (state *state) OutboxMessages() ([]OutboxMessage, error) {
+	messages := []OutboxMessage{}
+	if err := state.Iterate([]byte("Outbox:"), func(key, value []byte) error {
+		message := OutboxMessage{}
+		if err := json.Unmarshal(value, &message); err != nil {
+			return err
+		}
+		messages = append(messages, message)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].QueuedAt < messages[j].QueuedAt
+	})
+	return messages, nil
+}
@@ -0,0 +1,51 @@
+package store_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("Outbox", func() {
+	var state State
+
+	BeforeEach(func() {
+		state = NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+	})
+
+	It("queues messages with a transaction and returns them oldest first", func() {
+		tx := state.NewTransaction()
+		Expect(tx.PutOutboxMessage(OutboxMessage{ID: [32]byte{1}, Body: json.RawMessage(`{"n":1}`), QueuedAt: 200})).ShouldNot(HaveOccurred())
+		Expect(tx.PutOutboxMessage(OutboxMessage{ID: [32]byte{2}, Body: json.RawMessage(`{"n":2}`), QueuedAt: 100})).ShouldNot(HaveOccurred())
+		Expect(state.OutboxMessages()).Should(BeEmpty())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		messages, err := state.OutboxMessages()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(messages).Should(HaveLen(2))
+		Expect(messages[0].ID).Should(Equal([32]byte{2}))
+		Expect(messages[1].ID).Should(Equal([32]byte{1}))
+		Expect(string(messages[1].Body)).Should(Equal(`{"n":1}`))
+	})
+
+	It("records failed attempts and deletes delivered messages", func() {
+		Expect(state.PutOutboxMessage(OutboxMessage{ID: [32]byte{1}, QueuedAt: 100})).ShouldNot(HaveOccurred())
+
+		message, err := state.OutboxMessage([32]byte{1})
+		Expect(err).ShouldNot(HaveOccurred())
+		message.Attempts++
+		message.LastError = "connection refused"
+		Expect(state.PutOutboxMessage(message)).ShouldNot(HaveOccurred())
+		Expect(state.OutboxMessage([32]byte{1})).Should(Equal(message))
+
+		Expect(state.DeleteOutboxMessage([32]byte{1})).ShouldNot(HaveOccurred())
+		_, err = state.OutboxMessage([32]byte{1})
+		Expect(err).Should(Equal(ErrKeyNotFound))
+		Expect(state.OutboxMessages()).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// RefundTimer records when the atom that the swapper initiated for a swap
+// expires, so that it is refunded if the swap does not complete. Timers are
+// deleted when the swap is redeemed or refunded.
+type RefundTimer struct {
+	OrderID [32]byte `json:"orderId"`
+	Expiry  int64    `json:"expiry"`
+}
+
+func refundTimerKey(orderID [32]byte) []byte {
+	return append([]byte("Refund Timer:"), orderID[:]...)
+}
+
+func (tx *transaction) PutRefundTimer(orderID [32]byte, expiry int64) error {
+	timerBytes, err := json.Marshal(RefundTimer{
+		OrderID: orderID,
+		Expiry:  expiry,
+	})
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(refundTimerKey(orderID), timerBytes)
+	return nil
+}
+
+func (tx *transaction) DeleteRefundTimer(orderID [32]byte) error {
+	tx.batch.Delete(refundTimerKey(orderID))
+	return nil
+}
+
+// RefundTimers returns the refund timers, the earliest expiry first.
+func (state *state) RefundTimers() ([]RefundTimer, error) {
+	timers := []RefundTimer{}
+	if err := state.Iterate([]byte("Refund Timer:"), func(key, value []byte) error {
+		timer := RefundTimer{}
+		if err := json.Unmarshal(value, &timer); err != nil {
+			return err
+		}
+		timers = append(timers, timer)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].Expiry < timers[j].Expiry
+	})
+	return timers, nil
+}
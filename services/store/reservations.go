@@ -0,0 +1,70 @@
+package store
+
+import (
+	"encoding/json"
+	"math/big"
+)
+
+// Reservation records the funds that an order was checked to need when it was
+// posted, so that they are not used by other orders before it is matched.
+// Reservations are deleted when the order is matched or archived.
+type Reservation struct {
+	Currency uint32   `json:"currency"`
+	Amount   *big.Int `json:"amount"`
+}
+
+func reservationKey(orderID [32]byte) []byte {
+	return append([]byte("Reservation:"), orderID[:]...)
+}
+
+func (state *state) PutReservation(orderID [32]byte, reservation Reservation) error {
+	return state.update(func(tx Transaction) error {
+		return tx.PutReservation(orderID, reservation)
+	})
+}
+
+func (tx *transaction) PutReservation(orderID [32]byte, reservation Reservation) error {
+	reservationBytes, err := json.Marshal(reservation)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(reservationKey(orderID), reservationBytes)
+	return nil
+}
+
+func (state *state) Reservation(orderID [32]byte) (Reservation, error) {
+	reservation := Reservation{}
+	reservationBytes, err := state.Read(reservationKey(orderID))
+	if err != nil {
+		return reservation, err
+	}
+	if err := json.Unmarshal(reservationBytes, &reservation); err != nil {
+		return reservation, err
+	}
+	return reservation, nil
+}
+
+// Reservations returns the reservations of every order, whether or not it has
+// been added to the pending swaps yet.
+func (state *state) Reservations() (map[[32]byte]Reservation, error) {
+	reservations := map[[32]byte]Reservation{}
+	prefix := []byte("Reservation:")
+	if err := state.Iterate(prefix, func(key, value []byte) error {
+		reservation := Reservation{}
+		if err := json.Unmarshal(value, &reservation); err != nil {
+			return err
+		}
+		var orderID [32]byte
+		copy(orderID[:], key[len(prefix):])
+		reservations[orderID] = reservation
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (tx *transaction) DeleteReservation(orderID [32]byte) error {
+	tx.batch.Delete(reservationKey(orderID))
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
 	"github.com/republicprotocol/renex-swapper-go/services/logger"
 )
 
@@ -35,7 +36,8 @@ type SwapMatch struct {
 	ReceiveCurrency uint32   `json:"receiveCurrency"`
 }
 
-// PendingSwaps stores all the swaps that are pending
+// PendingSwaps stores all the swaps that are pending in stores before schema
+// version 2
 type PendingSwaps struct {
 	Swaps [][32]byte `json:"pendingSwaps"`
 }
@@ -43,15 +45,18 @@ type PendingSwaps struct {
 type state struct {
 	logger.Logger
 	Store
+	cipher Cipher
 	swapMu *sync.RWMutex
 }
 
 type State interface {
 	AddSwap([32]byte) error
-	DeleteSwap([32]byte) error
+	ArchiveSwap([32]byte, string) error
 	ExecutableSwaps(bool) ([][32]byte, error)
 	RefundableSwaps() ([][32]byte, error)
 
+	NewTransaction() Transaction
+
 	InitiateDetails([32]byte) (int64, [32]byte, error)
 	PutInitiateDetails([32]byte, int64, [32]byte) error
 
@@ -72,12 +77,47 @@ type State interface {
 	IsRedeemable([32]byte) bool
 	Complained([32]byte) bool
 	Redeemed([32]byte) error
+	RefundTimers() ([]RefundTimer, error)
+
+	Transactions([32]byte) (swapDomain.Transactions, error)
+	PutTransactions([32]byte, swapDomain.Transactions) error
+	History([32]byte) ([]StatusChange, error)
+
+	ArchivedSwap([32]byte) (SwapSummary, error)
+	ArchivedSwaps(ArchiveQuery) ([]SwapSummary, error)
+	PruneArchive(int64) (int, error)
+
+	PendingSwap([32]byte) (SwapSummary, error)
+	PendingSwaps() ([]SwapSummary, error)
+	ListSwaps(SwapQuery) (SwapPage, error)
+
+	CounterpartyTransactions([32]byte) (swapDomain.Transactions, error)
+	Complaint([32]byte) (Complaint, error)
+	PutError([32]byte, string) error
+	ClearError([32]byte) error
+	Error([32]byte) (SwapError, error)
+
+	PutReservation([32]byte, Reservation) error
+	Reservation([32]byte) (Reservation, error)
+	Reservations() (map[[32]byte]Reservation, error)
+
+	PutWithdrawal(Withdrawal) error
+	Withdrawal([32]byte) (Withdrawal, error)
+	Withdrawals() ([]Withdrawal, error)
+
+	PutOutboxMessage(OutboxMessage) error
+	DeleteOutboxMessage([32]byte) error
+	OutboxMessage([32]byte) (OutboxMessage, error)
+	OutboxMessages() ([]OutboxMessage, error)
 }
 
-func NewState(store Store, logger logger.Logger) State {
+// NewState returns a State that encrypts secrets and atom details using the
+// Cipher. If the Cipher is nil, they are stored in plain text.
+func NewState(store Store, cipher Cipher, logger logger.Logger) State {
 	return &state{
 		Store:  store,
 		Logger: logger,
+		cipher: cipher,
 		swapMu: new(sync.RWMutex),
 	}
 }
@@ -85,77 +125,29 @@ func NewState(store Store, logger logger.Logger) State {
 func (state *state) AddSwap(orderID [32]byte) error {
 	state.swapMu.Lock()
 	defer state.swapMu.Unlock()
-	pendingSwapsRawBytes, err := state.Read([]byte("Pending Swaps:"))
-	pendingSwaps := PendingSwaps{}
-
-	if err == nil {
-		if err := json.Unmarshal(pendingSwapsRawBytes, &pendingSwaps); err != nil {
-			return err
-		}
-	}
-
-	pendingSwaps.Swaps = append(pendingSwaps.Swaps, orderID)
-
-	pendingSwapsProcessedBytes, err := json.Marshal(pendingSwaps)
-	if err != nil {
-		return err
-	}
-
-	return state.Write([]byte("Pending Swaps:"), pendingSwapsProcessedBytes)
+	return state.update(func(tx Transaction) error {
+		return tx.AddSwap(orderID)
+	})
 }
 
-func (state *state) DeleteSwap(orderID [32]byte) error {
-	state.swapMu.Lock()
-	defer state.swapMu.Unlock()
-	defer state.LogInfo(orderID, "deleted the swap and its details")
-
-	pendingSwapsRawBytes, err := state.Read([]byte("Pending Swaps:"))
-	if err != nil {
-		return err
-	}
-
-	pendingSwaps := PendingSwaps{}
-	if err := json.Unmarshal(pendingSwapsRawBytes, &pendingSwaps); err != nil {
-		return err
-	}
-
-	for i, swap := range pendingSwaps.Swaps {
-		if swap == orderID {
-			if len(pendingSwaps.Swaps) == 1 {
-				pendingSwaps.Swaps = [][32]byte{}
-				break
-			}
-
-			if i == 0 {
-				pendingSwaps.Swaps = pendingSwaps.Swaps[1:]
-				break
-			}
-
-			pendingSwaps.Swaps = append(pendingSwaps.Swaps[:i-1], pendingSwaps.Swaps[i:]...)
-			break
-		}
-	}
-
-	pendingSwapsProcessedBytes, err := json.Marshal(pendingSwaps)
-	if err != nil {
-		return err
-	}
-
-	return state.Write([]byte("Pending Swaps:"), pendingSwapsProcessedBytes)
+// AddSwap adds the swap to the pending swaps along with the other updates in
+// the transaction.
+func (tx *transaction) AddSwap(orderID [32]byte) error {
+	tx.batch.Write(pendingSwapKey(orderID), orderID[:])
+	return nil
 }
 
 func (state *state) pendingSwaps() ([][32]byte, error) {
-	pendingSwapsBytes, err := state.Read([]byte("Pending Swaps:"))
-	if err != nil {
-		return [][32]byte{}, nil
-	}
-
-	pendingSwaps := PendingSwaps{}
-	if err := json.Unmarshal(pendingSwapsBytes, &pendingSwaps); err != nil {
+	pendingSwaps := [][32]byte{}
+	if err := state.Iterate([]byte("Pending Swap:"), func(key, value []byte) error {
+		var orderID [32]byte
+		copy(orderID[:], value)
+		pendingSwaps = append(pendingSwaps, orderID)
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-
-	return pendingSwaps.Swaps, nil
+	return pendingSwaps, nil
 }
 
 func (state *state) ExecutableSwaps(fullsync bool) ([][32]byte, error) {
@@ -210,15 +202,9 @@ func (state *state) RefundableSwaps() ([][32]byte, error) {
 }
 
 func (state *state) PutInitiateDetails(orderID [32]byte, expiry int64, hashLock [32]byte) error {
-	swapInitiateDetails := SwapInitiateDetails{
-		Expiry:   expiry,
-		HashLock: hashLock,
-	}
-	initiateDetailsBytes, err := json.Marshal(swapInitiateDetails)
-	if err != nil {
-		return err
-	}
-	return state.Write(append([]byte("Initiate Details:"), orderID[:]...), initiateDetailsBytes)
+	return state.update(func(tx Transaction) error {
+		return tx.PutInitiateDetails(orderID, expiry, hashLock)
+	})
 }
 
 func (state *state) InitiateDetails(orderID [32]byte) (int64, [32]byte, error) {
@@ -236,18 +222,13 @@ func (state *state) InitiateDetails(orderID [32]byte) (int64, [32]byte, error) {
 }
 
 func (state *state) PutRedeemDetails(orderID [32]byte, secret [32]byte) error {
-	swapRedeemDetails := SwapRedeemDetails{
-		Secret: secret,
-	}
-	redeemDetailsBytes, err := json.Marshal(swapRedeemDetails)
-	if err != nil {
-		return err
-	}
-	return state.Write(append([]byte("Redeem Details:"), orderID[:]...), redeemDetailsBytes)
+	return state.update(func(tx Transaction) error {
+		return tx.PutRedeemDetails(orderID, secret)
+	})
 }
 
 func (state *state) RedeemDetails(orderID [32]byte) ([32]byte, error) {
-	redeemDetailsBytes, err := state.Read(append([]byte("Redeem Details:"), orderID[:]...))
+	redeemDetailsBytes, err := state.readSensitive(append([]byte("Redeem Details:"), orderID[:]...))
 	if err != nil {
 		return [32]byte{}, err
 	}
@@ -261,14 +242,9 @@ func (state *state) RedeemDetails(orderID [32]byte) ([32]byte, error) {
 }
 
 func (state *state) PutStatus(orderID [32]byte, status string) error {
-	swapStatus := SwapStatus{
-		Status: status,
-	}
-	statusBytes, err := json.Marshal(swapStatus)
-	if err != nil {
-		return err
-	}
-	return state.Write(append([]byte("Status:"), orderID[:]...), statusBytes)
+	return state.update(func(tx Transaction) error {
+		return tx.PutStatus(orderID, status)
+	})
 }
 
 func (state *state) Status(orderID [32]byte) string {
@@ -285,20 +261,9 @@ func (state *state) Status(orderID [32]byte) string {
 }
 
 func (state *state) PutMatch(orderID [32]byte, m match.Match) error {
-	match := SwapMatch{
-		PersonalOrderID: m.PersonalOrderID(),
-		ForeignOrderID:  m.ForeignOrderID(),
-		SendValue:       m.SendValue(),
-		ReceiveValue:    m.ReceiveValue(),
-		SendCurrency:    m.SendCurrency(),
-		ReceiveCurrency: m.ReceiveCurrency(),
-	}
-
-	matchBytes, err := json.Marshal(match)
-	if err != nil {
-		return err
-	}
-	return state.Write(append([]byte("Match:"), orderID[:]...), matchBytes)
+	return state.update(func(tx Transaction) error {
+		return tx.PutMatch(orderID, m)
+	})
 }
 
 func (state *state) Match(orderID [32]byte) (match.Match, error) {
@@ -315,11 +280,13 @@ func (state *state) Match(orderID [32]byte) (match.Match, error) {
 }
 
 func (state *state) PutAtomDetails(orderID [32]byte, data []byte) error {
-	return state.Write(append([]byte("Atom Details:"), orderID[:]...), data)
+	return state.update(func(tx Transaction) error {
+		return tx.PutAtomDetails(orderID, data)
+	})
 }
 
 func (state *state) AtomDetails(orderID [32]byte) ([]byte, error) {
-	return state.Read(append([]byte("Atom Details:"), orderID[:]...))
+	return state.readSensitive(append([]byte("Atom Details:"), orderID[:]...))
 }
 
 func (state *state) AtomExists(orderID [32]byte) bool {
@@ -331,7 +298,7 @@ func (state *state) AtomExists(orderID [32]byte) bool {
 }
 
 func (state *state) IsRedeemable(orderID [32]byte) bool {
-	_, err := state.Read(append([]byte("Redeemable"), orderID[:]...))
+	_, err := state.Read(append([]byte("Redeemable:"), orderID[:]...))
 	if err != nil {
 		return false
 	}
@@ -339,24 +306,51 @@ func (state *state) IsRedeemable(orderID [32]byte) bool {
 }
 
 func (state *state) Complained(orderID [32]byte) bool {
-	statusBytes, err := state.Read(append([]byte("Status:"), orderID[:]...))
-	if err != nil {
-		return false
-	}
-	if string(statusBytes) != "COMPLAINED" {
-		return false
-	}
-	return true
+	return state.Status(orderID) == "COMPLAINED"
 }
 
 func (state *state) PutRedeemable(orderID [32]byte) error {
-	return state.Write(append([]byte("Redeemable"), orderID[:]...), orderID[:])
+	return state.update(func(tx Transaction) error {
+		return tx.PutRedeemable(orderID)
+	})
 }
 
 func (state *state) Redeemed(orderID [32]byte) error {
-	err := state.Delete(append([]byte("Redeemable"), orderID[:]...))
+	return state.update(func(tx Transaction) error {
+		return tx.Redeemed(orderID)
+	})
+}
+
+// NewTransaction returns a Transaction that commits all of its updates to the
+// underlying Store atomically.
+func (state *state) NewTransaction() Transaction {
+	return newTransaction(state.Store, state.cipher)
+}
+
+// readSensitive reads a record that may have been encrypted. Records written
+// in plain text by earlier versions of the swapper are returned as they are.
+func (state *state) readSensitive(key []byte) ([]byte, error) {
+	value, err := state.Read(key)
 	if err != nil {
+		return nil, err
+	}
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if state.cipher == nil {
+		return nil, ErrStoreLocked
+	}
+	return state.cipher.Decrypt(value)
+}
+
+func pendingSwapKey(orderID [32]byte) []byte {
+	return append([]byte("Pending Swap:"), orderID[:]...)
+}
+
+func (state *state) update(f func(tx Transaction) error) error {
+	tx := state.NewTransaction()
+	if err := f(tx); err != nil {
 		return err
 	}
-	return nil
+	return tx.Commit()
 }
@@ -0,0 +1,95 @@
+package store_test
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	. "github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var _ = Describe("State", func() {
+	var state State
+	var orderID, foreignOrderID [32]byte
+
+	BeforeEach(func() {
+		db := memory.NewMemoryStore()
+		state = NewState(db, nil, loggerAdapter.NewStdOutLogger())
+		rand.Read(orderID[:])
+		rand.Read(foreignOrderID[:])
+	})
+
+	It("does not apply the updates of a transaction before it is committed", func() {
+		tx := state.NewTransaction()
+		Expect(tx.PutInitiateDetails(orderID, 100, [32]byte{1})).ShouldNot(HaveOccurred())
+		Expect(tx.PutRedeemDetails(orderID, [32]byte{2})).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, "INITIATE_DETAILS_ACQUIRED")).ShouldNot(HaveOccurred())
+
+		Expect(state.Status(orderID)).Should(Equal("UNKNOWN"))
+		_, _, err := state.InitiateDetails(orderID)
+		Expect(err).Should(HaveOccurred())
+		_, err = state.RedeemDetails(orderID)
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("applies all the updates of a transaction when it is committed", func() {
+		m := match.NewMatch(orderID, foreignOrderID, big.NewInt(10), big.NewInt(20), 0, 1)
+
+		tx := state.NewTransaction()
+		Expect(tx.PutMatch(orderID, m)).ShouldNot(HaveOccurred())
+		Expect(tx.PutAtomDetails(orderID, []byte("details"))).ShouldNot(HaveOccurred())
+		Expect(tx.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, "INITIATED")).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		Expect(state.Status(orderID)).Should(Equal("INITIATED"))
+		Expect(state.IsRedeemable(orderID)).Should(BeTrue())
+		Expect(state.AtomExists(orderID)).Should(BeTrue())
+		stored, err := state.Match(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(stored.ForeignOrderID()).Should(Equal(foreignOrderID))
+		Expect(stored.SendValue().Cmp(big.NewInt(10))).Should(Equal(0))
+	})
+
+	It("knows which swaps have been complained about", func() {
+		Expect(state.Complained(orderID)).Should(BeFalse())
+		Expect(state.PutStatus(orderID, "INITIATED")).ShouldNot(HaveOccurred())
+		Expect(state.Complained(orderID)).Should(BeFalse())
+		Expect(state.PutStatus(orderID, "COMPLAINED")).ShouldNot(HaveOccurred())
+		Expect(state.Complained(orderID)).Should(BeTrue())
+	})
+
+	It("can delete keys as part of a transaction", func() {
+		Expect(state.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+
+		tx := state.NewTransaction()
+		Expect(tx.Redeemed(orderID)).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, "REDEEMED")).ShouldNot(HaveOccurred())
+		Expect(state.IsRedeemable(orderID)).Should(BeTrue())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+
+		Expect(state.IsRedeemable(orderID)).Should(BeFalse())
+		Expect(state.Status(orderID)).Should(Equal("REDEEMED"))
+	})
+
+	It("keeps refund timers in order of expiry until the swap is redeemed", func() {
+		tx := state.NewTransaction()
+		Expect(tx.PutRefundTimer(orderID, 200)).ShouldNot(HaveOccurred())
+		Expect(tx.PutRefundTimer(foreignOrderID, 100)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+		Expect(state.RefundTimers()).Should(Equal([]RefundTimer{
+			{OrderID: foreignOrderID, Expiry: 100},
+			{OrderID: orderID, Expiry: 200},
+		}))
+
+		tx = state.NewTransaction()
+		Expect(tx.Redeemed(foreignOrderID)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+		Expect(state.RefundTimers()).Should(Equal([]RefundTimer{{OrderID: orderID, Expiry: 200}}))
+	})
+})
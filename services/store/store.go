@@ -1,7 +1,28 @@
 package store
 
+import "errors"
+
+// ErrKeyNotFound is returned by a Store when reading a key that does not
+// exist.
+var ErrKeyNotFound = errors.New("key not found")
+
 type Store interface {
 	Read([]byte) ([]byte, error)
 	Write([]byte, []byte) error
 	Delete([]byte) error
+	NewBatch() Batch
+	Close() error
+
+	// Iterate calls the function for every key-value pair whose key starts
+	// with the prefix. The key and value are only valid until the function
+	// returns, and the function must not modify the Store.
+	Iterate(prefix []byte, f func(key, value []byte) error) error
+}
+
+// Batch collects writes and deletes that are applied to a Store atomically
+// when the batch is committed.
+type Batch interface {
+	Write([]byte, []byte)
+	Delete([]byte)
+	Commit() error
 }
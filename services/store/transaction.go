@@ -0,0 +1,271 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+)
+
+// Transaction collects the updates made by a single swap step. None of the
+// updates are visible until Commit is called, at which point they are written
+// to the Store all-or-nothing.
+type Transaction interface {
+	AddSwap([32]byte) error
+	PutInitiateDetails([32]byte, int64, [32]byte) error
+	PutRedeemDetails([32]byte, [32]byte) error
+	PutStatus([32]byte, string) error
+	PutMatch([32]byte, match.Match) error
+	PutAtomDetails([32]byte, []byte) error
+	PutRedeemable([32]byte) error
+	PutTransactions([32]byte, swapDomain.Transactions) error
+	PutCounterpartyTransactions([32]byte, swapDomain.Transactions) error
+	PutComplaint([32]byte, string) error
+	ResolveComplaint([32]byte, Complaint, string) error
+	PutAction([32]byte, string, string) error
+	PutFailedAttempt([32]byte, SwapError) error
+	ClearError([32]byte) error
+	PutRefundTimer([32]byte, int64) error
+	DeleteRefundTimer([32]byte) error
+	PutReservation([32]byte, Reservation) error
+	DeleteReservation([32]byte) error
+	PutOutboxMessage(OutboxMessage) error
+	ArchiveSwap([32]byte, string) error
+	Redeemed([32]byte) error
+	Commit() error
+}
+
+type transaction struct {
+	store  Store
+	batch  *pendingBatch
+	cipher Cipher
+
+	// historyTime is the time of the last history record written by the
+	// transaction, so that records written together get distinct keys.
+	historyTime time.Time
+}
+
+func newTransaction(store Store, cipher Cipher) Transaction {
+	return &transaction{
+		store:  store,
+		batch:  newPendingBatch(store.NewBatch()),
+		cipher: cipher,
+	}
+}
+
+func (tx *transaction) PutInitiateDetails(orderID [32]byte, expiry int64, hashLock [32]byte) error {
+	swapInitiateDetails := SwapInitiateDetails{
+		Expiry:   expiry,
+		HashLock: hashLock,
+	}
+	initiateDetailsBytes, err := json.Marshal(swapInitiateDetails)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Initiate Details:"), orderID[:]...), initiateDetailsBytes)
+	return nil
+}
+
+func (tx *transaction) PutRedeemDetails(orderID [32]byte, secret [32]byte) error {
+	swapRedeemDetails := SwapRedeemDetails{
+		Secret: secret,
+	}
+	redeemDetailsBytes, err := json.Marshal(swapRedeemDetails)
+	if err != nil {
+		return err
+	}
+	return tx.writeSensitive(append([]byte("Redeem Details:"), orderID[:]...), redeemDetailsBytes)
+}
+
+func (tx *transaction) PutStatus(orderID [32]byte, status string) error {
+	swapStatus := SwapStatus{
+		Status: status,
+	}
+	statusBytes, err := json.Marshal(swapStatus)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Status:"), orderID[:]...), statusBytes)
+	return tx.putHistory(orderID, StatusChange{
+		Status: status,
+	})
+}
+
+// PutAction records an action taken by an operator in the swap's history.
+func (tx *transaction) PutAction(orderID [32]byte, action, actor string) error {
+	return tx.putHistory(orderID, StatusChange{
+		Action: action,
+		Actor:  actor,
+	})
+}
+
+// PutFailedAttempt records the error that stopped the swap, and the attempt
+// that it stopped in the swap's history.
+func (tx *transaction) PutFailedAttempt(orderID [32]byte, swapErr SwapError) error {
+	if swapErr.Time == 0 {
+		swapErr.Time = time.Now().Unix()
+	}
+	errBytes, err := json.Marshal(swapErr)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Swap Error:"), orderID[:]...), errBytes)
+	return tx.putHistory(orderID, StatusChange{
+		Error:   swapErr.Message,
+		Attempt: swapErr.Attempts,
+	})
+}
+
+func (tx *transaction) putHistory(orderID [32]byte, change StatusChange) error {
+	now := time.Now()
+	if !now.After(tx.historyTime) {
+		now = tx.historyTime.Add(time.Nanosecond)
+	}
+	tx.historyTime = now
+
+	change.Time = now.Unix()
+	changeBytes, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(historyKey(orderID, now), changeBytes)
+	return nil
+}
+
+func (tx *transaction) PutMatch(orderID [32]byte, m match.Match) error {
+	match := SwapMatch{
+		PersonalOrderID: m.PersonalOrderID(),
+		ForeignOrderID:  m.ForeignOrderID(),
+		SendValue:       m.SendValue(),
+		ReceiveValue:    m.ReceiveValue(),
+		SendCurrency:    m.SendCurrency(),
+		ReceiveCurrency: m.ReceiveCurrency(),
+	}
+
+	matchBytes, err := json.Marshal(match)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Match:"), orderID[:]...), matchBytes)
+	return nil
+}
+
+func (tx *transaction) PutAtomDetails(orderID [32]byte, data []byte) error {
+	return tx.writeSensitive(append([]byte("Atom Details:"), orderID[:]...), data)
+}
+
+func (tx *transaction) PutRedeemable(orderID [32]byte) error {
+	tx.batch.Write(append([]byte("Redeemable:"), orderID[:]...), orderID[:])
+	return nil
+}
+
+func (tx *transaction) PutTransactions(orderID [32]byte, txs swapDomain.Transactions) error {
+	txsBytes, err := json.Marshal(txs)
+	if err != nil {
+		return err
+	}
+	tx.batch.Write(append([]byte("Transactions:"), orderID[:]...), txsBytes)
+	return nil
+}
+
+// Redeemed records that the swapper no longer has an atom to refund, because
+// it redeemed the counterparty's atom or refunded its own.
+func (tx *transaction) Redeemed(orderID [32]byte) error {
+	tx.batch.Delete(append([]byte("Redeemable:"), orderID[:]...))
+	tx.batch.Delete(refundTimerKey(orderID))
+	return nil
+}
+
+func (tx *transaction) writeSensitive(key, value []byte) error {
+	if tx.cipher != nil {
+		sealed, err := tx.cipher.Encrypt(value)
+		if err != nil {
+			return err
+		}
+		value = sealed
+	}
+	tx.batch.Write(key, value)
+	return nil
+}
+
+func (tx *transaction) Commit() error {
+	return tx.batch.Commit()
+}
+
+// pendingBatch remembers the writes and deletes added to a batch, so that a
+// transaction can read its own updates before they are committed.
+type pendingBatch struct {
+	Batch
+
+	// writes are the values written to each key, deleted keys have a nil
+	// value
+	writes map[string][]byte
+}
+
+func newPendingBatch(batch Batch) *pendingBatch {
+	return &pendingBatch{
+		Batch:  batch,
+		writes: map[string][]byte{},
+	}
+}
+
+func (batch *pendingBatch) Write(key, value []byte) {
+	batch.writes[string(key)] = append([]byte{}, value...)
+	batch.Batch.Write(key, value)
+}
+
+func (batch *pendingBatch) Delete(key []byte) {
+	batch.writes[string(key)] = nil
+	batch.Batch.Delete(key)
+}
+
+// pendingStore reads the Store as it will be once the batch is committed.
+type pendingStore struct {
+	Store
+	batch *pendingBatch
+}
+
+func (store pendingStore) Read(key []byte) ([]byte, error) {
+	if value, ok := store.batch.writes[string(key)]; ok {
+		if value == nil {
+			return nil, ErrKeyNotFound
+		}
+		return value, nil
+	}
+	return store.Store.Read(key)
+}
+
+func (store pendingStore) Iterate(prefix []byte, f func(key, value []byte) error) error {
+	values := map[string][]byte{}
+	if err := store.Store.Iterate(prefix, func(key, value []byte) error {
+		values[string(key)] = append([]byte{}, value...)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for key, value := range store.batch.writes {
+		if !strings.HasPrefix(key, string(prefix)) {
+			continue
+		}
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+		values[key] = value
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := f([]byte(key), values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
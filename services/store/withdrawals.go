@@ -0,0 +1,72 @@
+package store
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+)
+
+// Withdrawal statuses
+const (
+	WithdrawalPending = "PENDING"
+	WithdrawalSent    = "SENT"
+	WithdrawalFailed  = "FAILED"
+)
+
+// Withdrawal records funds that were sent from the swapper's own addresses.
+// The ID is the hash of the signed withdrawal request, so that a request can
+// not be replayed.
+type Withdrawal struct {
+	ID           [32]byte `json:"id"`
+	Currency     uint32   `json:"currency"`
+	To           string   `json:"to"`
+	Amount       *big.Int `json:"amount"`
+	AuthorizedBy string   `json:"authorizedBy"`
+	Status       string   `json:"status"`
+	TxHash       string   `json:"txHash,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	Time         int64    `json:"time"`
+}
+
+func withdrawalKey(id [32]byte) []byte {
+	return append([]byte("Withdrawal:"), id[:]...)
+}
+
+func (state *state) PutWithdrawal(withdrawal Withdrawal) error {
+	withdrawalBytes, err := json.Marshal(withdrawal)
+	if err != nil {
+		return err
+	}
+	return state.Write(withdrawalKey(withdrawal.ID), withdrawalBytes)
+}
+
+func (state *state) Withdrawal(id [32]byte) (Withdrawal, error) {
+	withdrawal := Withdrawal{}
+	withdrawalBytes, err := state.Read(withdrawalKey(id))
+	if err != nil {
+		return withdrawal, err
+	}
+	if err := json.Unmarshal(withdrawalBytes, &withdrawal); err != nil {
+		return withdrawal, err
+	}
+	return withdrawal, nil
+}
+
+// Withdrawals returns all of the withdrawals, most recent first.
+func (state *state) Withdrawals() ([]Withdrawal, error) {
+	withdrawals := []Withdrawal{}
+	if err := state.Iterate([]byte("Withdrawal:"), func(key, value []byte) error {
+		withdrawal := Withdrawal{}
+		if err := json.Unmarshal(value, &withdrawal); err != nil {
+			return err
+		}
+		withdrawals = append(withdrawals, withdrawal)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(withdrawals, func(i, j int) bool {
+		return withdrawals[i].Time > withdrawals[j].Time
+	})
+	return withdrawals, nil
+}
@@ -2,11 +2,30 @@ package swap
 
 import (
 	"math/big"
+
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
 )
 
 type Atom interface {
 	Initiate(to []byte, hash [32]byte, value *big.Int, expiry int64) error
 	Refund() error
+
+	// Refundable returns true if the atom has expired and has not been
+	// redeemed or refunded, according to the blockchain.
+	Refundable() (bool, error)
+
+	// RedeemedSecret returns the secret that the atom was redeemed with, and
+	// false if it has not been redeemed, according to the blockchain. Unlike
+	// AuditSecret it does not wait for the atom to be redeemed.
+	RedeemedSecret() ([32]byte, bool, error)
+
+	// Spent returns true if the atom has been redeemed or refunded,
+	// according to the blockchain.
+	Spent() (bool, error)
+
+	// Expiry returns the time after which the atom can no longer be redeemed
+	// and can be refunded, according to the blockchain.
+	Expiry() (int64, error)
 	AuditSecret() (secret [32]byte, err error)
 	Redeem(secret [32]byte) error
 	Audit() ([32]byte, []byte, *big.Int, int64, error)
@@ -16,4 +35,5 @@ type Atom interface {
 	GetFromAddress() ([]byte, error)
 	PriorityCode() uint32
 	RedeemedAt() (int64, error)
+	Transactions() swapDomain.Transactions
 }
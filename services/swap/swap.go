@@ -4,37 +4,82 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
 	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/watchdog"
 	"github.com/republicprotocol/renex-swapper-go/utils"
 )
 
+// ErrSwapAbandoned is returned when a swap was abandoned by an operator while
+// it was waiting for the counterparty.
+var ErrSwapAbandoned = errors.New("swap was abandoned")
+
+// errNotSubmitted and errNotRedeemed are returned while the counterparty has
+// not done its part of the swap.
+var (
+	errNotSubmitted = errors.New("the counterparty has not submitted its address")
+	errNotRedeemed  = errors.New("the counterparty has not redeemed the atom")
+)
+
+// counterpartyWait is how long the swapper waits for the counterparty to
+// submit its address and the details of its atom.
+const counterpartyWait = 24 * time.Hour
+
+// counterpartyPollInterval is how often a step that waits for the
+// counterparty checks whether it has done its part.
+const counterpartyPollInterval = 5 * time.Second
+
+// auditError is returned when the counterparty's atom does not match the
+// swap, with the value that was expected and the value that was observed as
+// evidence for the watchdog.
+type auditError struct {
+	message  string
+	expected string
+	observed string
+}
+
+func (err auditError) Error() string {
+	return err.message
+}
+
 // Swap is the interface for an atomic swap object
 type Swap interface {
 	Execute() error
 }
 
+// Blocker runs the steps of a swap that wait for the counterparty, which can
+// take hours, without holding one of the swapper's workers.
+type Blocker interface {
+	Block(orderID [32]byte, f func() error) error
+}
+
 type swap struct {
 	personalAtom Atom
 	foreignAtom  Atom
 	order        match.Match
 	swapAdapter  SwapAdapter
 	state        store.State
+	blocker      Blocker
 }
 
 // NewSwap returns a new Swap instance
-func NewSwap(personalAtom Atom, foreignAtom Atom, order match.Match, swapAdapter SwapAdapter, state store.State) Swap {
+func NewSwap(personalAtom Atom, foreignAtom Atom, order match.Match, swapAdapter SwapAdapter, state store.State, blocker Blocker) Swap {
 	return &swap{
 		personalAtom: personalAtom,
 		foreignAtom:  foreignAtom,
 		order:        order,
 		swapAdapter:  swapAdapter,
 		state:        state,
+		blocker:      blocker,
 	}
 }
 
@@ -53,7 +98,7 @@ func (swap *swap) request() error {
 	personalOrderID := swap.order.PersonalOrderID()
 	swap.swapAdapter.LogInfo(personalOrderID, "is the requestor")
 	if swap.state.Status(personalOrderID) == StatusInfoSubmitted {
-		if err := swap.generateDetails(); err != nil {
+		if err := swap.step("generate_details", swap.generateDetails); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to generate details: %v", err))
 			return fmt.Errorf("failed to generate details: %v", err)
 		}
@@ -62,7 +107,7 @@ func (swap *swap) request() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusInitiateDetailsAcquired {
-		if err := swap.initiate(); err != nil {
+		if err := swap.step("initiate", swap.initiate); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to initiate details: %v", err))
 			return fmt.Errorf("failed to initiate details: %v", err)
 		}
@@ -71,7 +116,7 @@ func (swap *swap) request() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusInitiated {
-		if err := swap.sendDetails(); err != nil {
+		if err := swap.step("send_details", swap.sendDetails); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to send details: %v", err))
 			return fmt.Errorf("failed to send details: %v", err)
 		}
@@ -80,13 +125,9 @@ func (swap *swap) request() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusSentSwapDetails {
-		if err := swap.receiveDetails(); err != nil {
-			if err := swap.swapAdapter.ComplainDelayedResponderInitiation(personalOrderID); err != nil {
-				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to complain to the watchdog: %v", err))
-				return fmt.Errorf("failed to complain to the watchdog: %v", err)
-			}
+		if err := swap.step("receive_details", swap.receiveDetails); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to receive details: %v", err))
-			if err := swap.state.PutStatus(personalOrderID, StatusComplained); err != nil {
+			if err := swap.complain(watchdog.DelayedResponderInitiation, fmt.Sprintf("the responder did not initiate the swap in time: %v", err), err); err != nil {
 				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to change the status: %v", err))
 				return fmt.Errorf("failed to change the status: %v", err)
 			}
@@ -97,13 +138,9 @@ func (swap *swap) request() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusReceivedSwapDetails {
-		if err := swap.requestorAudit(); err != nil {
-			if err := swap.swapAdapter.ComplainWrongResponderInitiation(personalOrderID); err != nil {
-				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to complain to the watch dog: %v", err))
-				return fmt.Errorf("failed to complain to the watch dog: %v", err)
-			}
+		if err := swap.step("audit", swap.requestorAudit); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to receive swap details: %v", err))
-			if err := swap.state.PutStatus(personalOrderID, StatusComplained); err != nil {
+			if err := swap.complain(watchdog.WrongResponderInitiation, fmt.Sprintf("the responder's contract failed the audit: %v", err), err); err != nil {
 				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to update the status: %v", err))
 				return fmt.Errorf("failed to update the status: %v", err)
 			}
@@ -114,7 +151,7 @@ func (swap *swap) request() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusAudited {
-		if err := swap.redeem(); err != nil {
+		if err := swap.step("redeem", swap.redeem); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to redeem: %v", err))
 			return fmt.Errorf("failed to redeem: %v", err)
 		}
@@ -131,13 +168,9 @@ func (swap *swap) respond() error {
 	swap.swapAdapter.LogInfo(personalOrderID, "is the responder")
 
 	if swap.state.Status(personalOrderID) == StatusInfoSubmitted {
-		if err := swap.receiveDetails(); err != nil {
-			if err := swap.swapAdapter.ComplainDelayedRequestorInitiation(personalOrderID); err != nil {
-				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to complain to the watch dog: %v", err))
-				return fmt.Errorf("failed to complain to the watch dog: %v", err)
-			}
+		if err := swap.step("receive_details", swap.receiveDetails); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to receive details: %v", err))
-			if err := swap.state.PutStatus(personalOrderID, StatusComplained); err != nil {
+			if err := swap.complain(watchdog.DelayedRequestorInitiation, fmt.Sprintf("the requestor did not initiate the swap in time: %v", err), err); err != nil {
 				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to change status: %v", err))
 				return fmt.Errorf("failed to change status: %v", err)
 			}
@@ -148,13 +181,9 @@ func (swap *swap) respond() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusReceivedSwapDetails {
-		if err := swap.responderAudit(); err != nil {
-			if err := swap.swapAdapter.ComplainWrongRequestorInitiation(personalOrderID); err != nil {
-				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to complain to the watch dog %v", err))
-				return fmt.Errorf("failed to complain to the watch dog %v", err)
-			}
+		if err := swap.step("audit", swap.responderAudit); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("audit failed %v", err))
-			if err := swap.state.PutStatus(personalOrderID, StatusComplained); err != nil {
+			if err := swap.complain(watchdog.WrongRequestorInitiation, fmt.Sprintf("the requestor's contract failed the audit: %v", err), err); err != nil {
 				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to update status %v", err))
 				return fmt.Errorf("failed to update status %v", err)
 			}
@@ -164,7 +193,7 @@ func (swap *swap) respond() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusAudited {
-		if err := swap.initiate(); err != nil {
+		if err := swap.step("initiate", swap.initiate); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to initiate %v", personalOrderID))
 			return fmt.Errorf("failed to initiate %v", personalOrderID)
 		}
@@ -173,7 +202,7 @@ func (swap *swap) respond() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusInitiated {
-		if err := swap.sendDetails(); err != nil {
+		if err := swap.step("send_details", swap.sendDetails); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to send details %v", personalOrderID))
 			return fmt.Errorf("failed to send details %v", personalOrderID)
 		}
@@ -182,13 +211,9 @@ func (swap *swap) respond() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusSentSwapDetails {
-		if err := swap.getRedeemDetails(); err != nil {
-			if err := swap.swapAdapter.ComplainDelayedRequestorRedemption(personalOrderID); err != nil {
-				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to complain to the watch dog %v", err))
-				return fmt.Errorf("failed to complain to the watch dog %v", err)
-			}
+		if err := swap.step("get_redeem_details", swap.getRedeemDetails); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to get redeem details %v", err))
-			if err := swap.state.PutStatus(personalOrderID, StatusComplained); err != nil {
+			if err := swap.complain(watchdog.DelayedRequestorRedemption, fmt.Sprintf("the requestor did not redeem the swap in time: %v", err), err); err != nil {
 				swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to update status %v", err))
 				return fmt.Errorf("failed to update status %v", err)
 			}
@@ -199,7 +224,7 @@ func (swap *swap) respond() error {
 	}
 
 	if swap.state.Status(personalOrderID) == StatusRedeemDetailsAcquired {
-		if err := swap.redeem(); err != nil {
+		if err := swap.step("redeem", swap.redeem); err != nil {
 			swap.swapAdapter.LogError(personalOrderID, fmt.Sprintf("failed to redeem %v", personalOrderID))
 			return fmt.Errorf("failed to redeem %v", personalOrderID)
 		}
@@ -221,17 +246,23 @@ func (swap *swap) generateDetails() error {
 	}
 	secretHash := sha256.Sum256(secret)
 
-	if err := swap.state.PutInitiateDetails(orderID, expiry, secretHash); err != nil {
+	tx := swap.state.NewTransaction()
+	if err := tx.PutInitiateDetails(orderID, expiry, secretHash); err != nil {
+		return err
+	}
+
+	if err := tx.PutRedeemDetails(orderID, secret32); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutRedeemDetails(orderID, secret32); err != nil {
+	if err := tx.PutStatus(orderID, StatusInitiateDetailsAcquired); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutStatus(orderID, StatusInitiateDetailsAcquired); err != nil {
+	if err := tx.Commit(); err != nil {
 		return err
 	}
+	swap.swapAdapter.Publish(events.Status(orderID, StatusInitiateDetailsAcquired))
 	swap.swapAdapter.LogInfo(orderID, "generated the swap details")
 	return nil
 }
@@ -244,12 +275,30 @@ func (swap *swap) initiate() error {
 	}
 	swap.swapAdapter.LogInfo(orderID, "initiating the swap")
 
-	foreignAddr, err := swap.swapAdapter.ReceiveOwnerAddress(swap.order.ForeignOrderID(), time.Now().Add(24*time.Hour).Unix())
-	if err != nil {
+	var foreignAddr []byte
+	if err := swap.wait(time.Now().Add(counterpartyWait), func() (err error) {
+		foreignAddr, err = swap.swapAdapter.ReceiveOwnerAddress(swap.order.ForeignOrderID(), 0)
+		if err == nil && len(foreignAddr) == 0 {
+			return errNotSubmitted
+		}
 		return err
+	}); err != nil {
+		return err
+	}
+
+	if swap.state.Status(orderID) == StatusAbandoned {
+		return ErrSwapAbandoned
 	}
 
-	if err = swap.personalAtom.Initiate(foreignAddr, secretHash, swap.order.SendValue(), expiry); err != nil {
+	return swap.swapAdapter.Broadcast(func() error {
+		return swap.initiateAtom(foreignAddr, secretHash, expiry)
+	})
+}
+
+// initiateAtom funds the swapper's atom and persists its details.
+func (swap *swap) initiateAtom(foreignAddr []byte, secretHash [32]byte, expiry int64) error {
+	orderID := swap.order.PersonalOrderID()
+	if err := swap.personalAtom.Initiate(foreignAddr, secretHash, swap.order.SendValue(), expiry); err != nil {
 		return err
 	}
 
@@ -258,18 +307,38 @@ func (swap *swap) initiate() error {
 		return err
 	}
 
-	if err := swap.state.PutAtomDetails(swap.order.PersonalOrderID(), details); err != nil {
+	txs, err := swap.transactions(orderID, swap.personalAtom.Transactions())
+	if err != nil {
 		return err
 	}
 
-	if err := swap.state.PutRedeemable(orderID); err != nil {
+	tx := swap.state.NewTransaction()
+	if err := tx.PutAtomDetails(swap.order.PersonalOrderID(), details); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutStatus(orderID, StatusInitiated); err != nil {
+	if err := tx.PutTransactions(orderID, txs); err != nil {
 		return err
 	}
 
+	if err := tx.PutRedeemable(orderID); err != nil {
+		return err
+	}
+
+	if err := tx.PutRefundTimer(orderID, expiry); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, StatusInitiated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.swapAdapter.Publish(events.Status(orderID, StatusInitiated))
+	swap.swapAdapter.Publish(events.Transaction(orderID, "initiate", txs.Initiate))
+
 	swap.swapAdapter.LogInfo(orderID, "initiated the swap")
 	return nil
 }
@@ -282,13 +351,19 @@ func (swap *swap) sendDetails() error {
 		return err
 	}
 	if err := swap.swapAdapter.SendSwapDetails(orderID, personalAtomBytes); err != nil {
-		log.Println("Error Here", err)
+		swap.swapAdapter.LogError(orderID, fmt.Sprintf("failed to send the swap details: %v", err))
 		return err
 	}
 
-	if err := swap.state.PutStatus(orderID, StatusSentSwapDetails); err != nil {
+	tx := swap.state.NewTransaction()
+	if err := tx.PutStatus(orderID, StatusSentSwapDetails); err != nil {
 		return err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.swapAdapter.Publish(events.Status(orderID, StatusSentSwapDetails))
 	swap.swapAdapter.LogInfo(orderID, "sent the swap details for")
 	return nil
 }
@@ -297,23 +372,37 @@ func (swap *swap) receiveDetails() error {
 	personalOrderID := swap.order.PersonalOrderID()
 	foreignOrderID := swap.order.ForeignOrderID()
 	swap.swapAdapter.LogInfo(personalOrderID, "receiving the swap details")
-	foreignAtomBytes, err := swap.swapAdapter.ReceiveSwapDetails(foreignOrderID, time.Now().Add(24*time.Hour).Unix())
-	if err != nil {
+	var foreignAtomBytes []byte
+	if err := swap.wait(time.Now().Add(counterpartyWait), func() (err error) {
+		foreignAtomBytes, err = swap.swapAdapter.ReceiveSwapDetails(foreignOrderID, 0)
+		return err
+	}); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutAtomDetails(foreignOrderID, foreignAtomBytes); err != nil {
+	tx := swap.state.NewTransaction()
+	if err := tx.PutAtomDetails(foreignOrderID, foreignAtomBytes); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutStatus(personalOrderID, StatusReceivedSwapDetails); err != nil {
+	if err := tx.PutStatus(personalOrderID, StatusReceivedSwapDetails); err != nil {
 		return err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.swapAdapter.Publish(events.Status(personalOrderID, StatusReceivedSwapDetails))
 	swap.swapAdapter.LogInfo(personalOrderID, "received the swap details")
 	return nil
 }
 
 func (swap *swap) redeem() error {
+	return swap.swapAdapter.Broadcast(swap.redeemAtom)
+}
+
+// redeemAtom redeems the counterparty's atom and persists the redemption.
+func (swap *swap) redeemAtom() error {
 	orderID := swap.order.PersonalOrderID()
 	swap.swapAdapter.LogInfo(orderID, "redeeming the swap details")
 
@@ -335,13 +424,34 @@ func (swap *swap) redeem() error {
 		return err
 	}
 
-	if err := swap.state.Redeemed(orderID); err != nil {
+	txs, err := swap.transactions(orderID, swapDomain.Transactions{Redeem: swap.foreignAtom.Transactions().Redeem})
+	if err != nil {
+		return err
+	}
+
+	tx := swap.state.NewTransaction()
+	if err := tx.Redeemed(orderID); err != nil {
+		return err
+	}
+
+	if err := tx.PutTransactions(orderID, txs); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, StatusRedeemed); err != nil {
+		return err
+	}
+
+	if err := tx.ArchiveSwap(orderID, StatusRedeemed); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutStatus(orderID, StatusRedeemed); err != nil {
+	if err := tx.Commit(); err != nil {
 		return err
 	}
+	metrics.SwapsFinished.WithLabelValues(StatusRedeemed).Inc()
+	swap.swapAdapter.Publish(events.Status(orderID, StatusRedeemed))
+	swap.swapAdapter.Publish(events.Transaction(orderID, "redeem", txs.Redeem))
 
 	swap.swapAdapter.LogInfo(orderID, "redeemed the swap details")
 	return nil
@@ -371,25 +481,47 @@ func (swap *swap) responderAudit() error {
 	}
 
 	if bytes.Compare(to, personalAddr) != 0 {
-		return errors.New("Receiver Address Mismatch")
+		return auditError{
+			message:  "Receiver Address Mismatch",
+			expected: hex.EncodeToString(personalAddr),
+			observed: hex.EncodeToString(to),
+		}
 	}
 
 	if value.Cmp(swap.order.ReceiveValue()) > 0 {
-		return errors.New("Receive value is less than expected")
+		return auditError{
+			message:  "Receive value is less than expected",
+			expected: swap.order.ReceiveValue().String(),
+			observed: value.String(),
+		}
 	}
 
 	if time.Now().Unix() > newExpiry {
-		return errors.New("No time left to do the atomic swap")
+		return auditError{
+			message:  "No time left to do the atomic swap",
+			expected: fmt.Sprintf("expiry after %d", time.Now().Unix()+24*60*60),
+			observed: fmt.Sprintf("expiry at %d", expiry),
+		}
+	}
+
+	tx := swap.state.NewTransaction()
+	if err := tx.PutInitiateDetails(orderID, newExpiry, hashLock); err != nil {
+		return err
 	}
 
-	if err := swap.state.PutInitiateDetails(orderID, newExpiry, hashLock); err != nil {
+	if err := tx.PutCounterpartyTransactions(orderID, swap.foreignAtom.Transactions()); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutStatus(orderID, StatusAudited); err != nil {
+	if err := tx.PutStatus(orderID, StatusAudited); err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.swapAdapter.Publish(events.Status(orderID, StatusAudited))
+
 	swap.swapAdapter.LogInfo(orderID, "auditing successful")
 	return nil
 }
@@ -417,7 +549,11 @@ func (swap *swap) requestorAudit() error {
 	}
 
 	if hashLock != selfHashLock {
-		return fmt.Errorf("Hashlock Mismatch %v %v", hashLock, selfHashLock)
+		return auditError{
+			message:  fmt.Sprintf("Hashlock Mismatch %v %v", hashLock, selfHashLock),
+			expected: hex.EncodeToString(selfHashLock[:]),
+			observed: hex.EncodeToString(hashLock[:]),
+		}
 	}
 
 	personalAddr, err := swap.swapAdapter.ReceiveOwnerAddress(swap.order.PersonalOrderID(), 0)
@@ -426,21 +562,43 @@ func (swap *swap) requestorAudit() error {
 	}
 
 	if bytes.Compare(to, personalAddr) != 0 {
-		return errors.New("Receiver Address Mismatch")
+		return auditError{
+			message:  "Receiver Address Mismatch",
+			expected: hex.EncodeToString(personalAddr),
+			observed: hex.EncodeToString(to),
+		}
 	}
 
 	if value.Cmp(swap.order.ReceiveValue()) < 0 {
-		return errors.New("Receive value is less than expected")
+		return auditError{
+			message:  "Receive value is less than expected",
+			expected: swap.order.ReceiveValue().String(),
+			observed: value.String(),
+		}
 	}
 
 	if time.Now().Unix() > expiry {
-		return errors.New("No time left to do the atomic swap")
+		return auditError{
+			message:  "No time left to do the atomic swap",
+			expected: fmt.Sprintf("expiry after %d", time.Now().Unix()),
+			observed: fmt.Sprintf("expiry at %d", expiry),
+		}
 	}
 
-	if err := swap.state.PutStatus(orderID, StatusAudited); err != nil {
+	tx := swap.state.NewTransaction()
+	if err := tx.PutCounterpartyTransactions(orderID, swap.foreignAtom.Transactions()); err != nil {
 		return err
 	}
 
+	if err := tx.PutStatus(orderID, StatusAudited); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.swapAdapter.Publish(events.Status(orderID, StatusAudited))
+
 	swap.swapAdapter.LogInfo(orderID, "auditing successful")
 	return nil
 }
@@ -449,7 +607,13 @@ func (swap *swap) getRedeemDetails() error {
 	orderID := swap.order.PersonalOrderID()
 	swap.swapAdapter.LogInfo(orderID, "receiving the redeem details")
 
-	if err := swap.personalAtom.WaitForCounterRedemption(); err != nil {
+	if err := swap.wait(time.Time{}, func() error {
+		_, redeemed, err := swap.personalAtom.RedeemedSecret()
+		if err == nil && !redeemed {
+			return errNotRedeemed
+		}
+		return err
+	}); err != nil {
 		return err
 	}
 
@@ -458,14 +622,112 @@ func (swap *swap) getRedeemDetails() error {
 		return err
 	}
 
-	if err := swap.state.PutRedeemDetails(orderID, secret); err != nil {
+	tx := swap.state.NewTransaction()
+	if err := tx.PutRedeemDetails(orderID, secret); err != nil {
 		return err
 	}
 
-	if err := swap.state.PutStatus(orderID, StatusRedeemDetailsAcquired); err != nil {
+	if err := tx.PutStatus(orderID, StatusRedeemDetailsAcquired); err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.swapAdapter.Publish(events.Status(orderID, StatusRedeemDetailsAcquired))
+
 	swap.swapAdapter.LogInfo(orderID, "received the redeem details")
 	return nil
 }
+
+// wait waits for the counterparty without holding a worker, calling check
+// until it succeeds, or until the deadline if it is not zero, in which case
+// the last error is returned. It returns shutdown.ErrShuttingDown once the
+// swapper starts shutting down, so that the swap is resumed from its last
+// checkpoint when the swapper restarts.
+func (swap *swap) wait(deadline time.Time, check func() error) error {
+	return swap.blocker.Block(swap.order.PersonalOrderID(), func() error {
+		for {
+			err := check()
+			if err == nil {
+				return nil
+			}
+			if !deadline.IsZero() && time.Now().After(deadline) {
+				return err
+			}
+			if swap.swapAdapter.ShuttingDown() {
+				return shutdown.ErrShuttingDown
+			}
+			time.Sleep(counterpartyPollInterval)
+		}
+	})
+}
+
+// step runs a step of the swap, recording how long it took.
+func (swap *swap) step(name string, f func() error) error {
+	start := time.Now()
+	err := f()
+	metrics.ObserveStep(name, start, err)
+	return err
+}
+
+// transactions merges the transaction hashes with the ones already stored for
+// the swap.
+func (swap *swap) transactions(orderID [32]byte, txs swapDomain.Transactions) (swapDomain.Transactions, error) {
+	stored, err := swap.state.Transactions(orderID)
+	if err != nil {
+		return swapDomain.Transactions{}, err
+	}
+	return txs.Merge(stored), nil
+}
+
+// complain records that the swapper complained to the watchdog about the swap,
+// and why, and queues the complaint to be sent to the watchdog along with the
+// evidence from the error that the swap failed with. A swap that stopped
+// because the swapper is shutting down is not complained about.
+func (swap *swap) complain(complaintType, reason string, err error) error {
+	if err == shutdown.ErrShuttingDown {
+		return nil
+	}
+	orderID := swap.order.PersonalOrderID()
+	complaint := watchdog.Complaint{
+		Type:            complaintType,
+		PersonalOrderID: orderID,
+		ForeignOrderID:  swap.order.ForeignOrderID(),
+		Evidence: watchdog.Evidence{
+			Reason: reason,
+		},
+		Time: time.Now().Unix(),
+	}
+	if auditErr, ok := err.(auditError); ok {
+		complaint.Evidence.Expected = auditErr.expected
+		complaint.Evidence.Observed = auditErr.observed
+	}
+	if txs, err := swap.state.Transactions(orderID); err == nil {
+		complaint.Evidence.Transactions = txs
+	}
+	if txs, err := swap.state.CounterpartyTransactions(orderID); err == nil {
+		complaint.Evidence.CounterpartyTransactions = txs
+	}
+
+	tx := swap.state.NewTransaction()
+	if err := tx.PutComplaint(orderID, reason); err != nil {
+		return err
+	}
+
+	if err := watchdog.Queue(tx, complaint); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, StatusComplained); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.swapAdapter.Notify()
+	swap.swapAdapter.Publish(events.Status(orderID, StatusComplained))
+	metrics.Complaints.Inc()
+	return nil
+}
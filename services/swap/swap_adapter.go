@@ -2,7 +2,9 @@ package swap
 
 import (
 	"github.com/republicprotocol/renex-swapper-go/domains/order"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
 	"github.com/republicprotocol/renex-swapper-go/services/logger"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
 	"github.com/republicprotocol/renex-swapper-go/services/watchdog"
 )
 
@@ -11,6 +13,8 @@ type SwapAdapter interface {
 	ReceiveOwnerAddress(order.ID, int64) ([]byte, error)
 	ReceiveSwapDetails(order.ID, int64) ([]byte, error)
 	SendSwapDetails(order.ID, []byte) error
-	watchdog.WatchdogClient
+	watchdog.Notifier
 	logger.Logger
+	events.Publisher
+	shutdown.Broadcaster
 }
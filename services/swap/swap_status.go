@@ -2,6 +2,7 @@ package swap
 
 const (
 	StatusUnknown                     = "UNKNOWN"
+	StatusPending                     = "PENDING"
 	StatusMatched                     = "MATCHED"
 	StatusInfoSubmitted               = "INFO_SUBMITTED"
 	StatusInitiateDetailsAcquired     = "INITIATE_DETAILS_ACQUIRED"
@@ -12,8 +13,66 @@ const (
 	StatusWaitingForCounterRedemption = "WAITING_FOR_COUNTER_REDEMPTION"
 	StatusRefunded                    = "REFUNDED"
 	StatusComplained                  = "COMPLAINED"
+	StatusCancelled                   = "CANCELLED"
+	StatusExpired                     = "EXPIRED"
+	StatusAbandoned                   = "ABANDONED"
 
 	StatusReceivedSwapDetails = "RECEIVED_SWAP_DETAILS"
 	StatusSentSwapDetails     = "SENT_SWAP_DETAILS"
 	StatusAudited             = "AUDITED"
 )
+
+// Action describes what a swap is waiting for. Deadline is the unix timestamp
+// by which it must happen, or zero if there is none.
+type Action struct {
+	Description string
+	Deadline    int64
+}
+
+// NextAction returns the action that a swap with the status is waiting for.
+// The expiry is the expiry of the swapper's own atom, after which it can be
+// refunded.
+func NextAction(status string, requestor bool, expiry int64) Action {
+	switch status {
+	case StatusUnknown, StatusPending:
+		return Action{Description: "waiting for the order to be matched"}
+	case StatusMatched:
+		return Action{Description: "submitting the swapper's address to the counterparty"}
+	case StatusInfoSubmitted:
+		if requestor {
+			return Action{Description: "generating the secret and the swap details"}
+		}
+		return Action{Description: "waiting for the requestor to initiate the swap"}
+	case StatusInitiateDetailsAcquired:
+		return Action{Description: "initiating the swap"}
+	case StatusInitiated:
+		return Action{Description: "sending the swap details to the counterparty"}
+	case StatusSentSwapDetails:
+		if requestor {
+			return Action{Description: "waiting for the responder to initiate the swap"}
+		}
+		return Action{Description: "waiting for the requestor to redeem and reveal the secret", Deadline: expiry}
+	case StatusReceivedSwapDetails:
+		return Action{Description: "auditing the counterparty's contract"}
+	case StatusAudited:
+		if requestor {
+			return Action{Description: "redeeming the counterparty's contract", Deadline: expiry}
+		}
+		return Action{Description: "initiating the swap", Deadline: expiry}
+	case StatusRedeemDetailsAcquired:
+		return Action{Description: "redeeming the counterparty's contract", Deadline: expiry}
+	case StatusComplained:
+		return Action{Description: "waiting for the swap to expire so that it can be refunded", Deadline: expiry}
+	}
+	return Action{}
+}
+
+// Finished returns true if a swap with the status will not progress any
+// further.
+func Finished(status string) bool {
+	switch status {
+	case StatusRedeemed, StatusRefunded, StatusCancelled, StatusExpired, StatusAbandoned:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+var ErrInvalidAmount = errors.New("amount must be greater than zero")
+var ErrInsufficientFunds = errors.New("insufficient funds that are not reserved for pending swaps")
+var ErrDuplicateWithdrawal = errors.New("withdrawal has already been requested")
+var ErrOrderNotFound = errors.New("order details are not available")
+
+// Funding is what an order needs to be funded once it is matched: the
+// maximum amount of the currency that it can send, and the estimated fee of
+// sending it.
+type Funding struct {
+	Currency uint32
+	Amount   *big.Int
+	Fee      *big.Int
+}
+
+// Total returns the amount and fee.
+func (funding Funding) Total() *big.Int {
+	total := new(big.Int).Set(funding.Amount)
+	if funding.Fee != nil {
+		total.Add(total, funding.Fee)
+	}
+	return total
+}
+
+// UnfundedError is returned when an order needs more funds than are
+// available.
+type UnfundedError struct {
+	Funding   Funding
+	Available *big.Int
+}
+
+func (err UnfundedError) Error() string {
+	name := cc.Name(err.Funding.Currency)
+	return fmt.Sprintf("order can send up to %v %s plus an estimated fee of %v, but only %v is available after the funds reserved for pending swaps", err.Funding.Amount, name, err.Funding.Fee, err.Available)
+}
+
+// Balance breaks down the funds held by the swapper in one currency. Amounts
+// are in the base unit of the currency. Unconfirmed is the change in the
+// balance that has not been confirmed yet, which is negative if funds are
+// being sent. Locked is the amount that pending swaps will send once they
+// initiate, and Available is what is left to trade and withdraw.
+type Balance struct {
+	Currency    uint32
+	Address     string
+	Confirmed   *big.Int
+	Unconfirmed *big.Int
+	Locked      *big.Int
+	Available   *big.Int
+}
+
+// Adapter sends funds from the swapper's own addresses.
+type Adapter interface {
+	// ChainBalance returns the address of the currency along with its
+	// confirmed and unconfirmed balance.
+	ChainBalance(currency uint32) (Balance, error)
+
+	// FeeBalance returns the balance of the trader in RenEx that fees are
+	// paid from, in wei.
+	FeeBalance(trader string) (*big.Int, error)
+
+	// OrderFunding looks up the order and returns the currency and maximum
+	// amount that it sends. It returns ErrOrderNotFound if the details of
+	// the order are not available yet.
+	OrderFunding(orderID [32]byte) (Funding, error)
+
+	// EstimateFee returns the estimated fee of funding a swap in the
+	// currency.
+	EstimateFee(currency uint32) (*big.Int, error)
+
+	// Transfer sends the amount of the currency to the address and returns
+	// the transaction hash.
+	Transfer(currency uint32, to string, amount *big.Int) (string, error)
+}
+
+// Wallet manages the funds held by the swapper.
+type Wallet interface {
+	// Balance returns the balance of the currency, net of the funds that are
+	// locked in pending swaps.
+	Balance(currency uint32) (Balance, error)
+
+	FeeBalance(trader string) (*big.Int, error)
+
+	// Withdraw sends funds that are not reserved for pending swaps to the
+	// address. The ID identifies the request, which is refused if it has
+	// been made before.
+	Withdraw(id [32]byte, currency uint32, to string, amount *big.Int, authorizedBy string) (store.Withdrawal, error)
+
+	// Fund checks that the order can be funded once it is matched and
+	// reserves the funds for it. The details of the order are looked up,
+	// and the fallback is used if they are not available yet. If the order
+	// cannot be funded an UnfundedError is returned, and the funds are only
+	// reserved if force is true. The order is added to the pending swaps
+	// along with its reservation.
+	Fund(orderID [32]byte, fallback *Funding, force bool) (Funding, error)
+
+	// Committed returns the amount of the currency that pending swaps will
+	// send once they initiate.
+	Committed(currency uint32) (*big.Int, error)
+}
+
+type wallet struct {
+	Adapter
+	mu    *sync.Mutex
+	state store.State
+}
+
+// NewWallet returns a Wallet that sends funds using the adapter.
+func NewWallet(adapter Adapter, state store.State) Wallet {
+	return &wallet{
+		Adapter: adapter,
+		mu:      new(sync.Mutex),
+		state:   state,
+	}
+}
+
+func (wallet *wallet) Withdraw(id [32]byte, currency uint32, to string, amount *big.Int, authorizedBy string) (store.Withdrawal, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return store.Withdrawal{}, ErrInvalidAmount
+	}
+
+	// Withdrawals are made one at a time so that two of them cannot spend
+	// the same funds
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+
+	if _, err := wallet.state.Withdrawal(id); err != store.ErrKeyNotFound {
+		if err == nil {
+			return store.Withdrawal{}, ErrDuplicateWithdrawal
+		}
+		return store.Withdrawal{}, err
+	}
+
+	balance, err := wallet.Balance(currency)
+	if err != nil {
+		return store.Withdrawal{}, err
+	}
+	if balance.Available.Cmp(amount) < 0 {
+		return store.Withdrawal{}, ErrInsufficientFunds
+	}
+
+	withdrawal := store.Withdrawal{
+		ID:           id,
+		Currency:     currency,
+		To:           to,
+		Amount:       amount,
+		AuthorizedBy: authorizedBy,
+		Status:       store.WithdrawalPending,
+		Time:         time.Now().Unix(),
+	}
+	if err := wallet.state.PutWithdrawal(withdrawal); err != nil {
+		return store.Withdrawal{}, err
+	}
+
+	txHash, transferErr := wallet.Transfer(currency, to, amount)
+	if transferErr != nil {
+		withdrawal.Status = store.WithdrawalFailed
+		withdrawal.Error = transferErr.Error()
+	} else {
+		withdrawal.Status = store.WithdrawalSent
+		withdrawal.TxHash = txHash
+	}
+	if err := wallet.state.PutWithdrawal(withdrawal); err != nil {
+		return withdrawal, err
+	}
+	return withdrawal, transferErr
+}
+
+func (wallet *wallet) Balance(currency uint32) (Balance, error) {
+	balance, err := wallet.ChainBalance(currency)
+	if err != nil {
+		return Balance{}, err
+	}
+	balance.Currency = currency
+	if balance.Locked, err = wallet.Committed(currency); err != nil {
+		return Balance{}, err
+	}
+
+	// Funds that are being sent can no longer be used, but funds that are
+	// being received cannot be used until they are confirmed
+	balance.Available = new(big.Int).Sub(balance.Confirmed, balance.Locked)
+	if balance.Unconfirmed.Sign() < 0 {
+		balance.Available.Add(balance.Available, balance.Unconfirmed)
+	}
+	if balance.Available.Sign() < 0 {
+		balance.Available.SetInt64(0)
+	}
+	return balance, nil
+}
+
+func (wallet *wallet) Fund(orderID [32]byte, fallback *Funding, force bool) (Funding, error) {
+	funding, err := wallet.OrderFunding(orderID)
+	if err == ErrOrderNotFound && fallback != nil {
+		funding, err = *fallback, nil
+	}
+	if err != nil {
+		return Funding{}, err
+	}
+	if funding.Fee, err = wallet.EstimateFee(funding.Currency); err != nil {
+		return Funding{}, err
+	}
+
+	// Orders are funded one at a time so that two of them cannot reserve
+	// the same funds
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+
+	balance, err := wallet.Balance(funding.Currency)
+	if err != nil {
+		return funding, err
+	}
+	var unfunded error
+	if balance.Available.Cmp(funding.Total()) < 0 {
+		unfunded = UnfundedError{
+			Funding:   funding,
+			Available: balance.Available,
+		}
+		if !force {
+			return funding, unfunded
+		}
+	}
+
+	// The swap is added along with its reservation so that the funds are
+	// never reserved for a swap that is not pending
+	tx := wallet.state.NewTransaction()
+	if err := tx.PutReservation(orderID, store.Reservation{
+		Currency: funding.Currency,
+		Amount:   funding.Total(),
+	}); err != nil {
+		return funding, err
+	}
+	if err := tx.AddSwap(orderID); err != nil {
+		return funding, err
+	}
+	if err := tx.Commit(); err != nil {
+		return funding, err
+	}
+	return funding, unfunded
+}
+
+func (wallet *wallet) Committed(currency uint32) (*big.Int, error) {
+	swaps, err := wallet.state.PendingSwaps()
+	if err != nil {
+		return nil, err
+	}
+	committed := big.NewInt(0)
+	matched := map[[32]byte]bool{}
+	for _, swap := range swaps {
+		if swap.SendValue == nil {
+			continue
+		}
+		matched[swap.OrderID] = true
+		if swap.SendCurrency != currency {
+			continue
+		}
+		// Once the swapper has initiated, the funds have left its address
+		if swap.Transactions.Initiate != "" || wallet.state.IsRedeemable(swap.OrderID) {
+			continue
+		}
+		committed.Add(committed, swap.SendValue)
+	}
+
+	// Orders that have not been matched yet use the funds that were reserved
+	// when they were posted, even before they are added to the pending swaps
+	reservations, err := wallet.state.Reservations()
+	if err != nil {
+		return nil, err
+	}
+	for orderID, reservation := range reservations {
+		if reservation.Currency != currency || matched[orderID] {
+			continue
+		}
+		if _, err := wallet.state.ArchivedSwap(orderID); err == nil {
+			continue
+		}
+		committed.Add(committed, reservation.Amount)
+	}
+	return committed, nil
+}
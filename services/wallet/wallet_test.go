@@ -0,0 +1,238 @@
+package wallet_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	. "github.com/republicprotocol/renex-swapper-go/services/wallet"
+)
+
+type mockAdapter struct {
+	confirmed   *big.Int
+	unconfirmed *big.Int
+	orders      map[[32]byte]Funding
+	transfers   []*big.Int
+	err         error
+}
+
+func (adapter *mockAdapter) ChainBalance(currency uint32) (Balance, error) {
+	return Balance{
+		Address:     "mxyz",
+		Confirmed:   adapter.confirmed,
+		Unconfirmed: adapter.unconfirmed,
+	}, nil
+}
+
+func (adapter *mockAdapter) FeeBalance(trader string) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (adapter *mockAdapter) OrderFunding(orderID [32]byte) (Funding, error) {
+	funding, ok := adapter.orders[orderID]
+	if !ok {
+		return Funding{}, ErrOrderNotFound
+	}
+	return funding, nil
+}
+
+func (adapter *mockAdapter) EstimateFee(currency uint32) (*big.Int, error) {
+	return big.NewInt(5), nil
+}
+
+func (adapter *mockAdapter) Transfer(currency uint32, to string, amount *big.Int) (string, error) {
+	if adapter.err != nil {
+		return "", adapter.err
+	}
+	adapter.transfers = append(adapter.transfers, amount)
+	return "0xtx", nil
+}
+
+var _ = Describe("Wallet", func() {
+	var state store.State
+	var adapter *mockAdapter
+	var wallet Wallet
+
+	randomID := func() [32]byte {
+		id := [32]byte{}
+		rand.Read(id[:])
+		return id
+	}
+
+	addSwap := func(sendValue int64, sendCurrency uint32) [32]byte {
+		orderID := randomID()
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		Expect(state.PutMatch(orderID, match.NewMatch(orderID, randomID(), big.NewInt(sendValue), big.NewInt(1), sendCurrency, 1-sendCurrency))).ShouldNot(HaveOccurred())
+		return orderID
+	}
+
+	BeforeEach(func() {
+		state = store.NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		adapter = &mockAdapter{confirmed: big.NewInt(100), unconfirmed: big.NewInt(0), orders: map[[32]byte]Funding{}}
+		wallet = NewWallet(adapter, state)
+	})
+
+	It("reserves the funds of swaps that have not initiated", func() {
+		addSwap(30, 0)
+		addSwap(1000, 1)
+		initiated := addSwap(20, 0)
+		Expect(state.PutRedeemable(initiated)).ShouldNot(HaveOccurred())
+
+		committed, err := wallet.Committed(0)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(committed.Int64()).Should(Equal(int64(30)))
+	})
+
+	It("breaks down the balance", func() {
+		addSwap(30, 0)
+		adapter.unconfirmed = big.NewInt(50)
+
+		balance, err := wallet.Balance(0)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(balance.Confirmed.Int64()).Should(Equal(int64(100)))
+		Expect(balance.Unconfirmed.Int64()).Should(Equal(int64(50)))
+		Expect(balance.Locked.Int64()).Should(Equal(int64(30)))
+		Expect(balance.Available.Int64()).Should(Equal(int64(70)))
+	})
+
+	It("does not make funds that are being sent available", func() {
+		addSwap(30, 0)
+		adapter.unconfirmed = big.NewInt(-80)
+
+		balance, err := wallet.Balance(0)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(balance.Available.Int64()).Should(Equal(int64(0)))
+	})
+
+	It("reserves the funds of orders that can be funded", func() {
+		orderID := randomID()
+		adapter.orders[orderID] = Funding{Currency: 0, Amount: big.NewInt(60)}
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+
+		funding, err := wallet.Fund(orderID, nil, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(funding.Total().Int64()).Should(Equal(int64(65)))
+
+		balance, err := wallet.Balance(0)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(balance.Locked.Int64()).Should(Equal(int64(65)))
+		Expect(balance.Available.Int64()).Should(Equal(int64(35)))
+	})
+
+	It("reserves the funds of orders that have not been added to the pending swaps", func() {
+		first, second := randomID(), randomID()
+		adapter.orders[first] = Funding{Currency: 0, Amount: big.NewInt(60)}
+		adapter.orders[second] = Funding{Currency: 0, Amount: big.NewInt(60)}
+
+		_, err := wallet.Fund(first, nil, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = wallet.Fund(second, nil, false)
+		Expect(err).Should(BeAssignableToTypeOf(UnfundedError{}))
+		Expect(err.(UnfundedError).Available.Int64()).Should(Equal(int64(35)))
+	})
+
+	It("releases the funds reserved for orders that are matched or archived", func() {
+		matched, cancelled := randomID(), randomID()
+		adapter.orders[matched] = Funding{Currency: 0, Amount: big.NewInt(40)}
+		adapter.orders[cancelled] = Funding{Currency: 0, Amount: big.NewInt(20)}
+		for _, orderID := range [][32]byte{matched, cancelled} {
+			Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+			_, err := wallet.Fund(orderID, nil, false)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		tx := state.NewTransaction()
+		Expect(tx.PutMatch(matched, match.NewMatch(matched, randomID(), big.NewInt(30), big.NewInt(1), 0, 1))).ShouldNot(HaveOccurred())
+		Expect(tx.DeleteReservation(matched)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+		Expect(state.ArchiveSwap(cancelled, "CANCELLED")).ShouldNot(HaveOccurred())
+
+		committed, err := wallet.Committed(0)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(committed.Int64()).Should(Equal(int64(30)))
+		reservations, err := state.Reservations()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(reservations).Should(BeEmpty())
+	})
+
+	It("rejects orders that cannot be funded", func() {
+		addSwap(30, 0)
+		orderID := randomID()
+		adapter.orders[orderID] = Funding{Currency: 0, Amount: big.NewInt(70)}
+
+		_, err := wallet.Fund(orderID, nil, false)
+		Expect(err).Should(BeAssignableToTypeOf(UnfundedError{}))
+		Expect(err.(UnfundedError).Available.Int64()).Should(Equal(int64(70)))
+		_, err = state.Reservation(orderID)
+		Expect(err).Should(Equal(store.ErrKeyNotFound))
+
+		_, err = wallet.Fund(orderID, nil, true)
+		Expect(err).Should(BeAssignableToTypeOf(UnfundedError{}))
+		_, err = state.Reservation(orderID)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("uses the fallback when the order details are not available", func() {
+		orderID := randomID()
+		_, err := wallet.Fund(orderID, nil, false)
+		Expect(err).Should(Equal(ErrOrderNotFound))
+
+		funding, err := wallet.Fund(orderID, &Funding{Currency: 1, Amount: big.NewInt(10)}, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(funding.Currency).Should(Equal(uint32(1)))
+	})
+
+	It("records withdrawals of available funds", func() {
+		addSwap(30, 0)
+		id := randomID()
+		withdrawal, err := wallet.Withdraw(id, 0, "mxyz", big.NewInt(70), "0xtrader")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(withdrawal.Status).Should(Equal(store.WithdrawalSent))
+		Expect(withdrawal.TxHash).Should(Equal("0xtx"))
+
+		recorded, err := state.Withdrawal(id)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(recorded.Status).Should(Equal(store.WithdrawalSent))
+		Expect(recorded.AuthorizedBy).Should(Equal("0xtrader"))
+		Expect(recorded.Amount.Int64()).Should(Equal(int64(70)))
+	})
+
+	It("refuses to spend funds reserved for pending swaps", func() {
+		addSwap(30, 0)
+		_, err := wallet.Withdraw(randomID(), 0, "mxyz", big.NewInt(71), "0xtrader")
+		Expect(err).Should(Equal(ErrInsufficientFunds))
+		Expect(adapter.transfers).Should(BeEmpty())
+
+		withdrawals, err := state.Withdrawals()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(withdrawals).Should(BeEmpty())
+	})
+
+	It("refuses to replay a withdrawal", func() {
+		id := randomID()
+		_, err := wallet.Withdraw(id, 0, "mxyz", big.NewInt(10), "0xtrader")
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = wallet.Withdraw(id, 0, "mxyz", big.NewInt(10), "0xtrader")
+		Expect(err).Should(Equal(ErrDuplicateWithdrawal))
+		Expect(adapter.transfers).Should(HaveLen(1))
+	})
+
+	It("records failed withdrawals", func() {
+		adapter.err = errors.New("connection refused")
+		id := randomID()
+		_, err := wallet.Withdraw(id, 0, "mxyz", big.NewInt(10), "0xtrader")
+		Expect(err).Should(Equal(adapter.err))
+
+		recorded, err := state.Withdrawal(id)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(recorded.Status).Should(Equal(store.WithdrawalFailed))
+		Expect(recorded.Error).Should(Equal("connection refused"))
+	})
+})
@@ -0,0 +1,140 @@
+package watch_test
+
+import (
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	"github.com/republicprotocol/renex-swapper-go/services/errors"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/swap"
+	. "github.com/republicprotocol/renex-swapper-go/services/watch"
+)
+
+var _ = Describe("Cancelling an order", func() {
+	var state store.State
+	var adapter *mockAdapter
+	var watch Watch
+
+	orderID := [32]byte{1}
+	foreignOrderID := [32]byte{2}
+	m := match.NewMatch(orderID, foreignOrderID, big.NewInt(1), big.NewInt(1), cc.BITCOINCC, cc.ETHEREUMCC)
+
+	// checking makes the orderbook wait for the result of the next check for
+	// a match, and returns a channel that receives once the check started and
+	// a channel that the result is sent on.
+	checking := func() (<-chan struct{}, chan<- error) {
+		started := make(chan struct{}, 1)
+		results := make(chan error)
+		adapter.checkForMatch = func() (match.Match, error) {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			if err := <-results; err != nil {
+				return nil, err
+			}
+			return m, nil
+		}
+		return started, results
+	}
+
+	archived := func() string {
+		summary, err := state.ArchivedSwap(orderID)
+		if err != nil {
+			return ""
+		}
+		return summary.Status
+	}
+
+	BeforeEach(func() {
+		state = store.NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		adapter = &mockAdapter{
+			Logger:      loggerAdapter.NewStdOutLogger(),
+			Publisher:   events.NewBroker(0),
+			Broadcaster: shutdown.NewShutdown(),
+		}
+		watch = NewWatch(adapter, state, 0)
+		errs := watch.Start()
+		go func() {
+			for range errs {
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		Expect(watch.Drain(time.Second)).Should(BeTrue())
+	})
+
+	It("archives an order that has not been matched as cancelled", func() {
+		Expect(watch.Add(orderID)).ShouldNot(HaveOccurred())
+		Expect(watch.Cancel(orderID)).ShouldNot(HaveOccurred())
+
+		Expect(watch.Status(orderID)).Should(Equal(swap.StatusCancelled))
+		Expect(archived()).Should(Equal(swap.StatusCancelled))
+	})
+
+	It("does not cancel an order that it is not watching", func() {
+		Expect(watch.Cancel(orderID)).Should(Equal(store.ErrKeyNotFound))
+	})
+
+	It("does not cancel an order that has been matched", func() {
+		started, results := checking()
+		Expect(watch.Add(orderID)).ShouldNot(HaveOccurred())
+		watch.Notify()
+		<-started
+		results <- nil
+
+		Eventually(func() error {
+			_, err := state.Match(orderID)
+			return err
+		}).ShouldNot(HaveOccurred())
+		Expect(watch.Cancel(orderID)).Should(Equal(ErrOrderMatched))
+		Expect(watch.Status(orderID)).ShouldNot(Equal(swap.StatusCancelled))
+	})
+
+	It("ignores a match that is found after the order was cancelled", func() {
+		started, results := checking()
+		Expect(watch.Add(orderID)).ShouldNot(HaveOccurred())
+		watch.Notify()
+		<-started
+
+		Expect(watch.Cancel(orderID)).ShouldNot(HaveOccurred())
+		results <- nil
+
+		Consistently(func() error {
+			_, err := state.Match(orderID)
+			return err
+		}).Should(Equal(store.ErrKeyNotFound))
+		Expect(watch.Status(orderID)).Should(Equal(swap.StatusCancelled))
+		Expect(archived()).Should(Equal(swap.StatusCancelled))
+	})
+
+	It("archives an order that was cancelled on the orderbook", func() {
+		started, results := checking()
+		Expect(watch.Add(orderID)).ShouldNot(HaveOccurred())
+		watch.Notify()
+		<-started
+		results <- errors.ErrOrderCancelled
+
+		Eventually(archived).Should(Equal(swap.StatusCancelled))
+	})
+
+	It("archives an order that expired on the orderbook", func() {
+		started, results := checking()
+		Expect(watch.Add(orderID)).ShouldNot(HaveOccurred())
+		watch.Notify()
+		<-started
+		results <- errors.ErrOrderExpired
+
+		Eventually(archived).Should(Equal(swap.StatusExpired))
+	})
+})
@@ -0,0 +1,161 @@
+package watch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	cc "github.com/republicprotocol/renex-swapper-go/domains/currency_codes"
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
+	"github.com/republicprotocol/renex-swapper-go/domains/order"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/logger"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	"github.com/republicprotocol/renex-swapper-go/services/swap"
+	. "github.com/republicprotocol/renex-swapper-go/services/watch"
+	"github.com/republicprotocol/renex-swapper-go/services/watchdog"
+)
+
+// mockAdapter is an adapter whose atoms cannot be built, so that every swap
+// that reaches its atoms fails with a transient error. Orders are matched by
+// checkForMatch, and cannot be checked if it is nil.
+type mockAdapter struct {
+	logger.Logger
+	events.Publisher
+	shutdown.Broadcaster
+
+	checkForMatch func() (match.Match, error)
+}
+
+func (adapter *mockAdapter) SendOwnerAddress(order.ID, []byte) error {
+	return nil
+}
+
+func (adapter *mockAdapter) ReceiveOwnerAddress(order.ID, int64) ([]byte, error) {
+	return nil, nil
+}
+
+func (adapter *mockAdapter) ReceiveSwapDetails(order.ID, int64) ([]byte, error) {
+	return nil, nil
+}
+
+func (adapter *mockAdapter) SendSwapDetails(order.ID, []byte) error {
+	return nil
+}
+
+func (adapter *mockAdapter) Notify() {
+}
+
+func (adapter *mockAdapter) BuildAtoms(store.State, match.Match) (swap.Atom, swap.Atom, error) {
+	return nil, nil, errors.New("connection refused")
+}
+
+func (adapter *mockAdapter) CheckForMatch(order.ID, bool) (match.Match, error) {
+	if adapter.checkForMatch == nil {
+		return nil, errors.New("connection refused")
+	}
+	return adapter.checkForMatch()
+}
+
+var _ = Describe("Giving up on a swap", func() {
+	var state store.State
+	var watch Watch
+
+	orderID := [32]byte{1}
+	foreignOrderID := [32]byte{2}
+
+	// initiated stores a swap that stopped at the status after its atom was
+	// initiated, and that expires before the watcher can retry it.
+	initiated := func(status string, m match.Match) {
+		Expect(state.AddSwap(orderID)).ShouldNot(HaveOccurred())
+		tx := state.NewTransaction()
+		if m != nil {
+			Expect(tx.PutMatch(orderID, m)).ShouldNot(HaveOccurred())
+		}
+		Expect(tx.PutInitiateDetails(orderID, time.Now().Add(30*time.Minute).Unix(), [32]byte{3})).ShouldNot(HaveOccurred())
+		Expect(tx.PutRedeemable(orderID)).ShouldNot(HaveOccurred())
+		Expect(tx.PutStatus(orderID, status)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+	}
+
+	givenUp := func() bool {
+		swapErr, err := state.Error(orderID)
+		return err == nil && swapErr.Permanent
+	}
+
+	complaints := func() []watchdog.Complaint {
+		messages, err := state.OutboxMessages()
+		Expect(err).ShouldNot(HaveOccurred())
+		complaints := []watchdog.Complaint{}
+		for _, message := range messages {
+			complaint := watchdog.Complaint{}
+			Expect(json.Unmarshal(message.Body, &complaint)).ShouldNot(HaveOccurred())
+			complaints = append(complaints, complaint)
+		}
+		return complaints
+	}
+
+	BeforeEach(func() {
+		state = store.NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		watch = NewWatch(&mockAdapter{
+			Logger:      loggerAdapter.NewStdOutLogger(),
+			Publisher:   events.NewBroker(0),
+			Broadcaster: shutdown.NewShutdown(),
+		}, state, 0)
+		errs := watch.Start()
+		go func() {
+			for range errs {
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		Expect(watch.Drain(time.Second)).Should(BeTrue())
+	})
+
+	It("complains that the responder did not initiate", func() {
+		initiated(swap.StatusSentSwapDetails, match.NewMatch(orderID, foreignOrderID, big.NewInt(1), big.NewInt(1), cc.BITCOINCC, cc.ETHEREUMCC))
+		watch.Notify()
+
+		Eventually(givenUp).Should(BeTrue())
+		Expect(state.Status(orderID)).Should(Equal(swap.StatusComplained))
+		Expect(complaints()).Should(HaveLen(1))
+		Expect(complaints()[0].Type).Should(Equal(watchdog.DelayedResponderInitiation))
+		Expect(complaints()[0].ForeignOrderID).Should(Equal(foreignOrderID))
+	})
+
+	It("complains that the requestor did not redeem", func() {
+		initiated(swap.StatusSentSwapDetails, match.NewMatch(orderID, foreignOrderID, big.NewInt(1), big.NewInt(1), cc.ETHEREUMCC, cc.BITCOINCC))
+		watch.Notify()
+
+		Eventually(givenUp).Should(BeTrue())
+		Expect(state.Status(orderID)).Should(Equal(swap.StatusComplained))
+		Expect(complaints()).Should(HaveLen(1))
+		Expect(complaints()[0].Type).Should(Equal(watchdog.DelayedRequestorRedemption))
+	})
+
+	It("does not complain when the swapper's own step failed", func() {
+		initiated(swap.StatusInitiated, match.NewMatch(orderID, foreignOrderID, big.NewInt(1), big.NewInt(1), cc.BITCOINCC, cc.ETHEREUMCC))
+		watch.Notify()
+
+		Eventually(givenUp).Should(BeTrue())
+		Expect(state.Status(orderID)).Should(Equal(swap.StatusInitiated))
+		Expect(complaints()).Should(BeEmpty())
+	})
+
+	It("does not complain about a swap without a match", func() {
+		initiated(swap.StatusSentSwapDetails, nil)
+		watch.Notify()
+
+		Eventually(givenUp).Should(BeTrue())
+		Expect(state.Status(orderID)).Should(Equal(swap.StatusSentSwapDetails))
+		Expect(complaints()).Should(BeEmpty())
+	})
+})
@@ -1,83 +1,120 @@
 package watch
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/republicprotocol/renex-swapper-go/domains/match"
 	"github.com/republicprotocol/renex-swapper-go/domains/order"
+	swapErrors "github.com/republicprotocol/renex-swapper-go/services/errors"
+	"github.com/republicprotocol/renex-swapper-go/services/events"
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
+	"github.com/republicprotocol/renex-swapper-go/services/scheduler"
+	"github.com/republicprotocol/renex-swapper-go/services/shutdown"
 	"github.com/republicprotocol/renex-swapper-go/services/store"
 	"github.com/republicprotocol/renex-swapper-go/services/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/watchdog"
 )
 
+// ErrOrderMatched is returned when cancelling an order that has already been
+// matched, its swap has to be completed.
+var ErrOrderMatched = errors.New("order has already been matched")
+
+// matchPollInterval is how often the orderbook is checked for a match.
+const matchPollInterval = 15 * time.Second
+
+// resyncInterval is how often every pending swap is checked, so that swaps
+// are retried without waiting for the watcher to be notified.
+const resyncInterval = time.Minute
+
+// expiryMargin is how long before the swapper's atom expires that the watcher
+// stops retrying a failed swap and leaves it to be refunded.
+const expiryMargin = time.Hour
+
 type watch struct {
-	adapter  Adapter
-	state    store.State
-	notifyCh chan struct{}
-	doneCh   chan struct{}
+	adapter   Adapter
+	state     store.State
+	scheduler scheduler.Scheduler
+	backoff   scheduler.Backoff
+	notifyCh  chan struct{}
+	doneCh    chan struct{}
+
+	// matchMu is held while the status of an order that has not been
+	// matched is checked and changed, so that an order is never cancelled
+	// while its match is stored.
+	matchMu *sync.Mutex
+
+	// running is 1 while the loop started by Start is running.
+	running int32
 }
 
 type Watch interface {
 	Start() <-chan error
 	Add([32]byte) error
+	Cancel([32]byte) error
+	Retry([32]byte) error
+	ResubmitDetails([32]byte) error
+	Hold([32]byte, func() error) error
 	Status([32]byte) string
 	Notify()
 	Stop()
+
+	// Drain stops the watcher and waits for the swaps that are running to
+	// stop at a checkpoint. It returns false if they did not stop before the
+	// timeout.
+	Drain(timeout time.Duration) bool
+
+	// Alive returns true if the watcher has been started and has not
+	// stopped.
+	Alive() bool
 }
 
-func NewWatch(adapter Adapter, state store.State) Watch {
-	return &watch{
+// NewWatch returns a Watch that runs at most concurrency swaps at the same
+// time, or scheduler.DefaultConcurrency if it is not positive.
+func NewWatch(adapter Adapter, state store.State, concurrency int) Watch {
+	watch := &watch{
 		adapter:  adapter,
 		state:    state,
+		backoff:  scheduler.DefaultBackoff,
 		notifyCh: make(chan struct{}, 1),
 		doneCh:   make(chan struct{}, 1),
+		matchMu:  new(sync.Mutex),
 	}
+	watch.scheduler = scheduler.NewScheduler(metrics.QueueWatch, concurrency, watch.run)
+	return watch
 }
 
-// Run runs the watch object on the given order id
+// Start schedules the executable swaps each time the watcher is notified, and
+// every pending swap once a minute. Swaps are run by the scheduler, which
+// never runs the same swap twice at the same time.
 func (watch *watch) Start() <-chan error {
 	errs := make(chan error)
 	fullsync := true
 	log.Println("Starting the watcher......")
+	atomic.StoreInt32(&watch.running, 1)
+	watch.scheduler.Start(errs)
 	go func() {
-		defer close(errs)
 		defer log.Println("Stopping the watcher......")
+		defer atomic.StoreInt32(&watch.running, 0)
+		defer watch.scheduler.Stop()
+		ticker := time.NewTicker(resyncInterval)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-watch.doneCh:
 				return
 			case <-watch.notifyCh:
-				swaps, err := watch.state.ExecutableSwaps(fullsync)
-				if fullsync {
-					fullsync = false
-				}
-				if err != nil {
+				if err := watch.sync(fullsync); err != nil {
 					errs <- err
-					continue
 				}
-				if len(swaps) < 1000 {
-					for i := range swaps {
-						go func(i int) {
-							if err := watch.Swap(swaps[i]); err != nil {
-								errs <- err
-								return
-							}
-							if watch.state.Status(swaps[i]) == swap.StatusRedeemed {
-								watch.state.DeleteSwap(swaps[i])
-							}
-						}(i)
-					}
-					continue
-				}
-				for i := range swaps[:1000] {
-					go func(i int) {
-						if err := watch.Swap(swaps[i]); err != nil {
-							errs <- err
-							return
-						}
-						if watch.state.Status(swaps[i]) == swap.StatusRedeemed {
-							watch.state.DeleteSwap(swaps[i])
-						}
-					}(i)
+				fullsync = false
+			case <-ticker.C:
+				if err := watch.sync(true); err != nil {
+					errs <- err
 				}
 			}
 		}
@@ -85,10 +122,311 @@ func (watch *watch) Start() <-chan error {
 	return errs
 }
 
+// sync schedules the executable swaps. Swaps that failed with a permanent
+// error are left for an operator to retry, and swaps that are waiting to be
+// retried are scheduled for when they are due.
+func (watch *watch) sync(fullsync bool) error {
+	swaps, err := watch.state.ExecutableSwaps(fullsync)
+	if err != nil {
+		return err
+	}
+	for _, orderID := range swaps {
+		if watch.scheduler.InFlight(orderID) {
+			continue
+		}
+		swapErr, err := watch.state.Error(orderID)
+		if err != nil || swapErr.Refund {
+			watch.scheduler.Schedule(orderID, watch.deadline(orderID))
+			continue
+		}
+		if swapErr.Permanent {
+			continue
+		}
+		delay := time.Until(time.Unix(swapErr.RetryAt, 0))
+		watch.scheduler.ScheduleAfter(orderID, watch.deadline(orderID), delay)
+	}
+	return nil
+}
+
+// run progresses the swap as far as it can, and archives it if it has
+// finished. A swap that fails is retried with backoff until it succeeds or it
+// is given up on.
+func (watch *watch) run(orderID [32]byte) error {
+	if err := watch.Swap(orderID); err != nil {
+		if watch.adapter.ShuttingDown() {
+			// The swap is resumed from its last checkpoint when the
+			// swapper is restarted.
+			return err
+		}
+		return watch.retry(orderID, err)
+	}
+	watch.archive(orderID)
+	return nil
+}
+
+// retry records the failed attempt and returns a scheduler.RetryAfter for
+// when the swap should be run again. The watcher gives up on a swap that
+// failed with a permanent error, that has failed too many times before the
+// swapper's atom was initiated, or that could not be retried before the atom
+// is about to expire. A swap that is given up on after the atom was initiated
+// is left for the guardian to refund.
+func (watch *watch) retry(orderID [32]byte, err error) error {
+	attempts := 1
+	if swapErr, readErr := watch.state.Error(orderID); readErr == nil && !swapErr.Refund {
+		attempts = swapErr.Attempts + 1
+	}
+	swapErr := store.SwapError{
+		Message:   err.Error(),
+		Attempts:  attempts,
+		Permanent: !swapErrors.IsTransient(err),
+	}
+
+	initiated := watch.state.IsRedeemable(orderID)
+	delay := watch.backoff.Delay(attempts)
+	retryAt := time.Now().Add(delay)
+	deadline := watch.deadline(orderID)
+
+	if !initiated && attempts >= watch.backoff.MaxAttempts {
+		swapErr.Permanent = true
+	}
+	if initiated && watch.canGiveUp(orderID) {
+		if swapErr.Permanent || (deadline != 0 && retryAt.After(time.Unix(deadline, 0).Add(-expiryMargin))) {
+			swapErr.Permanent = true
+			return watch.giveUp(orderID, swapErr, err)
+		}
+	} else if initiated {
+		// The swapper can no longer refund its atom safely, so the swap is
+		// retried however it failed.
+		swapErr.Permanent = false
+	}
+
+	if swapErr.Permanent {
+		watch.adapter.LogError(orderID, fmt.Sprintf("giving up on the swap after %d attempts: %v", attempts, err))
+		if putErr := watch.putFailedAttempt(orderID, swapErr); putErr != nil {
+			watch.adapter.LogError(orderID, fmt.Sprintf("failed to record the error: %v", putErr))
+		}
+		return err
+	}
+
+	swapErr.RetryAt = retryAt.Unix()
+	if putErr := watch.putFailedAttempt(orderID, swapErr); putErr != nil {
+		watch.adapter.LogError(orderID, fmt.Sprintf("failed to record the error: %v", putErr))
+	}
+	watch.adapter.LogInfo(orderID, fmt.Sprintf("retrying the swap in %v (attempt %d)", delay, attempts))
+	return scheduler.RetryAfter{
+		Delay:    delay,
+		Deadline: deadline,
+		Err:      err,
+	}
+}
+
+// canGiveUp returns true if the swapper can still refund its atom instead of
+// completing the swap. Once the counterparty has redeemed it, the swapper must
+// redeem the counterparty's atom.
+func (watch *watch) canGiveUp(orderID [32]byte) bool {
+	return watch.state.Status(orderID) != swap.StatusRedeemDetailsAcquired
+}
+
+// giveUp stops retrying a swap whose atom has been initiated, so that the
+// guardian refunds the atom once it expires. If the swap was waiting for the
+// counterparty, the watchdog is told that the counterparty did not complete
+// the swap, and otherwise only the failed attempt is recorded.
+func (watch *watch) giveUp(orderID [32]byte, swapErr store.SwapError, err error) error {
+	watch.adapter.LogError(orderID, fmt.Sprintf("giving up on the swap after %d attempts, it will be refunded: %v", swapErr.Attempts, err))
+	reason := fmt.Sprintf("gave up retrying the swap after %d attempts: %v", swapErr.Attempts, err)
+	complaint, ok, complaintErr := watch.complaint(orderID, reason)
+	if complaintErr != nil {
+		return complaintErr
+	}
+	if !ok {
+		if putErr := watch.putFailedAttempt(orderID, swapErr); putErr != nil {
+			return putErr
+		}
+		return err
+	}
+
+	tx := watch.state.NewTransaction()
+	if err := tx.PutComplaint(orderID, reason); err != nil {
+		return err
+	}
+
+	if err := watchdog.Queue(tx, complaint); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, swap.StatusComplained); err != nil {
+		return err
+	}
+
+	if err := tx.PutFailedAttempt(orderID, swapErr); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	watch.adapter.Notify()
+	watch.adapter.Publish(events.Status(orderID, swap.StatusComplained))
+	metrics.Complaints.Inc()
+	return err
+}
+
+// complaint builds the complaint to the watchdog about a swap that was given
+// up on while it was waiting for the counterparty. Having sent the swap
+// details, the requestor waits for the responder to initiate, and the
+// responder waits for the requestor to redeem. It returns false if the swap
+// stopped at one of the swapper's own steps, if it has already been
+// complained about, or if it has no match to complain about.
+func (watch *watch) complaint(orderID [32]byte, reason string) (watchdog.Complaint, bool, error) {
+	if watch.state.Status(orderID) != swap.StatusSentSwapDetails {
+		return watchdog.Complaint{}, false, nil
+	}
+	m, err := watch.state.Match(orderID)
+	if err == store.ErrKeyNotFound {
+		return watchdog.Complaint{}, false, nil
+	}
+	if err != nil {
+		return watchdog.Complaint{}, false, err
+	}
+	complaint := watchdog.Complaint{
+		Type:            watchdog.DelayedRequestorRedemption,
+		PersonalOrderID: orderID,
+		ForeignOrderID:  m.ForeignOrderID(),
+		Evidence: watchdog.Evidence{
+			Reason: reason,
+		},
+		Time: time.Now().Unix(),
+	}
+	if m.SendCurrency() < m.ReceiveCurrency() {
+		complaint.Type = watchdog.DelayedResponderInitiation
+	}
+	if txs, err := watch.state.Transactions(orderID); err == nil {
+		complaint.Evidence.Transactions = txs
+	}
+	if txs, err := watch.state.CounterpartyTransactions(orderID); err == nil {
+		complaint.Evidence.CounterpartyTransactions = txs
+	}
+	return complaint, true, nil
+}
+
+func (watch *watch) putFailedAttempt(orderID [32]byte, swapErr store.SwapError) error {
+	tx := watch.state.NewTransaction()
+	if err := tx.PutFailedAttempt(orderID, swapErr); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deadline returns the expiry of the swapper's atom, or zero if the swap has
+// not got that far yet.
+func (watch *watch) deadline(orderID [32]byte) int64 {
+	expiry, _, err := watch.state.InitiateDetails(orderID)
+	if err != nil {
+		return 0
+	}
+	return expiry
+}
+
+// archive moves the swap into the archive if it has finished and has not
+// been archived already. Swaps are archived by the step that finishes them,
+// so this only archives swaps that finished before they were archived with
+// their last step.
+func (watch *watch) archive(orderID [32]byte) {
+	status := watch.state.Status(orderID)
+	if !swap.Finished(status) {
+		return
+	}
+	if _, err := watch.state.PendingSwap(orderID); err == store.ErrKeyNotFound {
+		return
+	}
+	if err := watch.state.ArchiveSwap(orderID, status); err != nil {
+		watch.adapter.LogError(orderID, fmt.Sprintf("failed to archive the swap: %v", err))
+		return
+	}
+	metrics.SwapsFinished.WithLabelValues(status).Inc()
+}
+
 func (watch *watch) Add(orderID [32]byte) error {
 	return watch.state.AddSwap(orderID)
 }
 
+// Cancel stops watching an order that has not been matched yet. The order is
+// archived as cancelled, it still has to be cancelled on the orderbook.
+func (watch *watch) Cancel(orderID [32]byte) error {
+	watch.matchMu.Lock()
+	defer watch.matchMu.Unlock()
+
+	if _, err := watch.state.PendingSwap(orderID); err != nil {
+		return err
+	}
+	status := watch.state.Status(orderID)
+	if status != swap.StatusUnknown && status != swap.StatusPending {
+		return ErrOrderMatched
+	}
+	return watch.finish(orderID, swap.StatusCancelled)
+}
+
+// Retry clears the error that stopped the swap and runs it again in the
+// background, without waiting for its backoff. It returns
+// scheduler.ErrInFlight if the swap is already queued or running.
+func (watch *watch) Retry(orderID [32]byte) error {
+	if watch.scheduler.Wake(orderID) {
+		return watch.state.ClearError(orderID)
+	}
+	if watch.scheduler.InFlight(orderID) {
+		return scheduler.ErrInFlight
+	}
+	if err := watch.state.ClearError(orderID); err != nil {
+		return err
+	}
+	if !watch.scheduler.Schedule(orderID, watch.deadline(orderID)) {
+		return scheduler.ErrInFlight
+	}
+	return nil
+}
+
+// Hold runs the function while the swap is not running, taking the swap off
+// the queue if it is waiting to run. It returns scheduler.ErrInFlight if the
+// swap is running.
+func (watch *watch) Hold(orderID [32]byte, f func() error) error {
+	return watch.scheduler.RunNow(orderID, f)
+}
+
+// ResubmitDetails sends the details of the swapper's atom to the counterparty
+// again.
+func (watch *watch) ResubmitDetails(orderID [32]byte) error {
+	details, err := watch.state.AtomDetails(orderID)
+	if err != nil {
+		return err
+	}
+	if err := watch.adapter.SendSwapDetails(orderID, details); err != nil {
+		return err
+	}
+	watch.adapter.LogInfo(orderID, "resubmitted the swap details")
+	return nil
+}
+
+// finish gives an order that will never be matched its final status and
+// archives it.
+func (watch *watch) finish(orderID [32]byte, status string) error {
+	tx := watch.state.NewTransaction()
+	if err := tx.PutStatus(orderID, status); err != nil {
+		return err
+	}
+
+	if err := tx.ArchiveSwap(orderID, status); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	watch.adapter.Publish(events.Status(orderID, status))
+	watch.adapter.LogInfo(orderID, fmt.Sprintf("stopped watching the order: %s", status))
+	metrics.SwapsFinished.WithLabelValues(status).Inc()
+	return nil
+}
+
 func (watch *watch) Status(orderID [32]byte) string {
 	return watch.state.Status(orderID)
 }
@@ -101,8 +439,28 @@ func (watch *watch) Stop() {
 	watch.doneCh <- struct{}{}
 }
 
+func (watch *watch) Drain(timeout time.Duration) bool {
+	watch.Stop()
+	return watch.scheduler.Drain(timeout)
+}
+
+func (watch *watch) Alive() bool {
+	return atomic.LoadInt32(&watch.running) == 1
+}
+
+// Swap progresses the swap as far as it can, publishing the error that
+// stopped it if there is one. The error is recorded when the failed attempt
+// is.
 func (watch *watch) Swap(orderID [32]byte) error {
-	if watch.state.Status(orderID) == "UNKNOWN" {
+	if err := watch.swap(orderID); err != nil {
+		watch.adapter.Publish(events.Error(orderID, err))
+		return err
+	}
+	return watch.state.ClearError(orderID)
+}
+
+func (watch *watch) swap(orderID [32]byte) error {
+	if watch.state.Status(orderID) == swap.StatusUnknown {
 		if err := watch.initiate(orderID); err != nil {
 			watch.adapter.LogError(orderID, fmt.Sprintf("failed to initiate the watcher on %v", err))
 			return fmt.Errorf("failed to initiate the watcher on %v", err)
@@ -111,8 +469,8 @@ func (watch *watch) Swap(orderID [32]byte) error {
 		watch.adapter.LogInfo(orderID, "skipping watcher initiation")
 	}
 
-	if watch.state.Status(orderID) == "PENDING" {
-		if err := watch.getMatch(orderID); err != nil {
+	if watch.state.Status(orderID) == swap.StatusPending {
+		if err := watch.step("get_match", orderID, watch.getMatch); err != nil {
 			watch.adapter.LogError(orderID, fmt.Sprintf("failed to get the matching order %v", err))
 			return fmt.Errorf("failed to get the matching order %v", err)
 		}
@@ -120,8 +478,8 @@ func (watch *watch) Swap(orderID [32]byte) error {
 		watch.adapter.LogInfo(orderID, "skipping get match")
 	}
 
-	if watch.state.Status(orderID) == "MATCHED" {
-		if err := watch.setInfo(orderID); err != nil {
+	if watch.state.Status(orderID) == swap.StatusMatched {
+		if err := watch.step("submit_address", orderID, watch.setInfo); err != nil {
 			watch.adapter.LogError(orderID, fmt.Sprintf("failed to send address %v", err))
 			return fmt.Errorf("failed to send address %v", err)
 		}
@@ -129,7 +487,7 @@ func (watch *watch) Swap(orderID [32]byte) error {
 		watch.adapter.LogInfo(orderID, "skipping address submission")
 	}
 
-	if watch.state.Status(orderID) != "REDEEMED" && watch.state.Status(orderID) != "REFUNDED" && watch.state.Status(orderID) != swap.StatusComplained {
+	if status := watch.state.Status(orderID); !swap.Finished(status) && status != swap.StatusComplained {
 		if err := watch.execute(orderID); err != nil {
 			watch.adapter.LogError(orderID, fmt.Sprintf("failed to execute the atomic swap %v", err))
 			return fmt.Errorf("failed to execute the atomic swap %v", err)
@@ -141,6 +499,14 @@ func (watch *watch) Swap(orderID [32]byte) error {
 	return nil
 }
 
+// step runs a step of the swap, recording how long it took.
+func (watch *watch) step(name string, orderID [32]byte, f func([32]byte) error) error {
+	start := time.Now()
+	err := f(orderID)
+	metrics.ObserveStep(name, start, err)
+	return err
+}
+
 func (watch *watch) setInfo(orderID [32]byte) error {
 	watch.adapter.LogInfo(orderID, "submitting address")
 	m, err := watch.state.Match(orderID)
@@ -162,10 +528,16 @@ func (watch *watch) setInfo(orderID [32]byte) error {
 		return err
 	}
 
-	if err := watch.state.PutStatus(orderID, swap.StatusInfoSubmitted); err != nil {
+	tx := watch.state.NewTransaction()
+	if err := tx.PutStatus(orderID, swap.StatusInfoSubmitted); err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	watch.adapter.Publish(events.Status(orderID, swap.StatusInfoSubmitted))
+
 	watch.adapter.LogInfo(orderID, "submitted the address")
 	return nil
 }
@@ -181,36 +553,96 @@ func (watch *watch) execute(orderID [32]byte) error {
 		return err
 	}
 
-	atomicSwap := swap.NewSwap(personalAtom, foreignAtom, m, watch.adapter, watch.state)
+	atomicSwap := swap.NewSwap(personalAtom, foreignAtom, m, watch.adapter, watch.state, watch.scheduler)
 	return atomicSwap.Execute()
 }
 
 func (watch *watch) initiate(orderID [32]byte) error {
+	watch.matchMu.Lock()
+	defer watch.matchMu.Unlock()
+
+	// Stop if the order was cancelled using the swapper
+	if watch.state.Status(orderID) != swap.StatusUnknown {
+		return nil
+	}
+
 	watch.adapter.LogInfo(orderID, "starting the atomic swap")
-	err := watch.state.PutStatus(orderID, "PENDING")
-	if err != nil {
+	tx := watch.state.NewTransaction()
+	if err := tx.PutStatus(orderID, swap.StatusPending); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return err
 	}
+	watch.adapter.Publish(events.Status(orderID, swap.StatusPending))
 	watch.adapter.LogInfo(orderID, "started the atomic swap")
 	return nil
 }
 
 func (watch *watch) getMatch(orderID [32]byte) error {
 	watch.adapter.LogInfo(orderID, "waiting for the match to be found")
-	match, err := watch.adapter.CheckForMatch(orderID, true)
-	if err != nil {
+	var match match.Match
+	err := watch.scheduler.Block(orderID, func() error {
+		for {
+			// Stop waiting if the order was cancelled using the swapper
+			if watch.state.Status(orderID) != swap.StatusPending {
+				return nil
+			}
+			if watch.adapter.ShuttingDown() {
+				return shutdown.ErrShuttingDown
+			}
+
+			m, err := watch.adapter.CheckForMatch(orderID, false)
+			if err != swapErrors.ErrMatchNotFound {
+				match = m
+				return err
+			}
+			time.Sleep(matchPollInterval)
+		}
+	})
+	switch err {
+	case nil:
+		if match == nil {
+			return nil
+		}
+		return watch.putMatch(orderID, match)
+	case swapErrors.ErrOrderCancelled:
+		return watch.finish(orderID, swap.StatusCancelled)
+	case swapErrors.ErrOrderExpired:
+		return watch.finish(orderID, swap.StatusExpired)
+	default:
 		return err
 	}
+}
 
-	err = watch.state.PutMatch(orderID, match)
-	if err != nil {
+func (watch *watch) putMatch(orderID [32]byte, match match.Match) error {
+	watch.matchMu.Lock()
+	defer watch.matchMu.Unlock()
+
+	if watch.state.Status(orderID) != swap.StatusPending {
+		return nil
+	}
+
+	tx := watch.state.NewTransaction()
+	if err := tx.PutMatch(orderID, match); err != nil {
 		return err
 	}
 
-	err = watch.state.PutStatus(orderID, "MATCHED")
-	if err != nil {
+	// The funds reserved for the order are committed to the swap by the
+	// match
+	if err := tx.DeleteReservation(orderID); err != nil {
+		return err
+	}
+
+	if err := tx.PutStatus(orderID, swap.StatusMatched); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return err
 	}
+	watch.adapter.Publish(events.Status(orderID, swap.StatusMatched))
 
 	watch.adapter.LogInfo(orderID, fmt.Sprintf("<----------> (%s)", order.Fmt(match.ForeignOrderID())))
 	return nil
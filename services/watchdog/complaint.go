@@ -0,0 +1,59 @@
+package watchdog
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+// Complaint types
+const (
+	DelayedAddressSubmission   = "DELAYED_ADDRESS_SUBMISSION"
+	DelayedRequestorInitiation = "DELAYED_REQUESTOR_INITIATION"
+	WrongRequestorInitiation   = "WRONG_REQUESTOR_INITIATION"
+	DelayedResponderInitiation = "DELAYED_RESPONDER_INITIATION"
+	WrongResponderInitiation   = "WRONG_RESPONDER_INITIATION"
+	DelayedRequestorRedemption = "DELAYED_REQUESTOR_REDEMPTION"
+)
+
+// Complaint is a complaint to the watchdog that the counterparty of a swap
+// did not keep to the protocol.
+type Complaint struct {
+	Type            string   `json:"type"`
+	PersonalOrderID [32]byte `json:"personalOrderId"`
+	ForeignOrderID  [32]byte `json:"foreignOrderId"`
+	Evidence        Evidence `json:"evidence"`
+	Time            int64    `json:"time"`
+}
+
+// Evidence backs up a complaint. Expected and Observed are the values that
+// the counterparty's atom should have had and had, when it failed the audit.
+type Evidence struct {
+	Reason                   string                  `json:"reason"`
+	Expected                 string                  `json:"expected,omitempty"`
+	Observed                 string                  `json:"observed,omitempty"`
+	Transactions             swapDomain.Transactions `json:"transactions"`
+	CounterpartyTransactions swapDomain.Transactions `json:"counterpartyTransactions"`
+}
+
+// Queue queues the complaint in the transaction, to be sent to the watchdog
+// once the transaction is committed. A swap only has one complaint of each
+// type, queueing it again replaces it.
+func Queue(tx store.Transaction, complaint Complaint) error {
+	body, err := json.Marshal(complaint)
+	if err != nil {
+		return err
+	}
+	return tx.PutOutboxMessage(store.OutboxMessage{
+		ID:       complaintID(complaint),
+		Body:     body,
+		QueuedAt: time.Now().Unix(),
+	})
+}
+
+func complaintID(complaint Complaint) [32]byte {
+	return sha256.Sum256(append(complaint.PersonalOrderID[:], []byte(complaint.Type)...))
+}
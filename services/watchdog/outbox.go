@@ -0,0 +1,155 @@
+package watchdog
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/republicprotocol/renex-swapper-go/services/metrics"
+	"github.com/republicprotocol/renex-swapper-go/services/scheduler"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+)
+
+// resyncInterval is how often the queued complaints are checked, so that
+// complaints are retried without waiting for the outbox to be notified.
+const resyncInterval = time.Minute
+
+// Notifier is notified when complaints have been queued.
+type Notifier interface {
+	// Notify sends the queued complaints that are due straight away.
+	Notify()
+}
+
+// Outbox sends the complaints that have been queued in the store to the
+// watchdog, retrying them with backoff until the watchdog acknowledges them.
+// Complaints are only deleted once they are acknowledged, so they are sent
+// again when the swapper restarts.
+type Outbox interface {
+	Notifier
+	Start() <-chan error
+	Stop()
+
+	// Drain stops the outbox and waits for the complaints that are being
+	// sent. It returns false if they were not sent before the timeout.
+	Drain(timeout time.Duration) bool
+}
+
+type outbox struct {
+	client    WatchdogClient
+	state     store.State
+	scheduler scheduler.Scheduler
+	backoff   scheduler.Backoff
+	notifyCh  chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewOutbox returns an Outbox that sends complaints to the watchdog using
+// the client.
+func NewOutbox(client WatchdogClient, state store.State) Outbox {
+	outbox := &outbox{
+		client:   client,
+		state:    state,
+		backoff:  scheduler.DefaultBackoff,
+		notifyCh: make(chan struct{}, 1),
+		doneCh:   make(chan struct{}, 1),
+	}
+	outbox.scheduler = scheduler.NewScheduler(metrics.QueueWatchdog, 0, outbox.run)
+	return outbox
+}
+
+// Start schedules the queued complaints each time the outbox is notified,
+// and once a minute.
+func (outbox *outbox) Start() <-chan error {
+	errs := make(chan error)
+	log.Println("Starting the watchdog outbox......")
+	outbox.scheduler.Start(errs)
+	go func() {
+		defer log.Println("Stopping the watchdog outbox......")
+		defer outbox.scheduler.Stop()
+		ticker := time.NewTicker(resyncInterval)
+		defer ticker.Stop()
+		for {
+			if err := outbox.sync(); err != nil {
+				errs <- err
+			}
+			select {
+			case <-outbox.doneCh:
+				return
+			case <-outbox.notifyCh:
+			case <-ticker.C:
+			}
+		}
+	}()
+	return errs
+}
+
+func (outbox *outbox) Notify() {
+	select {
+	case outbox.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+func (outbox *outbox) Stop() {
+	select {
+	case outbox.doneCh <- struct{}{}:
+	default:
+	}
+}
+
+func (outbox *outbox) Drain(timeout time.Duration) bool {
+	outbox.Stop()
+	return outbox.scheduler.Drain(timeout)
+}
+
+// sync schedules every queued complaint for when it is due.
+func (outbox *outbox) sync() error {
+	messages, err := outbox.state.OutboxMessages()
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		if outbox.scheduler.InFlight(message.ID) {
+			continue
+		}
+		delay := time.Until(time.Unix(message.RetryAt, 0))
+		outbox.scheduler.ScheduleAfter(message.ID, message.QueuedAt, delay)
+	}
+	return nil
+}
+
+// run sends the complaint, and deletes it once the watchdog has acknowledged
+// it. A complaint that could not be sent is retried with backoff for as long
+// as it takes.
+func (outbox *outbox) run(id [32]byte) error {
+	message, err := outbox.state.OutboxMessage(id)
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	}
+
+	complaint := Complaint{}
+	if err := json.Unmarshal(message.Body, &complaint); err != nil {
+		return err
+	}
+
+	if err := outbox.client.Complain(complaint); err != nil {
+		metrics.ComplaintDeliveries.WithLabelValues("error").Inc()
+		message.Attempts++
+		message.LastError = err.Error()
+		delay := outbox.backoff.Delay(message.Attempts)
+		message.RetryAt = time.Now().Add(delay).Unix()
+		if putErr := outbox.state.PutOutboxMessage(message); putErr != nil {
+			return putErr
+		}
+		return scheduler.RetryAfter{
+			Delay:    delay,
+			Deadline: message.QueuedAt,
+			Err:      err,
+		}
+	}
+	metrics.ComplaintDeliveries.WithLabelValues("ok").Inc()
+	return outbox.state.DeleteOutboxMessage(id)
+}
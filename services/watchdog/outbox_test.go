@@ -0,0 +1,119 @@
+package watchdog_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	loggerAdapter "github.com/republicprotocol/renex-swapper-go/adapters/logger"
+	"github.com/republicprotocol/renex-swapper-go/adapters/store/memory"
+	swapDomain "github.com/republicprotocol/renex-swapper-go/domains/swap"
+	"github.com/republicprotocol/renex-swapper-go/services/store"
+	. "github.com/republicprotocol/renex-swapper-go/services/watchdog"
+)
+
+// mockClient records the complaints that it is sent, and fails to send them
+// while err is set.
+type mockClient struct {
+	mu         *sync.Mutex
+	complaints []Complaint
+	err        error
+}
+
+func (client *mockClient) Complain(complaint Complaint) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.err != nil {
+		return client.err
+	}
+	client.complaints = append(client.complaints, complaint)
+	return nil
+}
+
+func (client *mockClient) Ping() error {
+	return nil
+}
+
+func (client *mockClient) Complaints() []Complaint {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.complaints
+}
+
+var _ = Describe("Outbox", func() {
+	var state store.State
+	var client *mockClient
+	var outbox Outbox
+
+	complaint := Complaint{
+		Type:            WrongResponderInitiation,
+		PersonalOrderID: [32]byte{1},
+		ForeignOrderID:  [32]byte{2},
+		Evidence: Evidence{
+			Reason:       "the responder's contract failed the audit: Receive value is less than expected",
+			Expected:     "100",
+			Observed:     "90",
+			Transactions: swapDomain.Transactions{Initiate: "0x01"},
+		},
+		Time: 1530000000,
+	}
+
+	queue := func(complaint Complaint) {
+		tx := state.NewTransaction()
+		Expect(Queue(tx, complaint)).ShouldNot(HaveOccurred())
+		Expect(tx.Commit()).ShouldNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		state = store.NewState(memory.NewMemoryStore(), nil, loggerAdapter.NewStdOutLogger())
+		client = &mockClient{mu: new(sync.Mutex)}
+		outbox = NewOutbox(client, state)
+	})
+
+	AfterEach(func() {
+		Expect(outbox.Drain(time.Second)).Should(BeTrue())
+	})
+
+	It("sends the queued complaints and deletes them once they are acknowledged", func() {
+		queue(complaint)
+		go func() {
+			for range outbox.Start() {
+			}
+		}()
+
+		Eventually(client.Complaints).Should(Equal([]Complaint{complaint}))
+		Eventually(state.OutboxMessages).Should(BeEmpty())
+	})
+
+	It("replaces a complaint of the same type for the same swap", func() {
+		queue(complaint)
+		complaint.Time++
+		queue(complaint)
+
+		messages, err := state.OutboxMessages()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(messages).Should(HaveLen(1))
+	})
+
+	It("keeps a complaint that was not acknowledged to be retried", func() {
+		client.err = errors.New("connection refused")
+		queue(complaint)
+		errs := outbox.Start()
+
+		Eventually(errs).Should(Receive(MatchError("connection refused")))
+		messages, err := state.OutboxMessages()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(messages).Should(HaveLen(1))
+		Expect(messages[0].Attempts).Should(Equal(1))
+		Expect(messages[0].LastError).Should(Equal("connection refused"))
+		Expect(messages[0].RetryAt).Should(BeNumerically(">", time.Now().Unix()))
+		Expect(client.Complaints()).Should(BeEmpty())
+		go func() {
+			for range errs {
+			}
+		}()
+	})
+})
@@ -1,10 +1,11 @@
 package watchdog
 
+// WatchdogClient sends complaints to the watchdog.
 type WatchdogClient interface {
-	ComplainDelayedAddressSubmission([32]byte) error
-	ComplainDelayedRequestorInitiation([32]byte) error
-	ComplainWrongRequestorInitiation([32]byte) error
-	ComplainDelayedResponderInitiation([32]byte) error
-	ComplainWrongResponderInitiation([32]byte) error
-	ComplainDelayedRequestorRedemption([32]byte) error
+	// Complain sends the complaint to the watchdog, and returns nil once the
+	// watchdog has acknowledged it.
+	Complain(Complaint) error
+
+	// Ping checks that the watchdog can be reached.
+	Ping() error
 }